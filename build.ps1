@@ -118,7 +118,8 @@ switch($ManifestMethod){
 $env:GOOS = 'windows'
 $env:GOARCH = $Arch
 Log "Building stub (GOARCH=$Arch, method=$chosen)"
-go build -o $StubExe ./installer/stub
+# GUI 子系统：默认显示图形向导；/S 或 /CONSOLE 时附加到父进程控制台
+go build -ldflags "-H=windowsgui" -o $StubExe ./installer/stub
 
 if($ManifestMethod -eq 'mt' -or ($ManifestMethod -eq 'auto' -and $chosen -eq 'mt')){
   EmbedManifestMt
@@ -0,0 +1,250 @@
+package installer
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	uninstallMagic = "SFXUNINS" // 卸载程序：stub 本体 + 空归档
+	trailerSize    = 8 + 8
+)
+
+// Setup 是一个已生成的 setup（或卸载程序）的内容，读取时不会执行它
+type Setup struct {
+	Path       string
+	Size       int64
+	Magic      string // SFXMAGIC 或 SFXUNINS
+	StubSize   int64  // 不含归档与 trailer 的 stub 本体大小
+	StubArch   string // 从 stub 的 PE/ELF 头识别，无法识别时为空
+	ArchiveLen int64  // tar.gz 归档的字节数
+	MetaJSON   []byte // 原始 meta.json；卸载程序与损坏的 setup 为空
+	Files      []SetupFile
+}
+
+// SetupFile 归档中的一个文件
+type SetupFile struct {
+	Name   string `json:"name"`
+	Mode   int64  `json:"mode"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+	data   []byte
+}
+
+// fileDigest meta.json 中 files 的元素
+type fileDigest struct {
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// OpenSetup 按 stub 的 extractSelf 相同的方式解析 trailer 并读取归档。
+// trailer、gzip 或 tar 结构损坏时返回错误；内容是否与 meta.json 一致由 Verify 检查。
+func OpenSetup(p string) (*Setup, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	s := &Setup{Path: p, Size: int64(len(data))}
+	if len(data) < trailerSize {
+		return nil, fmt.Errorf("%s: file too small", p)
+	}
+	trailer := data[len(data)-trailerSize:]
+	s.Magic = string(trailer[8:])
+	if s.Magic != magicTrailer && s.Magic != uninstallMagic {
+		return nil, fmt.Errorf("%s: not a setup (magic %q)", p, s.Magic)
+	}
+	archiveLen := binary.LittleEndian.Uint64(trailer[:8])
+	if archiveLen > uint64(len(data)-trailerSize) {
+		return nil, fmt.Errorf("%s: invalid archive length %d", p, archiveLen)
+	}
+	s.ArchiveLen = int64(archiveLen)
+	s.StubSize = s.Size - trailerSize - s.ArchiveLen
+	s.StubArch = detectArch(data[:s.StubSize])
+	if s.ArchiveLen == 0 {
+		return s, nil
+	}
+
+	gzr, err := gzip.NewReader(bytes.NewReader(data[s.StubSize : s.StubSize+s.ArchiveLen]))
+	if err != nil {
+		return nil, fmt.Errorf("%s: archive: %w", p, err)
+	}
+	tr := tar.NewReader(gzr)
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: archive: %w", p, err)
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		body, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: archive %s: %w", p, h.Name, err)
+		}
+		sum := sha256.Sum256(body)
+		s.Files = append(s.Files, SetupFile{
+			Name:   h.Name,
+			Mode:   h.Mode,
+			Size:   int64(len(body)),
+			SHA256: hex.EncodeToString(sum[:]),
+			data:   body,
+		})
+		if h.Name == "meta.json" {
+			s.MetaJSON = body
+		}
+	}
+	// tar 结束标记之后还有填充，读完 gzip 流才会校验 CRC-32
+	if _, err := io.Copy(io.Discard, gzr); err != nil {
+		return nil, fmt.Errorf("%s: archive: %w", p, err)
+	}
+	sort.Slice(s.Files, func(i, j int) bool { return s.Files[i].Name < s.Files[j].Name })
+	return s, nil
+}
+
+// Meta 解析 meta.json
+func (s *Setup) Meta() (map[string]any, error) {
+	if s.MetaJSON == nil {
+		return nil, errors.New("meta.json not found")
+	}
+	var m map[string]any
+	if err := json.Unmarshal(s.MetaJSON, &m); err != nil {
+		return nil, fmt.Errorf("meta.json: %w", err)
+	}
+	return m, nil
+}
+
+// Extract 将名称匹配 patterns（path.Match 通配符，或目录前缀如 "hooks/"）的文件写入 dir；
+// patterns 为空时写出全部文件。返回写出的文件数，没有任何文件匹配时返回错误。
+func (s *Setup) Extract(dir string, patterns ...string) (int, error) {
+	n := 0
+	for _, f := range s.Files {
+		if len(patterns) > 0 && !matchAny(f.Name, patterns) {
+			continue
+		}
+		dst, err := safeJoin(dir, f.Name)
+		if err != nil {
+			return n, err
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return n, err
+		}
+		if err := os.WriteFile(dst, f.data, os.FileMode(f.Mode).Perm()|0o600); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if n == 0 && len(patterns) > 0 {
+		return 0, fmt.Errorf("no files match %s", strings.Join(patterns, ", "))
+	}
+	return n, nil
+}
+
+func matchAny(name string, patterns []string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok || name == p || strings.HasSuffix(p, "/") && strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// safeJoin 将归档内路径拼接到 dir 下，拒绝绝对路径与 ".." 逃逸
+func safeJoin(dir, name string) (string, error) {
+	rel, err := archivePath(name)
+	if err != nil {
+		return "", fmt.Errorf("unsafe path in archive: %q", name)
+	}
+	return filepath.Join(dir, filepath.FromSlash(rel)), nil
+}
+
+// Verify 检查 setup 的完整性：meta.json 可解析、主程序与引用的文件存在，
+// 且每个文件与 meta.json 中记录的大小和 SHA-256 一致。返回发现的全部问题。
+func (s *Setup) Verify() error {
+	if s.Magic == uninstallMagic {
+		if s.ArchiveLen != 0 {
+			return errors.New("uninstaller carries an archive")
+		}
+		return nil
+	}
+	if s.StubArch == "" {
+		return errors.New("stub is not a recognized PE/ELF executable")
+	}
+	if _, err := s.Meta(); err != nil {
+		return err
+	}
+	var meta struct {
+		ExeName     string                `json:"exeName"`
+		LicenseFile string                `json:"licenseFile"`
+		Arches      []string              `json:"arches"`
+		Files       map[string]fileDigest `json:"files"`
+	}
+	if err := json.Unmarshal(s.MetaJSON, &meta); err != nil {
+		return fmt.Errorf("meta.json: %w", err)
+	}
+
+	var errs []error
+	byName := map[string]SetupFile{}
+	for _, f := range s.Files {
+		byName[f.Name] = f
+	}
+	required := []string{}
+	if len(meta.Arches) == 0 {
+		required = append(required, meta.ExeName)
+	}
+	for _, a := range meta.Arches {
+		required = append(required, "arch/"+a+"/"+meta.ExeName)
+	}
+	if meta.LicenseFile != "" {
+		required = append(required, meta.LicenseFile)
+	}
+	for _, name := range required {
+		_, listed := meta.Files[name] // 清单中的文件在下面检查
+		if _, ok := byName[name]; !ok && !listed {
+			errs = append(errs, fmt.Errorf("%s: missing", name))
+		}
+	}
+
+	if meta.Files == nil {
+		// 旧版本打包的 setup 没有文件清单，只能检查容器结构
+		return errors.Join(append(errs, errors.New("meta.json has no file hashes"))...)
+	}
+	names := make([]string, 0, len(meta.Files))
+	for name := range meta.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		d := meta.Files[name]
+		f, ok := byName[name]
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Errorf("%s: missing", name))
+		case f.Size != d.Size:
+			errs = append(errs, fmt.Errorf("%s: size %d, expected %d", name, f.Size, d.Size))
+		case f.SHA256 != d.SHA256:
+			errs = append(errs, fmt.Errorf("%s: sha256 %s, expected %s", name, f.SHA256, d.SHA256))
+		}
+	}
+	for _, f := range s.Files {
+		if _, ok := meta.Files[f.Name]; !ok && f.Name != "meta.json" {
+			errs = append(errs, fmt.Errorf("%s: not listed in meta.json", f.Name))
+		}
+	}
+	return errors.Join(errs...)
+}
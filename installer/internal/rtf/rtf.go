@@ -0,0 +1,188 @@
+// Package rtf 提取 RTF 许可协议的正文，供安装程序显示与打包时校验共用，
+// 保证两者对目标组、替代字符与代码页的处理一致。
+package rtf
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Document RTF 的解析结果
+type Document struct {
+	Text     string // 正文
+	CodePage int    // \ansicpgN 声明的 ANSI 代码页，未声明时为 1252
+	ANSIText bool   // 正文中含有非 ASCII 的 \'hh 字符（按 cp1252 解码），CodePage 不是 1252 时显示为乱码
+}
+
+// skipDestinations 不包含正文的 RTF 目标组
+var skipDestinations = map[string]bool{
+	"fonttbl": true, "colortbl": true, "stylesheet": true, "info": true, "pict": true,
+	"header": true, "footer": true, "headerl": true, "headerr": true, "footerl": true,
+	"footerr": true, "listtable": true, "listoverridetable": true, "rsidtbl": true,
+	"generator": true, "xmlnstbl": true, "themedata": true, "colorschememapping": true,
+	"latentstyles": true, "datastore": true, "object": true, "fldinst": true,
+}
+
+// Parse 提取 RTF 正文：处理分组、段落/换行/制表符、\uN Unicode 与 \'hh 字符（按 cp1252 解码），
+// 忽略字体表、样式表、图片等目标组以及 \uN 之后的替代字符
+func Parse(data []byte) Document {
+	type group struct {
+		skip bool
+		uc   int // \ucN：\uN 之后需要跳过的替代字符数
+	}
+	var (
+		out      strings.Builder
+		stack    []group
+		cur      = group{uc: 1}
+		skipNext int // 待跳过的替代字符
+		doc      = Document{CodePage: 1252}
+	)
+	// emit 输出正文，返回 s 是否属于正文（不是替代字符且不在跳过的目标组中）
+	emit := func(s string) bool {
+		if skipNext > 0 {
+			skipNext--
+			return false
+		}
+		if cur.skip {
+			return false
+		}
+		out.WriteString(s)
+		return true
+	}
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch c {
+		case '{':
+			stack = append(stack, cur)
+			skipNext = 0
+		case '}':
+			if len(stack) > 0 {
+				cur = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+			skipNext = 0
+		case '\r', '\n':
+		case '\\':
+			if i+1 >= len(data) {
+				break
+			}
+			n := data[i+1]
+			switch {
+			case n == '\\' || n == '{' || n == '}':
+				emit(string(n))
+				i++
+			case n == '\'':
+				if i+3 < len(data) {
+					if v, err := strconv.ParseUint(string(data[i+2:i+4]), 16, 8); err == nil {
+						if emit(DecodeCP1252([]byte{byte(v)})) && v >= 0x80 {
+							doc.ANSIText = true
+						}
+					}
+				}
+				i += 3
+			case n == '*':
+				cur.skip = true
+				i++
+			case n == '~':
+				emit(" ")
+				i++
+			case n == '-' || n == '_':
+				i++
+			case isLetter(n):
+				j := i + 1
+				for j < len(data) && isLetter(data[j]) {
+					j++
+				}
+				word := string(data[i+1 : j])
+				k := j
+				if k < len(data) && (data[k] == '-' || data[k] >= '0' && data[k] <= '9') {
+					k++
+					for k < len(data) && data[k] >= '0' && data[k] <= '9' {
+						k++
+					}
+				}
+				param, hasParam := 0, k > j
+				if hasParam {
+					param, _ = strconv.Atoi(string(data[j:k]))
+				}
+				if k < len(data) && data[k] == ' ' {
+					k++ // 控制字后的分隔空格
+				}
+				i = k - 1
+
+				switch {
+				case skipDestinations[word]:
+					cur.skip = true
+				case word == "par" || word == "line" || word == "sect" || word == "page":
+					emit("\n")
+				case word == "tab":
+					emit("\t")
+				case word == "ansicpg" && hasParam:
+					doc.CodePage = param
+				case word == "uc" && hasParam:
+					cur.uc = param
+				case word == "u" && hasParam:
+					if param < 0 {
+						param += 65536
+					}
+					emit(string(rune(param)))
+					skipNext = cur.uc
+				case word == "emdash":
+					emit("—")
+				case word == "endash":
+					emit("–")
+				case word == "bullet":
+					emit("•")
+				case word == "lquote":
+					emit("‘")
+				case word == "rquote":
+					emit("’")
+				case word == "ldblquote":
+					emit("“")
+				case word == "rdblquote":
+					emit("”")
+				}
+			default:
+				i++
+			}
+		default:
+			if c < utf8.RuneSelf {
+				emit(string(c))
+				break
+			}
+			// 标准 RTF 为 7 位文本；个别生成器直接写入 UTF-8
+			r, size := utf8.DecodeRune(data[i:])
+			if r == utf8.RuneError {
+				emit(DecodeCP1252([]byte{c}))
+				break
+			}
+			emit(string(r))
+			i += size - 1
+		}
+	}
+	doc.Text = strings.TrimSpace(out.String())
+	return doc
+}
+
+func isLetter(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' }
+
+// cp1252 0x80-0x9F 区间与 Latin-1 不同的字符
+var cp1252High = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
+
+// DecodeCP1252 按 Windows-1252 解码单字节文本
+func DecodeCP1252(data []byte) string {
+	var b strings.Builder
+	for _, c := range data {
+		if c >= 0x80 && c < 0xA0 {
+			b.WriteRune(cp1252High[c-0x80])
+		} else {
+			b.WriteRune(rune(c))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,39 @@
+package rtf
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		rtf      string
+		text     string
+		codePage int
+		ansi     bool
+	}{
+		{"plain", `{\rtf1\ansi Hello\par World\tab!}`, "Hello\nWorld\t!", 1252, false},
+		{"cp1252 escape", `{\rtf1\ansi\ansicpg1252 Caf\'e9 \'93q\'94}`, "Café “q”", 1252, true},
+		{"escaped symbols", `{\rtf1 a\\b\{c\}\~d\-e}`, "a\\b{c}\u00a0de", 1252, false},
+		{"unicode with fallback", `{\rtf1\ansi\ansicpg936\uc2 \u35768\'d0\'ed\u21487\'bf\'c9}`, "许可", 936, false},
+		{"unicode negative", `{\rtf1\uc1 \u-3913?}`, "", 1252, false},
+		{"uc scoped to group", `{\rtf1{\uc2 \u35768xx}\u21487?}`, "许可", 1252, false},
+		{"font table and stylesheet skipped",
+			`{\rtf1\ansi\ansicpg936{\fonttbl{\f0\fcharset134 \'cb\'ce\'cc\'e5;}}{\stylesheet{\s1 Heading;}}\f0 \u35768?}`,
+			"许", 936, false},
+		{"ignorable destination skipped", `{\rtf1{\*\generator Riched20;}{\*\userprops x}Body}`, "Body", 1252, false},
+		{"info skipped", `{\rtf1{\info{\title T\'e9}}Body}`, "Body", 1252, false},
+		{"ansi text in body", `{\rtf1\ansi\ansicpg936 \'d0\'ed}`, "Ðí", 936, true},
+		{"raw utf-8", "{\\rtf1 許可}", "許可", 1252, false},
+	}
+	for _, tt := range tests {
+		doc := Parse([]byte(tt.rtf))
+		if doc.Text != tt.text || doc.CodePage != tt.codePage || doc.ANSIText != tt.ansi {
+			t.Errorf("%s: Parse = %+v, want text %q code page %d ansi %v", tt.name, doc, tt.text, tt.codePage, tt.ansi)
+		}
+	}
+}
+
+func TestDecodeCP1252(t *testing.T) {
+	if got := DecodeCP1252([]byte{'a', 0x80, 0x93, 0xE9, 0x81}); got != "a€“é\u0081" {
+		t.Errorf("DecodeCP1252 = %q", got)
+	}
+}
@@ -0,0 +1,152 @@
+// Package version 解析与比较版本号，供安装程序的版本策略、系统与前置组件检测以及打包时校验共用，
+// 保证各处对 "1.0" 与 "1.0.0"、预发布版与四段版本的判断一致。
+package version
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Version 解析后的版本：语义化版本（1.2.3、1.2.3-beta.1+build.5）
+// 或 Windows 四段版本（1.2.3.4）；段数不足的按 0 补齐后比较
+type Version struct {
+	Nums []int
+	Pre  []string // 预发布标识，为空表示正式版
+}
+
+// ErrInvalid 版本号不是 1 至 4 段数字加可选的预发布标识与构建元数据
+var ErrInvalid = errors.New("invalid version")
+
+// Parse 解析产品版本；前缀 v 与构建元数据（+ 之后）被忽略
+func Parse(s string) (Version, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	s, build, hasBuild := strings.Cut(s, "+")
+	if hasBuild && !validIdents(strings.Split(build, ".")) {
+		return Version{}, ErrInvalid
+	}
+	core, pre, hasPre := strings.Cut(s, "-")
+	var v Version
+	parts := strings.Split(core, ".")
+	if len(parts) > 4 {
+		return Version{}, ErrInvalid
+	}
+	for _, p := range parts {
+		if p == "" || strings.TrimLeft(p, "0123456789") != "" {
+			return Version{}, ErrInvalid
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return Version{}, ErrInvalid
+		}
+		v.Nums = append(v.Nums, n)
+	}
+	if hasPre {
+		v.Pre = strings.Split(pre, ".")
+		if !validIdents(v.Pre) {
+			return Version{}, ErrInvalid
+		}
+	}
+	return v, nil
+}
+
+// validIdents 预发布标识与构建元数据只能由字母、数字与 - 组成且不能为空
+func validIdents(ids []string) bool {
+	for _, id := range ids {
+		if id == "" {
+			return false
+		}
+		for _, r := range id {
+			if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ParseNumbers 只取开头以 . 分隔的数字段，用于系统、文件与注册表中的版本号：
+// "6.1.0-13-amd64" 之类的后缀是发行版或构建标识而不是预发布版，不参与比较。无法解析时为空版本（0）。
+func ParseNumbers(s string) Version {
+	var v Version
+	for _, part := range strings.Split(strings.TrimPrefix(strings.TrimSpace(s), "v"), ".") {
+		end := 0
+		for end < len(part) && part[end] >= '0' && part[end] <= '9' {
+			end++
+		}
+		n, err := strconv.Atoi(part[:end])
+		if err != nil {
+			break
+		}
+		v.Nums = append(v.Nums, n)
+		if end < len(part) {
+			break
+		}
+	}
+	return v
+}
+
+// Major 主版本号
+func (v Version) Major() int { return at(v.Nums, 0) }
+
+// Minor 次版本号
+func (v Version) Minor() int { return at(v.Nums, 1) }
+
+// Compare 按语义化版本的优先级比较：数字段逐段比较，相同时预发布版低于正式版，
+// 预发布标识逐个比较（数字按数值，其余按字典序，数字低于非数字，较短者较低）
+func (v Version) Compare(o Version) int {
+	for i := 0; i < len(v.Nums) || i < len(o.Nums); i++ {
+		if c := compareInt(at(v.Nums, i), at(o.Nums, i)); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(v.Pre) == 0 && len(o.Pre) == 0:
+		return 0
+	case len(v.Pre) == 0:
+		return 1
+	case len(o.Pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.Pre) && i < len(o.Pre); i++ {
+		a, errA := strconv.Atoi(v.Pre[i])
+		b, errB := strconv.Atoi(o.Pre[i])
+		var c int
+		switch {
+		case errA == nil && errB == nil:
+			c = compareInt(a, b)
+		case errA == nil:
+			c = -1
+		case errB == nil:
+			c = 1
+		default:
+			c = strings.Compare(v.Pre[i], o.Pre[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return compareInt(len(v.Pre), len(o.Pre))
+}
+
+// CompareNumbers 以 ParseNumbers 解析 a 与 b 后比较
+func CompareNumbers(a, b string) int {
+	return ParseNumbers(a).Compare(ParseNumbers(b))
+}
+
+func at(nums []int, i int) int {
+	if i < len(nums) {
+		return nums[i]
+	}
+	return 0
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
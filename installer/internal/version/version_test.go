@@ -0,0 +1,108 @@
+package version
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		nums    []int
+		pre     []string
+		wantErr bool
+	}{
+		{"1", []int{1}, nil, false},
+		{"1.2.3", []int{1, 2, 3}, nil, false},
+		{"v1.2.3", []int{1, 2, 3}, nil, false},
+		{"1.2.3.4", []int{1, 2, 3, 4}, nil, false},
+		{"1.2.3-beta.1", []int{1, 2, 3}, []string{"beta", "1"}, false},
+		{"1.2.3-rc-1+build.5", []int{1, 2, 3}, []string{"rc-1"}, false},
+		{"1.2.3+20240101", []int{1, 2, 3}, nil, false},
+		{"", nil, nil, true},
+		{"1.2.3.4.5", nil, nil, true},
+		{"1..2", nil, nil, true},
+		{"1.+2", nil, nil, true},
+		{"1.2.x", nil, nil, true},
+		{"1.2.3-", nil, nil, true},
+		{"1.2.3-beta..1", nil, nil, true},
+		{"1.2.3-beta_1", nil, nil, true},
+		{"1.2.3+", nil, nil, true},
+	}
+	for _, tt := range tests {
+		v, err := Parse(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) err = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (!reflect.DeepEqual(v.Nums, tt.nums) || !reflect.DeepEqual(v.Pre, tt.pre)) {
+			t.Errorf("Parse(%q) = %+v, want nums %v pre %v", tt.in, v, tt.nums, tt.pre)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0", "1.0.0", 0},
+		{"1.0.0.0", "1.0", 0},
+		{"1.0.0+build.1", "1.0.0+build.2", 0},
+		{"1.2.3", "1.10.0", -1},
+		{"2.0.0", "1.99.99", 1},
+		{"1.2.3.4", "1.2.3.10", -1},
+		{"1.2.3.1", "1.2.3", 1},
+		// 语义化版本规范中的优先级示例
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"1.0.0-alpha.beta", "1.0.0-beta", -1},
+		{"1.0.0-beta", "1.0.0-beta.2", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{"1.0.0-beta.11", "1.0.0-rc.1", -1},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0.0", "1.0.0-rc.1", 1},
+		{"1.0.0-rc.1", "0.9.9", 1},
+	}
+	for _, tt := range tests {
+		a, err1 := Parse(tt.a)
+		b, err2 := Parse(tt.b)
+		if err1 != nil || err2 != nil {
+			t.Fatalf("Parse(%q, %q): %v, %v", tt.a, tt.b, err1, err2)
+		}
+		if got := a.Compare(b); got != tt.want {
+			t.Errorf("Compare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestCompareNumbers(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"10.0.19045", "10.0.17763", 1},
+		{"6.1.0-13-amd64", "6.1", 0},
+		{"5.15.0-91-generic", "6.1", -1},
+		{"6.8.0rc1", "6.8", 0},
+		{"14.38.33130.00", "14.40.33810", -1},
+		{"v2.0", "2", 0},
+		{"", "1.0", -1},
+		{"unknown", "0", 0},
+	}
+	for _, tt := range tests {
+		if got := CompareNumbers(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareNumbers(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestMajorMinor(t *testing.T) {
+	v, err := Parse("3-beta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Major() != 3 || v.Minor() != 0 {
+		t.Errorf("Major, Minor = %d, %d, want 3, 0", v.Major(), v.Minor())
+	}
+}
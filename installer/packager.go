@@ -4,13 +4,26 @@ import (
 	"archive/tar"
 	"bytes"
 	"compress/gzip"
+	"crypto/sha256"
+	"debug/elf"
+	"debug/pe"
 	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+
+	"exe_installer/installer/internal/rtf"
+	"exe_installer/installer/internal/version"
 )
 
 const magicTrailer = "SFXMAGIC"
@@ -18,11 +31,308 @@ const magicTrailer = "SFXMAGIC"
 type Options struct {
 	ProductName             string
 	ExeName                 string
-	InstallDir              string
+	InstallDir              string // 默认安装目录模板，见 InstallDirTokens；为空时 Windows 为 {ProgramFiles}\{ProductName}
 	CreateDesktopShortcut   bool
 	CreateStartMenuShortcut bool
-	Version                 string
+	Version                 string // 语义化版本（1.2.3、1.2.3-beta.1）或四段版本（1.2.3.4），与已安装版本比较
 	ShortcutName            string // 新增：快捷方式显示名称（为空则使用 ProductName）
+
+	// 以下字段写入“应用和功能”(Uninstall 注册表键)，均可为空
+	Publisher     string
+	URLInfoAbout  string
+	HelpLink      string
+	URLUpdateInfo string
+	Contact       string
+	Comments      string
+	Language      uint32 // LCID，例如 2052 (zh-CN)、1033 (en-US)；0 表示不写入
+	DisplayIcon   string // 相对安装目录的图标文件，可带 ",索引"；为空则使用 ExeName
+
+	Hooks []Hook // 安装/卸载各阶段执行的命令
+
+	// CloseAppsTimeout 安装前请求安装目录中运行的程序退出后等待的时间，超时则强制结束；0 表示 15 秒
+	CloseAppsTimeout time.Duration
+
+	// Translations 额外的界面语言：语言代码（如 "de"、"pt-BR"）-> 本地 JSON 翻译文件路径。
+	// 文件内容为 {"key": "文本"}，key 与 stub 内置英文目录一致，缺失的 key 回退到英文。
+	// 内置 en 与 zh，同名代码会覆盖内置文本。
+	Translations map[string]string
+
+	// LicenseFile 许可协议文件（.txt、.md 或 .rtf），安装前必须接受（静默安装需 /ACCEPTEULA），
+	// 并以原文件名复制到安装目录
+	LicenseFile string
+
+	// Components 可选组件；未归入任何组件的文件（主程序等）总是安装
+	Components []Component
+
+	// Arch 主程序的 CPU 架构（amd64、386、arm64，与 build.ps1 的 -Arch 一致）；
+	// 为空时从 payload 的 PE/ELF 头自动识别，识别不了则不检查
+	Arch string
+	// MinOSVersion 最低系统版本：Windows 为 "10.0.17763" 形式，Linux 为内核版本；为空不检查
+	MinOSVersion string
+
+	// Payloads 按架构提供的主程序：架构（amd64、386、arm64、arm）-> 本地文件，
+	// 用于 CreateUniversalInstaller 与 CreateArchInstallers；各变体安装后均名为 ExeName
+	Payloads map[string]string
+	// ArchFallback 通用 setup 选择主程序的顺序：本机架构 -> 依次尝试的变体架构。
+	// 未配置的本机架构按 本机架构、可模拟运行的架构（64 位优先）的顺序选择
+	ArchFallback map[string][]string
+
+	// EntryPoints 安装目录中的可执行入口（主程序、辅助程序），各平台可使用不同文件名；
+	// 为空时只有一个由 ExeName 指定的主程序。打包时校验 stub 所属平台的入口文件都在归档中
+	EntryPoints []EntryPoint
+
+	// RunAfterInstall 完成页“运行程序”选项：启动的入口、参数与工作目录
+	RunAfterInstall RunAfterInstall
+
+	// VersionPolicy 已安装其他版本或相同版本时的处理
+	VersionPolicy VersionPolicy
+
+	// SecondInstance 同一产品的安装、卸载或维护程序已在运行时的处理：SecondInstanceWait、
+	// SecondInstanceFocus 或 SecondInstanceExit；为空时交互运行为 focus，静默运行为 wait
+	SecondInstance        string
+	SecondInstanceTimeout time.Duration // wait 的最长等待时间，0 表示默认 10 分钟
+
+	// Environment 安装时设置的环境变量与加入 PATH 等列表的目录，卸载时只撤销本次安装所做的修改
+	Environment []EnvVar
+
+	// Services 作为后台服务运行的入口。升级时替换文件前先停止，写入后注册（已存在则更新配置）并启动；
+	// 不再声明的服务与卸载时的服务会被删除
+	Services []Service
+
+	// Autostart 登录时启动或按计划运行的入口。向导目录页显示“自动启动”复选框（默认值见 AutostartChoice），
+	// 命令行 /AUTOSTART 强制注册，/AUTOSTART=0 不注册；升级时沿用上次的选择。卸载时删除
+	Autostart       []Autostart
+	AutostartChoice string // AutostartChecked（默认）、AutostartUnchecked 或 AutostartAlways
+
+	// CachePayload 在安装目录保存一份归档（payload.cache，约为 setup 的大小），
+	// 修复与修改时无需再次提供 setup
+	CachePayload bool
+
+	// Portable 生成便携版：运行时不安装，解压到临时目录运行主程序（参数原样传递），退出后删除；
+	// 便携版不运行前置组件安装程序、钩子，也不创建快捷方式与注册表项
+	Portable bool
+
+	// Prerequisites 安装主程序前按顺序检测并安装缺少的前置组件（VC++ 运行库、WebView2 等）
+	Prerequisites []Prerequisite
+}
+
+// Prerequisite 一个前置组件：Detect 判断已安装时跳过，否则以 Args 运行其安装程序。
+// Bundled 为 true 时 Command 是打包机上的本地文件，会被打包进 setup 的 prereqs/ 目录（不会复制到安装目录）；
+// 否则 Command 为绝对路径或相对 setup 所在目录的路径（与 setup 一起分发）。
+type Prerequisite struct {
+	Name         string // 显示名称
+	Command      string
+	Bundled      bool
+	Args         []string // 静默安装参数，例如 /install /quiet /norestart
+	Detect       DetectRule
+	SuccessCodes []int         // 表示成功的退出码；为空时为 0
+	RebootCodes  []int         // 表示成功但需要重启的退出码；为空时 Windows 为 3010、1641，Linux 不认任何退出码
+	Timeout      time.Duration // 0 表示默认 30 分钟
+	Optional     bool          // 安装失败时继续安装主程序
+}
+
+// DetectRule 前置组件的检测规则，至少设置 File 或 RegistryKey，设置的条件须全部满足；
+// 路径中可使用 %ProgramFiles% 等环境变量（$ 按字面处理）。
+// MinVersion 与注册表值比较；未设置注册表时与 File 的文件版本比较（仅 Windows）。
+type DetectRule struct {
+	File          string // 存在即视为已安装
+	RegistryKey   string // 例如 `HKLM\SOFTWARE\Microsoft\VisualStudio\14.0\VC\Runtimes\x64`
+	RegistryValue string // 为空时只检查键是否存在；值为 "0" 视为未安装
+	MinVersion    string // 例如 "14.40.33810"
+}
+
+// EntryPoint 一个可执行入口。Main 入口用于快捷方式、注册表、安装后启动与便携版，有且只有一个，且不能属于可选组件；
+// 其他入口可以属于可选组件，未选该组件时不安装。
+type EntryPoint struct {
+	Name    string // 标识，例如 "main"、"updater"
+	Windows string // Windows 上相对安装目录的文件，例如 myproject.exe
+	Linux   string // Linux 上的文件，例如 myproject；为空时为 Windows 去掉 .exe
+	Main    bool
+}
+
+// RunAfterInstall 安装完成后启动的程序。安装程序以管理员身份运行时，以登录的非管理员用户身份启动，
+// 避免程序把设置写入管理员的用户目录。命令行 /LAUNCH 强制启动（静默安装也会启动），/LAUNCH=0 不启动。
+type RunAfterInstall struct {
+	EntryPoint string   // 入口名称，为空时为 Main 入口
+	Args       []string // 可使用 {InstallDir}、{ProductName}、{Version}
+	WorkingDir string   // 相对安装目录，可使用同样的占位符；为空时为安装目录
+	Unchecked  bool     // 完成页默认不勾选
+	Disabled   bool     // 不提供该选项，/LAUNCH 也不会启动
+}
+
+// EnvVar 一个环境变量修改。Windows 写入注册表 Environment 键（PATH 等保持 REG_EXPAND_SZ）并广播设置变化；
+// Linux 写入 /etc/profile.d/<产品>.sh（系统范围）或 ~/.config/environment.d/60-<产品>.conf（用户范围）。
+// 卸载时 set 仅在值未被他人修改时恢复原值，append/prepend 只移除本次加入的列表项。
+type EnvVar struct {
+	Name    string // 变量名，例如 PATH、MYAPP_HOME
+	Value   string // 可使用 {InstallDir}、{ProductName}、{Version}，例如 {InstallDir}/bin
+	Action  string // EnvSet（默认）、EnvAppend 或 EnvPrepend
+	Machine bool   // 系统范围（需要管理员权限）；默认为当前用户
+}
+
+// 环境变量的修改方式，用于 EnvVar.Action
+const (
+	EnvSet     = "set"     // 设置为 Value，覆盖原值
+	EnvAppend  = "append"  // 将 Value 加到列表末尾（Windows 以 ; 分隔，Linux 以 : 分隔），已存在时不重复添加
+	EnvPrepend = "prepend" // 将 Value 加到列表开头
+)
+
+// Service 一个服务。Windows 注册到服务控制管理器（需要管理员权限）；
+// Linux 生成 systemd --user 单元（~/.config/systemd/user/<Name>.service），忽略 Account。
+type Service struct {
+	Name         string // 服务名，也是 systemd 单元名（不含 .service）
+	DisplayName  string // 为空时为 Name
+	Description  string
+	EntryPoint   string   // 入口名称，为空时为 Main 入口
+	Args         []string // 可使用 {InstallDir}、{ProductName}、{Version}
+	StartType    string   // ServiceAuto（默认）、ServiceDelayed、ServiceManual 或 ServiceDisabled
+	Account      string   // Windows 运行账户，例如 `NT AUTHORITY\LocalService`；为空时为 LocalSystem。不支持需要密码的账户
+	Dependencies []string // 依赖的服务名
+	Recovery     []ServiceRecovery
+	ResetPeriod  time.Duration // 失败计数清零的时间
+	NoStart      bool          // 安装后不启动（升级前正在运行的仍会重新启动）
+}
+
+// ServiceRecovery 服务失败后的恢复操作，依次对应第一次、第二次与之后的失败；
+// Linux 只支持第一项的 ServiceRestart
+type ServiceRecovery struct {
+	Action string // ServiceRestart、ServiceReboot 或 ServiceNoAction
+	Delay  time.Duration
+}
+
+// 服务的启动类型，用于 Service.StartType
+const (
+	ServiceAuto     = "auto"
+	ServiceDelayed  = "delayed" // 其他自动启动的服务之后延迟启动；Linux 与 auto 相同
+	ServiceManual   = "manual"
+	ServiceDisabled = "disabled"
+)
+
+// 服务失败后的恢复操作，用于 ServiceRecovery.Action
+const (
+	ServiceNoAction = "none"
+	ServiceRestart  = "restart"
+	ServiceReboot   = "reboot"
+)
+
+// Autostart 一个自动启动项。登录时启动：Windows 写入 HKCU Run 键或“启动”文件夹快捷方式，
+// Linux 写入 ~/.config/autostart/<Name>.desktop。设置了 Daily 或 Interval 时改为按计划运行：
+// Windows 为计划任务 <ProductName>\<Name>，Linux 为 systemd --user 定时器 <Name>-task.timer。
+type Autostart struct {
+	Name          string        // Run 值名、快捷方式名、任务名或文件名；为空时为 ProductName
+	EntryPoint    string        // 入口名称，为空时为 Main 入口
+	Args          []string      // 可使用 {InstallDir}、{ProductName}、{Version}
+	StartupFolder bool          // Windows 使用“启动”文件夹快捷方式而不是 Run 键
+	Daily         string        // "HH:MM"：每天此时运行
+	Interval      time.Duration // 每隔多长时间运行：整分钟，小于 24 小时或 24 小时的整数倍
+}
+
+// “自动启动”复选框的默认值，用于 Options.AutostartChoice
+const (
+	AutostartChecked   = "checked"
+	AutostartUnchecked = "unchecked"
+	AutostartAlways    = "always" // 不显示复选框，始终注册（/AUTOSTART=0 仍可跳过）
+)
+
+// 另一个实例正在运行时的处理，用于 Options.SecondInstance。未能运行时以 1618（ERROR_INSTALL_ALREADY_RUNNING）退出。
+const (
+	SecondInstanceWait  = "wait"  // 等待其结束后继续
+	SecondInstanceFocus = "focus" // 将其向导窗口置于前台后退出（找不到窗口时提示后退出）
+	SecondInstanceExit  = "exit"  // 提示后退出
+)
+
+// 相同版本已安装时的处理，用于 VersionPolicy.SameVersion
+const (
+	SameVersionReinstall = "reinstall" // 默认：重新安装
+	SameVersionRepair    = "repair"    // 只恢复缺失或损坏的文件（见 /REPAIR）
+	SameVersionAsk       = "ask"       // 让用户选择修复、重新安装或取消；静默安装时重新安装
+	SameVersionBlock     = "block"     // 不安装，以 1638 退出
+)
+
+// VersionPolicy 根据安装记录中的已安装版本决定能否安装。被拒绝时以 1638（ERROR_PRODUCT_VERSION）退出。
+type VersionPolicy struct {
+	AllowDowngrade           bool   // 默认禁止安装比已安装版本低的版本
+	SameVersion              string // 为空时为 SameVersionReinstall
+	UninstallOlderMajorFirst bool   // 已安装的主版本较低时要求先卸载旧版本
+}
+
+// InstallDirTokens 是 Options.InstallDir 中可用的 {名称}，在安装时按平台解析。
+// ProgramFiles 与主程序架构匹配（32 位主程序为 Program Files (x86)），Linux 上 ProgramFiles* 均为 /opt；
+// LocalAppData 与 XDG_DATA_HOME 在两个平台上互为对应。
+var InstallDirTokens = []string{
+	"ProgramFiles", "ProgramFiles64", "ProgramFilesX86", "LocalAppData", "AppData",
+	"UserHome", "XDG_DATA_HOME", "Desktop", "ProductName", "Version", "Publisher",
+}
+
+var templateToken = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// validateInstallDir 检查安装目录模板只使用已知的 {名称}
+func validateInstallDir(tmpl string) error {
+	for _, m := range templateToken.FindAllStringSubmatch(tmpl, -1) {
+		if !slices.Contains(InstallDirTokens, m[1]) {
+			return fmt.Errorf("installDir: unknown token {%s}", m[1])
+		}
+	}
+	return nil
+}
+
+// Component 一组可由用户选择安装的文件、快捷方式与注册表值。
+// 选择某组件时会同时选择 Depends 中的组件；Required 组件总是安装。
+type Component struct {
+	Name        string            // 唯一标识，用于 /COMPONENTS=a,b，不能包含逗号
+	Description string            // 组件页中显示的说明
+	Files       map[string]string // 安装目录内的相对路径 -> 打包机上的本地文件
+	Shortcuts   []Shortcut
+	Registry    []RegistryValue
+	Default     bool // 首次安装时默认选中
+	Required    bool
+	Depends     []string
+}
+
+// Shortcut 组件附带的快捷方式（仅 Windows）
+type Shortcut struct {
+	Name      string // 快捷方式显示名称
+	Target    string // 相对安装目录的目标文件
+	Desktop   bool
+	StartMenu bool // 位于开始菜单的产品目录中
+}
+
+// RegistryValue 组件附带的注册表值（仅 Windows，位于 HKCU）；Value 中可使用 {InstallDir}、{ProductName}、{Version}
+type RegistryValue struct {
+	Key   string // 相对 HKEY_CURRENT_USER，例如 `Software\Classes\.myext`
+	Name  string // 为空表示默认值
+	Value string
+	Type  string // "string"（默认）、"expand" 或 "dword"
+}
+
+// 钩子阶段
+const (
+	HookPreInstall    = "pre-install"    // 清理旧文件之前，例如停止正在运行的服务
+	HookPostInstall   = "post-install"   // 文件写入之后、创建快捷方式与注册信息之前
+	HookPreUninstall  = "pre-uninstall"  // 卸载删除任何内容之前
+	HookPostUninstall = "post-uninstall" // 卸载删除文件之后
+)
+
+// 钩子失败（非零退出码、超时、无法启动）时的处理策略
+const (
+	HookFailAbort    = "abort"    // 默认：停止后续步骤；post-install 阶段文件已替换，同 HookFailRollback
+	HookFailRollback = "rollback" // 停止并恢复安装前的文件（仅安装阶段有效）
+	HookFailIgnore   = "ignore"   // 记录后继续
+)
+
+// Hook 描述一个在指定阶段执行的命令。
+// Command 可为绝对路径、相对安装目录的已安装文件，或 PATH 中的命令；
+// Bundled 为 true 时 Command 是打包机上的本地文件，会被打包进 setup 的 hooks/ 目录。
+// Args / Env / WorkingDir 中可使用 {InstallDir}、{ProductName}、{Version}。
+type Hook struct {
+	Stage      string
+	Command    string
+	Bundled    bool
+	Args       []string
+	Env        map[string]string
+	WorkingDir string        // 相对安装目录；为空时为安装目录
+	Timeout    time.Duration // 0 表示默认 5 分钟
+	OnFailure  string        // 为空时为 HookFailAbort
 }
 
 // CreateInstaller 将 payloadExe 打包并附加到 stubExe 生成 setup
@@ -35,6 +345,106 @@ func CreateInstaller(stubExe, payloadExe, outputSetup string, opts Options) erro
 	if opts.ExeName == "" {
 		opts.ExeName = filepath.Base(payloadExe)
 	}
+	if opts.Arch == "" {
+		opts.Arch = detectArch(payloadData)
+	}
+	return writeSetup(stubExe, outputSetup, map[string][]byte{opts.ExeName: payloadData}, opts)
+}
+
+// CreateUniversalInstaller 将 opts.Payloads 中的全部架构变体打包进一个 setup（归档内 arch/<架构>/），
+// 由 stub 在运行时按本机架构与 ArchFallback 选择安装哪一个。
+// stubExe 须能在所有目标系统上运行，Windows 上通常使用 386 的 stub（arm64 与 x64 系统均可运行）。
+func CreateUniversalInstaller(stubExe, outputSetup string, opts Options) error {
+	arches, payloads, err := readPayloads(opts.Payloads)
+	if err != nil {
+		return err
+	}
+	if opts.ExeName == "" {
+		opts.ExeName = filepath.Base(opts.Payloads[arches[0]])
+	}
+	for native, order := range opts.ArchFallback {
+		if !knownArch(native) {
+			return fmt.Errorf("archFallback: unknown arch %q", native)
+		}
+		for _, a := range order {
+			if _, ok := payloads[a]; !ok {
+				return fmt.Errorf("archFallback %s: no payload for %q", native, a)
+			}
+		}
+	}
+	files := map[string][]byte{}
+	for a, data := range payloads {
+		files["arch/"+a+"/"+opts.ExeName] = data
+	}
+	opts.Arch = ""
+	return writeSetup(stubExe, outputSetup, files, opts)
+}
+
+// CreateArchInstallers 为 opts.Payloads 中的每个架构生成一个 setup。
+// stubs 为架构 -> 同架构的 stub；outputSetup 中的 {Arch} 替换为架构名，
+// 不含 {Arch} 时在扩展名之前插入 "-<架构>"。返回生成的文件路径。
+func CreateArchInstallers(stubs map[string]string, outputSetup string, opts Options) ([]string, error) {
+	arches, _, err := readPayloads(opts.Payloads)
+	if err != nil {
+		return nil, err
+	}
+	var outputs []string
+	for _, a := range arches {
+		stub, ok := stubs[a]
+		if !ok {
+			return outputs, fmt.Errorf("no stub for arch %s", a)
+		}
+		out := strings.ReplaceAll(outputSetup, "{Arch}", a)
+		if out == outputSetup {
+			ext := filepath.Ext(outputSetup)
+			out = strings.TrimSuffix(outputSetup, ext) + "-" + a + ext
+		}
+		archOpts := opts
+		archOpts.Arch = a
+		archOpts.Payloads, archOpts.ArchFallback = nil, nil
+		if err := CreateInstaller(stub, opts.Payloads[a], out, archOpts); err != nil {
+			return outputs, fmt.Errorf("%s: %w", a, err)
+		}
+		outputs = append(outputs, out)
+	}
+	return outputs, nil
+}
+
+// readPayloads 读取各架构的主程序，并校验其 PE/ELF 头与声明的架构一致；返回排序后的架构列表
+func readPayloads(paths map[string]string) ([]string, map[string][]byte, error) {
+	if len(paths) == 0 {
+		return nil, nil, fmt.Errorf("no payloads")
+	}
+	arches := make([]string, 0, len(paths))
+	payloads := map[string][]byte{}
+	for a, p := range paths {
+		if !knownArch(a) {
+			return nil, nil, fmt.Errorf("payload %s: unknown arch %q", p, a)
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, nil, fmt.Errorf("read payload: %w", err)
+		}
+		if d := detectArch(data); d != "" && d != a {
+			return nil, nil, fmt.Errorf("payload %s is %s, not %s", p, d, a)
+		}
+		arches = append(arches, a)
+		payloads[a] = data
+	}
+	sort.Strings(arches)
+	return arches, payloads, nil
+}
+
+func knownArch(a string) bool {
+	switch a {
+	case "amd64", "386", "arm64", "arm":
+		return true
+	}
+	return false
+}
+
+// writeSetup 将 payload（归档内路径 -> 内容）与其余配置打包并附加到 stubExe
+func writeSetup(stubExe, outputSetup string, payload map[string][]byte, opts Options) error {
 	if opts.ProductName == "" {
 		opts.ProductName = "MyApp"
 	}
@@ -43,32 +453,177 @@ func CreateInstaller(stubExe, payloadExe, outputSetup string, opts Options) erro
 		opts.ShortcutName = opts.ProductName
 	}
 
-	meta := map[string]any{
-		"productName":             opts.ProductName,
-		"exeName":                 opts.ExeName,
-		"installDir":              opts.InstallDir,
-		"createDesktopShortcut":   opts.CreateDesktopShortcut,
-		"createStartMenuShortcut": opts.CreateStartMenuShortcut,
-		"version":                 opts.Version,
-		"shortcutName":            opts.ShortcutName,
-		"generatedAt":             time.Now().Format(time.RFC3339),
+	if err := validateInstallDir(opts.InstallDir); err != nil {
+		return err
+	}
+	if _, err := version.Parse(opts.Version); opts.Version != "" && err != nil {
+		return fmt.Errorf("version %q: expected semantic (1.2.3-beta.1) or four-part (1.2.3.4) version", opts.Version)
+	}
+	switch opts.SecondInstance {
+	case "", SecondInstanceWait, SecondInstanceFocus, SecondInstanceExit:
+	default:
+		return fmt.Errorf("unknown second instance action %q", opts.SecondInstance)
+	}
+	if err := validateEnvironment(opts.Environment); err != nil {
+		return err
+	}
+	if opts.Portable && len(opts.Environment) > 0 {
+		return fmt.Errorf("portable setup cannot set environment variables")
+	}
+	if opts.Portable && len(opts.Services) > 0 {
+		return fmt.Errorf("portable setup cannot install services")
+	}
+	if opts.Portable && len(opts.Autostart) > 0 {
+		return fmt.Errorf("portable setup cannot register automatic start")
+	}
+	switch opts.AutostartChoice {
+	case "", AutostartChecked, AutostartUnchecked, AutostartAlways:
+	default:
+		return fmt.Errorf("unknown autostart choice %q", opts.AutostartChoice)
+	}
+	switch opts.VersionPolicy.SameVersion {
+	case "", SameVersionReinstall, SameVersionRepair, SameVersionAsk, SameVersionBlock:
+	default:
+		return fmt.Errorf("version policy: unknown same-version action %q", opts.VersionPolicy.SameVersion)
+	}
+	if opts.Portable && len(opts.Prerequisites) > 0 {
+		return fmt.Errorf("portable setup cannot install prerequisites")
 	}
 
-	metaBytes, _ := json.MarshalIndent(meta, "", "  ")
+	stubData, err := os.ReadFile(stubExe)
+	if err != nil {
+		return fmt.Errorf("read stub: %w", err)
+	}
 
-	files := map[string][]byte{
-		opts.ExeName: payloadData,
-		"meta.json":  metaBytes,
+	files := map[string][]byte{}
+	for name, data := range payload {
+		files[name] = data
 	}
 
-	archive, err := buildTarGz(files)
+	hooks, err := packHooks(opts.Hooks, files)
 	if err != nil {
-		return fmt.Errorf("build archive: %w", err)
+		return err
+	}
+	if err := packTranslations(opts.Translations, files); err != nil {
+		return err
+	}
+	licenseName, err := packLicense(opts.LicenseFile, files)
+	if err != nil {
+		return err
+	}
+	components, err := packComponents(opts.Components, files)
+	if err != nil {
+		return err
+	}
+	prereqs, err := packPrerequisites(opts.Prerequisites, files)
+	if err != nil {
+		return err
+	}
+	entryPoints, err := packEntryPoints(opts, detectOS(stubData), files)
+	if err != nil {
+		return err
+	}
+	if err := validateRunAfterInstall(opts.RunAfterInstall, entryPoints); err != nil {
+		return err
+	}
+	services, err := packServices(opts.Services, entryPoints)
+	if err != nil {
+		return err
+	}
+	autostart, err := packAutostart(opts.Autostart, entryPoints)
+	if err != nil {
+		return err
 	}
+	environment := []map[string]any{}
+	for _, v := range opts.Environment {
+		environment = append(environment, map[string]any{
+			"name": v.Name, "value": v.Value, "action": v.Action, "machine": v.Machine,
+		})
+	}
+	// 通用 setup 只安装其中一个架构变体，按最大的变体计算
+	var installSize, largestVariant int64
+	var arches []string
+	for name, data := range files {
+		switch {
+		case strings.HasPrefix(name, "prereqs/"):
+		case strings.HasPrefix(name, "arch/"):
+			arches = append(arches, strings.Split(name, "/")[1])
+			largestVariant = max(largestVariant, int64(len(data)))
+		default:
+			installSize += int64(len(data))
+		}
+	}
+	installSize += largestVariant
+	sort.Strings(arches)
 
-	stubData, err := os.ReadFile(stubExe)
+	// 每个文件的大小与 SHA-256，供 inspect/verify 与安装后的校验使用（meta.json 自身除外）
+	manifest := map[string]any{}
+	for name, data := range files {
+		sum := sha256.Sum256(data)
+		manifest[name] = map[string]any{"size": len(data), "sha256": hex.EncodeToString(sum[:])}
+	}
+
+	major, minor := splitVersion(opts.Version)
+
+	meta := map[string]any{
+		"productName":                  opts.ProductName,
+		"exeName":                      opts.ExeName,
+		"installDir":                   opts.InstallDir,
+		"createDesktopShortcut":        opts.CreateDesktopShortcut,
+		"createStartMenuShortcut":      opts.CreateStartMenuShortcut,
+		"version":                      opts.Version,
+		"shortcutName":                 opts.ShortcutName,
+		"generatedAt":                  time.Now().Format(time.RFC3339),
+		"publisher":                    opts.Publisher,
+		"urlInfoAbout":                 opts.URLInfoAbout,
+		"helpLink":                     opts.HelpLink,
+		"urlUpdateInfo":                opts.URLUpdateInfo,
+		"contact":                      opts.Contact,
+		"comments":                     opts.Comments,
+		"language":                     opts.Language,
+		"displayIcon":                  opts.DisplayIcon,
+		"versionMajor":                 major,
+		"versionMinor":                 minor,
+		"installSize":                  installSize,
+		"hooks":                        hooks,
+		"closeAppsTimeoutSeconds":      int(opts.CloseAppsTimeout / time.Second),
+		"licenseFile":                  licenseName,
+		"components":                   components,
+		"arch":                         opts.Arch,
+		"minOSVersion":                 opts.MinOSVersion,
+		"prerequisites":                prereqs,
+		"arches":                       arches,
+		"archFallback":                 opts.ArchFallback,
+		"files":                        manifest,
+		"portable":                     opts.Portable,
+		"cachePayload":                 opts.CachePayload,
+		"secondInstance":               opts.SecondInstance,
+		"secondInstanceTimeoutSeconds": int(opts.SecondInstanceTimeout / time.Second),
+		"versionPolicy": map[string]any{
+			"allowDowngrade":           opts.VersionPolicy.AllowDowngrade,
+			"sameVersion":              opts.VersionPolicy.SameVersion,
+			"uninstallOlderMajorFirst": opts.VersionPolicy.UninstallOlderMajorFirst,
+		},
+		"entryPoints":     entryPoints,
+		"environment":     environment,
+		"services":        services,
+		"autostart":       autostart,
+		"autostartChoice": opts.AutostartChoice,
+		"runAfterInstall": map[string]any{
+			"entryPoint": opts.RunAfterInstall.EntryPoint,
+			"args":       opts.RunAfterInstall.Args,
+			"workingDir": opts.RunAfterInstall.WorkingDir,
+			"unchecked":  opts.RunAfterInstall.Unchecked,
+			"disabled":   opts.RunAfterInstall.Disabled,
+		},
+	}
+
+	metaBytes, _ := json.MarshalIndent(meta, "", "  ")
+	files["meta.json"] = metaBytes
+
+	archive, err := buildTarGz(files)
 	if err != nil {
-		return fmt.Errorf("read stub: %w", err)
+		return fmt.Errorf("build archive: %w", err)
 	}
 
 	f, err := os.OpenFile(outputSetup, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o755)
@@ -98,6 +653,524 @@ func CreateInstaller(stubExe, payloadExe, outputSetup string, opts Options) erro
 	return nil
 }
 
+// packHooks 校验钩子配置，将 Bundled 钩子文件加入归档，返回写入 meta.json 的描述
+func packHooks(hooks []Hook, files map[string][]byte) ([]map[string]any, error) {
+	var out []map[string]any
+	bundled := map[string]string{} // 归档名 -> 源文件；同一脚本可被多个钩子使用
+	for i, h := range hooks {
+		switch h.Stage {
+		case HookPreInstall, HookPostInstall, HookPreUninstall, HookPostUninstall:
+		default:
+			return nil, fmt.Errorf("hook %d: unknown stage %q", i, h.Stage)
+		}
+		if h.OnFailure == "" {
+			h.OnFailure = HookFailAbort
+		}
+		switch h.OnFailure {
+		case HookFailAbort, HookFailRollback, HookFailIgnore:
+		default:
+			return nil, fmt.Errorf("hook %d: unknown onFailure %q", i, h.OnFailure)
+		}
+		if h.Command == "" {
+			return nil, fmt.Errorf("hook %d: empty command", i)
+		}
+		command := h.Command
+		if h.Bundled {
+			data, err := os.ReadFile(h.Command)
+			if err != nil {
+				return nil, fmt.Errorf("read hook %s: %w", h.Command, err)
+			}
+			command = "hooks/" + filepath.Base(h.Command)
+			if src, ok := bundled[command]; ok {
+				if filepath.Clean(src) != filepath.Clean(h.Command) {
+					return nil, fmt.Errorf("hook %d: %s and %s are both bundled as %s", i, src, h.Command, command)
+				}
+			} else if _, dup := files[command]; dup {
+				return nil, fmt.Errorf("hook %d: bundled %s conflicts with payload file %s", i, h.Command, command)
+			}
+			bundled[command] = h.Command
+			files[command] = data
+		}
+		out = append(out, map[string]any{
+			"stage":          h.Stage,
+			"command":        command,
+			"bundled":        h.Bundled,
+			"args":           h.Args,
+			"env":            h.Env,
+			"workingDir":     h.WorkingDir,
+			"timeoutSeconds": int(h.Timeout / time.Second),
+			"onFailure":      h.OnFailure,
+		})
+	}
+	return out, nil
+}
+
+// packPrerequisites 校验前置组件配置，将 Bundled 安装程序加入归档，返回写入 meta.json 的描述
+func packPrerequisites(prereqs []Prerequisite, files map[string][]byte) ([]map[string]any, error) {
+	var out []map[string]any
+	for i, p := range prereqs {
+		if p.Name == "" {
+			return nil, fmt.Errorf("prerequisite %d: empty name", i)
+		}
+		if p.Command == "" {
+			return nil, fmt.Errorf("prerequisite %s: empty command", p.Name)
+		}
+		// 只有 MinVersion 时没有可比较的对象，检测总是通过，安装程序永远不会运行
+		if p.Detect.File == "" && p.Detect.RegistryKey == "" {
+			return nil, fmt.Errorf("prerequisite %s: detection rule needs File or RegistryKey", p.Name)
+		}
+		if p.Detect.RegistryValue != "" && p.Detect.RegistryKey == "" {
+			return nil, fmt.Errorf("prerequisite %s: registryValue without registryKey", p.Name)
+		}
+		command := p.Command
+		if p.Bundled {
+			data, err := os.ReadFile(p.Command)
+			if err != nil {
+				return nil, fmt.Errorf("read prerequisite %s: %w", p.Command, err)
+			}
+			command = "prereqs/" + filepath.Base(p.Command)
+			if _, dup := files[command]; dup {
+				return nil, fmt.Errorf("prerequisite %s: duplicate installer %s", p.Name, command)
+			}
+			files[command] = data
+		}
+		out = append(out, map[string]any{
+			"name":    p.Name,
+			"command": command,
+			"bundled": p.Bundled,
+			"args":    p.Args,
+			"detect": map[string]any{
+				"file":          p.Detect.File,
+				"registryKey":   p.Detect.RegistryKey,
+				"registryValue": p.Detect.RegistryValue,
+				"minVersion":    p.Detect.MinVersion,
+			},
+			"successCodes":   p.SuccessCodes,
+			"rebootCodes":    p.RebootCodes,
+			"timeoutSeconds": int(p.Timeout / time.Second),
+			"optional":       p.Optional,
+		})
+	}
+	return out, nil
+}
+
+// packEntryPoints 补全并校验入口：名称唯一、恰有一个 Main，且 stub 所属平台（goos）的入口文件在归档中；
+// 主程序不能属于可选组件，其他入口可以（未选该组件时不安装）
+// （通用 setup 须在每个 arch/<架构>/ 中）。goos 为空（无法识别 stub）时按 Windows 校验。
+func packEntryPoints(opts Options, goos string, files map[string][]byte) ([]map[string]any, error) {
+	eps := opts.EntryPoints
+	if len(eps) == 0 {
+		eps = []EntryPoint{{Name: "main", Windows: opts.ExeName, Linux: opts.ExeName, Main: true}}
+	}
+	var arches []string
+	for name := range files {
+		if strings.HasPrefix(name, "arch/") {
+			if a := strings.Split(name, "/")[1]; !slices.Contains(arches, a) {
+				arches = append(arches, a)
+			}
+		}
+	}
+
+	var out []map[string]any
+	seen := map[string]bool{}
+	mains := 0
+	for _, ep := range eps {
+		if ep.Name == "" || seen[ep.Name] {
+			return nil, fmt.Errorf("entry point: empty or duplicate name %q", ep.Name)
+		}
+		seen[ep.Name] = true
+		if ep.Main {
+			mains++
+		}
+		if ep.Linux == "" {
+			ep.Linux = strings.TrimSuffix(ep.Windows, filepath.Ext(ep.Windows))
+		}
+		target := ep.Windows
+		if goos == "linux" {
+			target = ep.Linux
+		}
+		rel, err := archivePath(target)
+		if err != nil {
+			return nil, fmt.Errorf("entry point %s: %w", ep.Name, err)
+		}
+		if len(arches) > 0 && rel == opts.ExeName {
+			for _, a := range arches {
+				if _, ok := files["arch/"+a+"/"+rel]; !ok {
+					return nil, fmt.Errorf("entry point %s: %s not in payload for %s", ep.Name, rel, a)
+				}
+			}
+		} else if _, ok := files[rel]; !ok {
+			return nil, fmt.Errorf("entry point %s: %s not in payload", ep.Name, rel)
+		}
+		if c := optionalComponentOf(opts.Components, rel); ep.Main && c != "" {
+			return nil, fmt.Errorf("entry point %s: main program %s is in optional component %s", ep.Name, rel, c)
+		}
+		out = append(out, map[string]any{
+			"name":    ep.Name,
+			"windows": ep.Windows,
+			"linux":   ep.Linux,
+			"main":    ep.Main,
+		})
+	}
+	if mains != 1 {
+		return nil, fmt.Errorf("entry points: need exactly one main entry, got %d", mains)
+	}
+	return out, nil
+}
+
+// optionalComponentOf 返回包含归档路径 rel 的非必选组件名称，不属于任何可选组件时为空
+func optionalComponentOf(components []Component, rel string) string {
+	for _, c := range components {
+		if c.Required {
+			continue
+		}
+		for name := range c.Files {
+			if p, err := archivePath(name); err == nil && p == rel {
+				return c.Name
+			}
+		}
+	}
+	return ""
+}
+
+// validateEnvironment 校验变量名可移植（字母、数字、下划线，不以数字开头）且修改方式有效
+func validateEnvironment(vars []EnvVar) error {
+	for _, v := range vars {
+		if !envNamePattern.MatchString(v.Name) {
+			return fmt.Errorf("environment: invalid variable name %q", v.Name)
+		}
+		switch v.Action {
+		case "", EnvSet:
+		case EnvAppend, EnvPrepend:
+			if v.Value == "" || strings.Contains(v.Value, ";") {
+				return fmt.Errorf("environment: %s: list entry must be a single path", v.Name)
+			}
+		default:
+			return fmt.Errorf("environment: %s: unknown action %q", v.Name, v.Action)
+		}
+	}
+	return nil
+}
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// packServices 校验服务并生成 meta 中的 services
+func packServices(svcs []Service, entryPoints []map[string]any) ([]map[string]any, error) {
+	out := []map[string]any{}
+	seen := map[string]bool{}
+	for _, s := range svcs {
+		if !serviceNamePattern.MatchString(s.Name) {
+			return nil, fmt.Errorf("service: invalid name %q", s.Name)
+		}
+		if seen[strings.ToLower(s.Name)] {
+			return nil, fmt.Errorf("service: duplicate name %q", s.Name)
+		}
+		seen[strings.ToLower(s.Name)] = true
+		if s.EntryPoint != "" && !slices.ContainsFunc(entryPoints, func(ep map[string]any) bool { return ep["name"] == s.EntryPoint }) {
+			return nil, fmt.Errorf("service %s: unknown entry point %q", s.Name, s.EntryPoint)
+		}
+		switch s.StartType {
+		case "", ServiceAuto, ServiceDelayed, ServiceManual, ServiceDisabled:
+		default:
+			return nil, fmt.Errorf("service %s: unknown start type %q", s.Name, s.StartType)
+		}
+		var recovery []map[string]any
+		for _, r := range s.Recovery {
+			switch r.Action {
+			case ServiceNoAction, ServiceRestart, ServiceReboot:
+			default:
+				return nil, fmt.Errorf("service %s: unknown recovery action %q", s.Name, r.Action)
+			}
+			recovery = append(recovery, map[string]any{"action": r.Action, "delaySeconds": int(r.Delay / time.Second)})
+		}
+		out = append(out, map[string]any{
+			"name":               s.Name,
+			"displayName":        s.DisplayName,
+			"description":        s.Description,
+			"entryPoint":         s.EntryPoint,
+			"args":               s.Args,
+			"startType":          s.StartType,
+			"account":            s.Account,
+			"dependencies":       s.Dependencies,
+			"recovery":           recovery,
+			"resetPeriodSeconds": int(s.ResetPeriod / time.Second),
+			"noStart":            s.NoStart,
+		})
+	}
+	return out, nil
+}
+
+// packAutostart 校验自动启动项并生成 meta 中的 autostart
+func packAutostart(items []Autostart, entryPoints []map[string]any) ([]map[string]any, error) {
+	out := []map[string]any{}
+	seen := map[string]bool{}
+	for _, a := range items {
+		if strings.ContainsAny(a.Name, `/\`) {
+			return nil, fmt.Errorf("autostart: invalid name %q", a.Name)
+		}
+		key := strings.ToLower(a.Name)
+		if seen[key] {
+			return nil, fmt.Errorf("autostart: duplicate name %q", a.Name)
+		}
+		seen[key] = true
+		if a.EntryPoint != "" && !slices.ContainsFunc(entryPoints, func(ep map[string]any) bool { return ep["name"] == a.EntryPoint }) {
+			return nil, fmt.Errorf("autostart %s: unknown entry point %q", a.Name, a.EntryPoint)
+		}
+		if a.Daily != "" && !dailyPattern.MatchString(a.Daily) {
+			return nil, fmt.Errorf("autostart %s: daily time %q: expected HH:MM", a.Name, a.Daily)
+		}
+		if a.Daily != "" && a.Interval != 0 {
+			return nil, fmt.Errorf("autostart %s: set either Daily or Interval", a.Name)
+		}
+		minutes := int(a.Interval / time.Minute)
+		if a.Interval != 0 && (a.Interval%time.Minute != 0 || minutes < 1 || minutes >= 1440 && minutes%1440 != 0) {
+			return nil, fmt.Errorf("autostart %s: interval %v: expected whole minutes below 24h or whole days", a.Name, a.Interval)
+		}
+		out = append(out, map[string]any{
+			"name":            a.Name,
+			"entryPoint":      a.EntryPoint,
+			"args":            a.Args,
+			"startupFolder":   a.StartupFolder,
+			"daily":           a.Daily,
+			"intervalMinutes": minutes,
+		})
+	}
+	return out, nil
+}
+
+var dailyPattern = regexp.MustCompile(`^([01]\d|2[0-3]):[0-5]\d$`)
+
+// serviceNamePattern 同时可用作 Windows 服务名与 systemd 单元名
+var serviceNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.@-]+$`)
+
+// validateRunAfterInstall 校验安装后启动的入口已定义，工作目录不能逃出安装目录
+func validateRunAfterInstall(r RunAfterInstall, entryPoints []map[string]any) error {
+	if r.EntryPoint != "" && !slices.ContainsFunc(entryPoints, func(ep map[string]any) bool { return ep["name"] == r.EntryPoint }) {
+		return fmt.Errorf("run after install: unknown entry point %q", r.EntryPoint)
+	}
+	if r.WorkingDir != "" {
+		if _, err := archivePath(r.WorkingDir); err != nil {
+			return fmt.Errorf("run after install: working dir: %w", err)
+		}
+	}
+	return nil
+}
+
+// packTranslations 校验翻译文件并以 lang/<代码>.json 加入归档
+func packTranslations(translations map[string]string, files map[string][]byte) error {
+	for code, file := range translations {
+		if code == "" || strings.ContainsAny(code, `/\. `) {
+			return fmt.Errorf("translation: invalid language code %q", code)
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("read translation %s: %w", file, err)
+		}
+		var m map[string]string
+		if err := json.Unmarshal(data, &m); err != nil {
+			return fmt.Errorf("translation %s: %w", file, err)
+		}
+		files["lang/"+code+".json"] = data
+	}
+	return nil
+}
+
+// packLicense 将许可协议加入归档根目录，返回其文件名；未设置时返回空
+func packLicense(licenseFile string, files map[string][]byte) (string, error) {
+	if licenseFile == "" {
+		return "", nil
+	}
+	switch strings.ToLower(filepath.Ext(licenseFile)) {
+	case ".txt", ".md", ".rtf":
+	default:
+		return "", fmt.Errorf("license %s: unsupported format (want .txt, .md or .rtf)", licenseFile)
+	}
+	name := filepath.Base(licenseFile)
+	if _, ok := files[name]; ok || name == "meta.json" {
+		return "", fmt.Errorf("license %s: name conflicts with another packaged file", licenseFile)
+	}
+	data, err := os.ReadFile(licenseFile)
+	if err != nil {
+		return "", fmt.Errorf("read license: %w", err)
+	}
+	if strings.EqualFold(filepath.Ext(licenseFile), ".rtf") {
+		if err := checkRTFCodePage(data); err != nil {
+			return "", fmt.Errorf("license %s: %w", licenseFile, err)
+		}
+	}
+	files[name] = data
+	return name, nil
+}
+
+// checkRTFCodePage 安装程序与此处共用 rtf.Parse，按 Windows-1252 解码正文中的 \'hh 字符。声明了其他代码页
+// （\ansicpgN，例如简体中文的 936）且正文含有非 ASCII 的 \'hh 时，显示会出现乱码，因此在打包时拒绝。
+// 字体表等目标组中的 \'hh 与 \uN 之后的替代字符不显示，不受影响。
+func checkRTFCodePage(data []byte) error {
+	doc := rtf.Parse(data)
+	if doc.CodePage != 1252 && doc.ANSIText {
+		return fmt.Errorf("RTF uses code page %d; only Windows-1252 or \\uN characters are supported, save it as Unicode RTF or UTF-8 text", doc.CodePage)
+	}
+	return nil
+}
+
+// packComponents 校验组件（名称唯一、依赖存在且无环、文件路径安全且不冲突），
+// 将组件文件加入归档，返回写入 meta.json 的描述
+func packComponents(components []Component, files map[string][]byte) ([]map[string]any, error) {
+	byName := map[string]*Component{}
+	for i := range components {
+		c := &components[i]
+		if c.Name == "" || strings.ContainsAny(c.Name, ", ") {
+			return nil, fmt.Errorf("component %d: invalid name %q", i, c.Name)
+		}
+		if byName[c.Name] != nil {
+			return nil, fmt.Errorf("component %s: duplicate name", c.Name)
+		}
+		byName[c.Name] = c
+	}
+	for _, c := range components {
+		for _, d := range c.Depends {
+			if byName[d] == nil {
+				return nil, fmt.Errorf("component %s: unknown dependency %q", c.Name, d)
+			}
+		}
+	}
+	// 依赖环检测：0 未访问，1 访问中，2 已完成
+	state := map[string]int{}
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case 1:
+			return fmt.Errorf("component %s: dependency cycle", name)
+		case 2:
+			return nil
+		}
+		state[name] = 1
+		for _, d := range byName[name].Depends {
+			if err := visit(d); err != nil {
+				return err
+			}
+		}
+		state[name] = 2
+		return nil
+	}
+
+	var out []map[string]any
+	for _, c := range components {
+		if err := visit(c.Name); err != nil {
+			return nil, err
+		}
+		var names []string
+		var size int64
+		for rel, local := range c.Files {
+			name, err := archivePath(rel)
+			if err != nil {
+				return nil, fmt.Errorf("component %s: %w", c.Name, err)
+			}
+			if _, ok := files[name]; ok || name == "meta.json" {
+				return nil, fmt.Errorf("component %s: %s is packaged more than once", c.Name, name)
+			}
+			data, err := os.ReadFile(local)
+			if err != nil {
+				return nil, fmt.Errorf("component %s: %w", c.Name, err)
+			}
+			files[name] = data
+			names = append(names, name)
+			size += int64(len(data))
+		}
+		sort.Strings(names)
+
+		var shortcuts []map[string]any
+		for _, s := range c.Shortcuts {
+			target, err := archivePath(s.Target)
+			if err != nil || s.Name == "" {
+				return nil, fmt.Errorf("component %s: invalid shortcut %q -> %q", c.Name, s.Name, s.Target)
+			}
+			shortcuts = append(shortcuts, map[string]any{
+				"name": s.Name, "target": target, "desktop": s.Desktop, "startMenu": s.StartMenu,
+			})
+		}
+		var values []map[string]any
+		for _, r := range c.Registry {
+			switch r.Type {
+			case "", "string", "expand":
+			case "dword":
+				if _, err := strconv.ParseUint(r.Value, 0, 32); err != nil {
+					return nil, fmt.Errorf("component %s: registry %s\\%s: invalid dword %q", c.Name, r.Key, r.Name, r.Value)
+				}
+			default:
+				return nil, fmt.Errorf("component %s: registry %s: unknown type %q", c.Name, r.Key, r.Type)
+			}
+			if strings.TrimSpace(r.Key) == "" {
+				return nil, fmt.Errorf("component %s: empty registry key", c.Name)
+			}
+			values = append(values, map[string]any{"key": r.Key, "name": r.Name, "value": r.Value, "type": r.Type})
+		}
+
+		out = append(out, map[string]any{
+			"name":        c.Name,
+			"description": c.Description,
+			"files":       names,
+			"size":        size,
+			"shortcuts":   shortcuts,
+			"registry":    values,
+			"default":     c.Default,
+			"required":    c.Required,
+			"depends":     c.Depends,
+		})
+	}
+	return out, nil
+}
+
+// archivePath 将安装目录内的相对路径规范为归档中的 "/" 分隔路径，拒绝绝对路径与 ".."
+func archivePath(rel string) (string, error) {
+	name := path.Clean(strings.ReplaceAll(rel, "\\", "/"))
+	if rel == "" || name == "." || path.IsAbs(name) || filepath.IsAbs(rel) || name == ".." || strings.HasPrefix(name, "../") {
+		return "", fmt.Errorf("invalid relative path %q", rel)
+	}
+	return name, nil
+}
+
+// detectOS 从 PE/ELF 头识别可执行文件的目标系统（GOOS 命名），无法识别时返回空
+func detectOS(data []byte) string {
+	if _, err := pe.NewFile(bytes.NewReader(data)); err == nil {
+		return "windows"
+	}
+	if _, err := elf.NewFile(bytes.NewReader(data)); err == nil {
+		return "linux"
+	}
+	return ""
+}
+
+// detectArch 从 PE/ELF 头识别可执行文件的 CPU 架构（GOARCH 命名），无法识别时返回空
+func detectArch(data []byte) string {
+	if f, err := pe.NewFile(bytes.NewReader(data)); err == nil {
+		switch f.Machine {
+		case pe.IMAGE_FILE_MACHINE_AMD64:
+			return "amd64"
+		case pe.IMAGE_FILE_MACHINE_I386:
+			return "386"
+		case pe.IMAGE_FILE_MACHINE_ARM64:
+			return "arm64"
+		case pe.IMAGE_FILE_MACHINE_ARMNT:
+			return "arm"
+		}
+		return ""
+	}
+	if f, err := elf.NewFile(bytes.NewReader(data)); err == nil {
+		switch f.Machine {
+		case elf.EM_X86_64:
+			return "amd64"
+		case elf.EM_386:
+			return "386"
+		case elf.EM_AARCH64:
+			return "arm64"
+		case elf.EM_ARM:
+			return "arm"
+		}
+	}
+	return ""
+}
+
 func buildTarGz(files map[string][]byte) ([]byte, error) {
 	var buf bytes.Buffer
 	gzw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
@@ -114,8 +1187,9 @@ func buildTarGz(files map[string][]byte) ([]byte, error) {
 			Size:    int64(len(data)),
 			ModTime: now,
 		}
-		// 对于 exe 给予执行权限（在 *nix 上）
-		if filepath.Ext(strings.ToLower(name)) == ".exe" {
+		// 对于 exe、钩子、前置组件安装程序、各架构主程序以及 ELF 与脚本文件给予执行权限（在 *nix 上）
+		if filepath.Ext(strings.ToLower(name)) == ".exe" || strings.HasPrefix(name, "hooks/") || strings.HasPrefix(name, "prereqs/") || strings.HasPrefix(name, "arch/") ||
+			bytes.HasPrefix(data, []byte("\x7fELF")) || bytes.HasPrefix(data, []byte("#!")) {
 			h.Mode = 0o755
 		}
 		if err := tw.WriteHeader(h); err != nil {
@@ -139,3 +1213,18 @@ func buildTarGz(files map[string][]byte) ([]byte, error) {
 	}
 	return buf.Bytes(), nil
 }
+
+// splitVersion 取出写入注册表的主/次版本号，无法解析或超出 DWORD 范围时按 0 处理
+func splitVersion(v string) (major, minor uint32) {
+	parsed, err := version.Parse(v)
+	if err != nil {
+		return 0, 0
+	}
+	dword := func(n int) uint32 {
+		if uint64(n) > math.MaxUint32 {
+			return 0
+		}
+		return uint32(n)
+	}
+	return dword(parsed.Major()), dword(parsed.Minor())
+}
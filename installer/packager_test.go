@@ -0,0 +1,74 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckRTFCodePage(t *testing.T) {
+	tests := []struct {
+		name    string
+		rtf     string
+		wantErr bool
+	}{
+		{"no code page", `{\rtf1\ansi Caf\'e9}`, false},
+		{"cp1252", `{\rtf1\ansi\ansicpg1252 Caf\'e9}`, false},
+		{"cp936 ascii only", `{\rtf1\ansi\ansicpg936 License\par}`, false},
+		{"cp936 ascii escape", `{\rtf1\ansi\ansicpg936 a\'41b}`, false},
+		{"cp936 unicode with fallback", `{\rtf1\ansi\ansicpg936\uc2 \u35768\'d0\'ed\u21487\'bf\'c9}`, false},
+		{"cp936 unicode with default uc", `{\rtf1\ansi\ansicpg936 \u35768?\u21487?}`, false},
+		{"cp936 font table", `{\rtf1\ansi\ansicpg936{\fonttbl{\f0\fcharset134 \'cb\'ce\'cc\'e5;}}\f0\uc1 \u35768?}`, false},
+		{"cp936 stylesheet", `{\rtf1\ansi\ansicpg936{\stylesheet{\s1 \'b1\'ea\'cc\'e2 1;}}\uc1\u35768?}`, false},
+		{"cp936 ignorable destination", `{\rtf1\ansi\ansicpg936{\*\panose 02010600030101010101}{\*\userprops {\propname \'d7\'f7\'d5\'df}}\u35768?}`, false},
+		{"cp936 info", `{\rtf1\ansi\ansicpg936{\info{\title \'d0\'ed\'bf\'c9}}\u35768?}`, false},
+		{"cp936 body after font table", `{\rtf1\ansi\ansicpg936{\fonttbl{\f0 \'cb\'ce\'cc\'e5;}}\'d0\'ed}`, true},
+		{"cp936 raw bytes", `{\rtf1\ansi\ansicpg936 \'d0\'ed\'bf\'c9}`, true},
+		{"cp936 fallback count exceeded", `{\rtf1\ansi\ansicpg936 \u35768\'d0\'ed}`, true},
+		{"cp936 uc restored after group", `{\rtf1\ansi\ansicpg936 {\uc2 \u35768\'d0\'ed}\u21487\'bf\'c9}`, true},
+	}
+	for _, tt := range tests {
+		if err := checkRTFCodePage([]byte(tt.rtf)); (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestPackHooksBundledCollision(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a", "setup.sh")
+	b := filepath.Join(dir, "b", "setup.sh")
+	for _, p := range []string{a, b} {
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hook := func(stage, command string) Hook { return Hook{Stage: stage, Command: command, Bundled: true} }
+
+	tests := []struct {
+		name    string
+		hooks   []Hook
+		payload map[string][]byte
+		wantErr bool
+	}{
+		{"same source twice", []Hook{hook(HookPreInstall, a), hook(HookPostInstall, a)}, nil, false},
+		{"different sources", []Hook{hook(HookPreInstall, a), hook(HookPostInstall, b)}, nil, true},
+		{"payload file", []Hook{hook(HookPostInstall, a)}, map[string][]byte{"hooks/setup.sh": []byte("app")}, true},
+	}
+	for _, tt := range tests {
+		files := map[string][]byte{}
+		for k, v := range tt.payload {
+			files[k] = v
+		}
+		_, err := packHooks(tt.hooks, files)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		if err == nil && string(files["hooks/setup.sh"]) != a {
+			t.Errorf("%s: hooks/setup.sh = %q, want contents of %s", tt.name, files["hooks/setup.sh"], a)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+// archPreference 未配置 ArchFallback 时可模拟运行的架构的尝试顺序（64 位优先）
+var archPreference = []string{"amd64", "arm64", "386", "arm"}
+
+// payloadArchOrder 返回本机依次尝试的主程序架构：/ARCH= 开关优先，
+// 其次为 meta 中为本机架构配置的顺序，否则为本机架构及可模拟运行的架构
+func payloadArchOrder(m InstallMeta, native string) []string {
+	if v, ok := switchValue("ARCH"); ok && v != "" {
+		return []string{strings.ToLower(v)}
+	}
+	if order, ok := m.ArchFallback[native]; ok {
+		return order
+	}
+	order := []string{native}
+	for _, a := range archPreference {
+		if a != native && archCanRun(native, a) {
+			order = append(order, a)
+		}
+	}
+	return order
+}
+
+// selectPayloadArch 为通用 setup 选择本机可运行的主程序变体
+func selectPayloadArch(m InstallMeta) (string, error) {
+	native := nativeArch()
+	order := payloadArchOrder(m, native)
+	for _, a := range order {
+		if slices.Contains(m.Arches, a) && archCanRun(native, a) {
+			return a, nil
+		}
+	}
+	wanted := m.Arches
+	if _, ok := switchValue("ARCH"); ok {
+		wanted = order
+	}
+	return "", withExitCode(exitArchMismatch, errors.New(T("check.arch", strings.Join(wanted, ", "), native)))
+}
+
+// selectArchFiles 通用 setup 按本机选择主程序变体并设置 meta.Arch；普通 setup 原样返回
+func selectArchFiles(files []*inMemoryFile) ([]*inMemoryFile, error) {
+	if len(meta.Arches) == 0 {
+		return files, nil
+	}
+	arch, err := selectPayloadArch(meta)
+	if err != nil {
+		return nil, err
+	}
+	meta.Arch = arch
+	runLog.write(logEntry{Level: levelInfo, Msg: T("install.arch", arch, nativeArch())})
+	return applyPayloadArch(files, arch), nil
+}
+
+// applyPayloadArch 将 arch/<arch>/ 下的文件移到归档根目录，丢弃其他架构的变体
+func applyPayloadArch(files []*inMemoryFile, arch string) []*inMemoryFile {
+	prefix := "arch/" + arch + "/"
+	out := make([]*inMemoryFile, 0, len(files))
+	for _, f := range files {
+		switch {
+		case strings.HasPrefix(f.Name, prefix):
+			f.Name = strings.TrimPrefix(f.Name, prefix)
+			out = append(out, f)
+		case !strings.HasPrefix(f.Name, "arch/"):
+			out = append(out, f)
+		}
+	}
+	return out
+}
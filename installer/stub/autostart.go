@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"strings"
+)
+
+// 自动启动项的类型，记录在安装记录中，卸载时据此删除
+const (
+	autostartRun     = "run"     // Windows：HKCU Run 键中的值
+	autostartStartup = "startup" // Windows：“启动”文件夹中的快捷方式
+	autostartTask    = "task"    // Windows：计划任务
+	autostartDesktop = "desktop" // Linux：XDG autostart 中的 .desktop 文件
+	autostartTimer   = "timer"   // Linux：systemd --user 定时器
+)
+
+// 向导中“自动启动”复选框的默认值，对应 meta 中的 autostartChoice
+const (
+	autostartChecked   = "checked" // 默认
+	autostartUnchecked = "unchecked"
+	autostartAlways    = "always" // 不显示复选框，始终注册
+)
+
+// autostartSpec 与 meta.json 中 autostart 的元素对应
+type autostartSpec struct {
+	Name            string   `json:"name"`       // Run 值名、快捷方式名、任务名或 .desktop 文件名；为空时为产品名
+	EntryPoint      string   `json:"entryPoint"` // 为空时为主入口
+	Args            []string `json:"args"`
+	StartupFolder   bool     `json:"startupFolder"`   // Windows 使用“启动”文件夹快捷方式而不是 Run 键
+	Daily           string   `json:"daily"`           // "HH:MM"：每天此时运行
+	IntervalMinutes int      `json:"intervalMinutes"` // 每隔多少分钟运行
+}
+
+// scheduled 按计划运行，而不是登录时启动
+func (s autostartSpec) scheduled() bool { return s.Daily != "" || s.IntervalMinutes > 0 }
+
+// autostartEntry 已创建的自动启动项
+type autostartEntry struct {
+	Kind     string `json:"kind"`
+	Location string `json:"location"` // 注册表值名、任务名或文件路径
+}
+
+// autostartCommand 已解析出可执行文件绝对路径、替换了参数占位符的自动启动项
+type autostartCommand struct {
+	autostartSpec
+	Product string
+	Path    string
+	Dir     string // 工作目录，即安装目录
+}
+
+// autostartManager 抽象自动启动项的创建与删除，便于替换为假实现进行测试
+type autostartManager interface {
+	// Register 创建自动启动项（已存在时覆盖）并返回其位置
+	Register(c autostartCommand) (autostartEntry, error)
+	// Unregister 删除自动启动项，不存在视为成功
+	Unregister(e autostartEntry) error
+}
+
+// autostarts 当前使用的实现，默认为操作系统实现
+var autostarts autostartManager = osAutostartManager{}
+
+type osAutostartManager struct{}
+
+// autostartOffered 向导是否显示“自动启动”复选框
+func autostartOffered(m InstallMeta) bool {
+	return len(m.Autostart) > 0 && m.AutostartChoice != autostartAlways
+}
+
+// autostartDefault “自动启动”的默认值：/AUTOSTART 强制注册，/AUTOSTART=0 不注册；
+// 升级时沿用上次的选择，否则按 autostartChoice
+func autostartDefault(m InstallMeta, prev *installRecord) bool {
+	if v, ok := switchValue("AUTOSTART"); ok {
+		return v != "0"
+	}
+	if m.AutostartChoice == autostartAlways {
+		return true
+	}
+	if prev != nil && (len(prev.Autostart) > 0 || prev.AutostartDeclined) {
+		return !prev.AutostartDeclined
+	}
+	return m.AutostartChoice != autostartUnchecked
+}
+
+// applyAutostart 按选择创建自动启动项并写入安装记录，删除上次安装创建而本次不再需要的项
+func applyAutostart(m InstallMeta, hc hookContext, rec, prev *installRecord, enabled bool) error {
+	var errs []string
+	if enabled {
+		for _, s := range m.Autostart {
+			c, err := buildAutostart(m, s, hc)
+			if err == nil {
+				var e autostartEntry
+				if e, err = autostarts.Register(c); err == nil {
+					logT("autostart.registered", e.Kind, e.Location)
+					rec.Autostart = append(rec.Autostart, e)
+				}
+			}
+			if err != nil {
+				errs = append(errs, c.Name+": "+err.Error())
+			}
+		}
+	} else if len(m.Autostart) > 0 {
+		rec.AutostartDeclined = true
+	}
+	if prev != nil {
+		for _, e := range prev.Autostart {
+			if !containsAutostart(rec.Autostart, e) {
+				removeAutostartEntry(e)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func containsAutostart(list []autostartEntry, e autostartEntry) bool {
+	for _, x := range list {
+		if x.Kind == e.Kind && strings.EqualFold(x.Location, e.Location) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildAutostart 解析自动启动项的入口与参数
+func buildAutostart(m InstallMeta, s autostartSpec, hc hookContext) (autostartCommand, error) {
+	if s.Name == "" {
+		s.Name = m.ProductName
+	}
+	c := autostartCommand{autostartSpec: s, Product: m.ProductName, Dir: hc.InstallDir}
+	path, err := entryPointPath(m, s.EntryPoint, hc.InstallDir)
+	if err != nil {
+		return c, err
+	}
+	c.Path = path
+	c.Args = nil
+	for _, a := range s.Args {
+		c.Args = append(c.Args, hc.expand(a))
+	}
+	return c, nil
+}
+
+// removeAutostart 卸载时删除安装记录中的自动启动项
+func removeAutostart(rec *installRecord) {
+	for _, e := range rec.Autostart {
+		removeAutostartEntry(e)
+	}
+}
+
+func removeAutostartEntry(e autostartEntry) {
+	if err := autostarts.Unregister(e); err != nil {
+		warnT("autostart.remove_failed", e.Location, err)
+		return
+	}
+	logT("autostart.removed", e.Kind, e.Location)
+}
@@ -0,0 +1,112 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 登录时启动写入 XDG autostart 目录（~/.config/autostart/<名称>.desktop）；
+// 按计划运行生成 systemd --user 的 <名称>-task.service 与 <名称>-task.timer。
+
+func (osAutostartManager) Register(c autostartCommand) (autostartEntry, error) {
+	config, err := knownFolders.Path(folderAppData)
+	if err != nil {
+		return autostartEntry{}, err
+	}
+	name := sanitizeLogName(c.Name)
+	if c.scheduled() {
+		dir := filepath.Join(config, "systemd", "user")
+		timer := filepath.Join(dir, name+"-task.timer")
+		return autostartEntry{Kind: autostartTimer, Location: timer}, writeTimer(c, timer)
+	}
+	path := filepath.Join(config, "autostart", name+".desktop")
+	return autostartEntry{Kind: autostartDesktop, Location: path}, writeAutostartFile(path, desktopEntry(c))
+}
+
+func writeAutostartFile(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	err := os.WriteFile(path, []byte(content), 0o644)
+	logFileOp("write", path, err)
+	return err
+}
+
+// desktopEntry 生成 XDG autostart 的 .desktop 文件内容
+func desktopEntry(c autostartCommand) string {
+	cmd := []string{desktopQuote(c.Path)}
+	for _, a := range c.Args {
+		cmd = append(cmd, desktopQuote(a))
+	}
+	return fmt.Sprintf("[Desktop Entry]\nType=Application\nName=%s\nExec=%s\nPath=%s\nTerminal=false\nX-GNOME-Autostart-enabled=true\n",
+		c.Name, strings.Join(cmd, " "), c.Dir)
+}
+
+// desktopQuote 按 Desktop Entry 规范引用 Exec 中的参数：含保留字符时加双引号并转义，
+// 再按字符串值的规则转义反斜杠；% 写为 %%
+func desktopQuote(s string) string {
+	s = strings.ReplaceAll(s, "%", "%%")
+	if s != "" && !strings.ContainsAny(s, " \t\n\"'\\><~|&;$*?#()`") {
+		return s
+	}
+	q := `"` + strings.NewReplacer(`"`, `\"`, "`", "\\`", `$`, `\$`, `\`, `\\`).Replace(s) + `"`
+	return strings.ReplaceAll(q, `\`, `\\`)
+}
+
+// writeTimer 写入一次性服务与定时器单元并启用定时器
+func writeTimer(c autostartCommand, timer string) error {
+	unit := strings.TrimSuffix(filepath.Base(timer), ".timer")
+	cmd := []string{unitQuote(c.Path)}
+	for _, a := range c.Args {
+		cmd = append(cmd, unitQuote(a))
+	}
+	service := fmt.Sprintf("[Unit]\nDescription=%s\n\n[Service]\nType=oneshot\nExecStart=%s\nWorkingDirectory=%s\n",
+		c.Name, strings.Join(cmd, " "), strings.ReplaceAll(c.Dir, "%", "%%"))
+	if err := writeAutostartFile(strings.TrimSuffix(timer, ".timer")+".service", service); err != nil {
+		return err
+	}
+	var when string
+	if c.Daily != "" {
+		when = fmt.Sprintf("OnCalendar=*-*-* %s:00\nPersistent=true\n", c.Daily)
+	} else {
+		when = fmt.Sprintf("OnStartupSec=%dmin\nOnUnitActiveSec=%dmin\n", c.IntervalMinutes, c.IntervalMinutes)
+	}
+	content := fmt.Sprintf("[Unit]\nDescription=%s\n\n[Timer]\n%s\n[Install]\nWantedBy=timers.target\n", c.Name, when)
+	if err := writeAutostartFile(timer, content); err != nil {
+		return err
+	}
+	if err := systemctl("daemon-reload"); err != nil {
+		return err
+	}
+	return systemctl("enable", "--now", unit+".timer")
+}
+
+func (osAutostartManager) Unregister(e autostartEntry) error {
+	if e.Kind != autostartTimer {
+		return removeAutostartFile(e.Location)
+	}
+	// 定时器文件可能已被手动删除，仍需停用定时器并删除对应的服务单元
+	if err := systemctl("disable", "--now", filepath.Base(e.Location)); err != nil {
+		logAt(levelWarn, err.Error())
+	}
+	service := strings.TrimSuffix(e.Location, ".timer") + ".service"
+	if err := errors.Join(removeAutostartFile(service), removeAutostartFile(e.Location)); err != nil {
+		return err
+	}
+	return systemctl("daemon-reload")
+}
+
+// removeAutostartFile 删除文件，不存在视为成功
+func removeAutostartFile(path string) error {
+	err := os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	logFileOp("remove", path, err)
+	return err
+}
@@ -0,0 +1,37 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUnregisterTimerWithoutTimerFile(t *testing.T) {
+	// systemctl 替换为只记录参数的脚本，不影响本机的用户单元
+	bin := t.TempDir()
+	log := filepath.Join(bin, "calls")
+	writeTestFile(t, filepath.Join(bin, "systemctl"), "#!/bin/sh\necho \"$@\" >> "+log+"\n")
+	if err := os.Chmod(filepath.Join(bin, "systemctl"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+
+	dir := t.TempDir()
+	timer := filepath.Join(dir, "app-task.timer")
+	service := filepath.Join(dir, "app-task.service")
+	writeTestFile(t, service, "[Service]\n")
+
+	if err := (osAutostartManager{}).Unregister(autostartEntry{Kind: autostartTimer, Location: timer}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(service); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("service unit left behind: %v", err)
+	}
+	want := "--user disable --now app-task.timer\n--user daemon-reload\n"
+	if got := readTestFile(t, log); got != want {
+		t.Errorf("systemctl calls = %q, want %q", got, want)
+	}
+}
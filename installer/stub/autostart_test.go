@@ -0,0 +1,205 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// fakeAutostartManager 记录调用顺序的假实现；fail 中的名称注册失败
+type fakeAutostartManager struct {
+	calls []string
+	fail  map[string]bool
+}
+
+func newFakeAutostarts(t *testing.T) *fakeAutostartManager {
+	f := &fakeAutostartManager{fail: map[string]bool{}}
+	old := autostarts
+	autostarts = f
+	t.Cleanup(func() { autostarts = old })
+	return f
+}
+
+func (f *fakeAutostartManager) Register(c autostartCommand) (autostartEntry, error) {
+	f.calls = append(f.calls, "register "+c.Name)
+	if f.fail[c.Name] {
+		return autostartEntry{}, errors.New("denied")
+	}
+	return autostartEntry{Kind: autostartDesktop, Location: c.Name}, nil
+}
+
+func (f *fakeAutostartManager) Unregister(e autostartEntry) error {
+	f.calls = append(f.calls, "unregister "+e.Location)
+	return nil
+}
+
+// installWithAutostart 与 tryInstallVersion 相同，但按 enabled 选择是否自动启动
+func installWithAutostart(t *testing.T, dir string, m InstallMeta, enabled bool) *installRecord {
+	t.Helper()
+	m.ProductName, m.ExeName = "svc-test", "app"
+	meta = m
+	files := []*inMemoryFile{{Name: "app", Mode: 0o755, Data: []byte(m.Version)}}
+	if _, err := install(files, dir, nil, enabled, func(int, string) {}); err != nil {
+		t.Fatalf("install %s: %v", m.Version, err)
+	}
+	rec, err := loadInstallRecord(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rec
+}
+
+func TestAutostartDefault(t *testing.T) {
+	spec := []autostartSpec{{Name: "tray"}}
+	tests := []struct {
+		name   string
+		args   []string
+		choice string
+		prev   *installRecord
+		want   bool
+	}{
+		{"checked by default", nil, "", nil, true},
+		{"unchecked", nil, autostartUnchecked, nil, false},
+		{"always", nil, autostartAlways, &installRecord{AutostartDeclined: true}, true},
+		{"switch forces on", []string{"/AUTOSTART"}, autostartUnchecked, nil, true},
+		{"switch forces off", []string{"/AUTOSTART=0"}, autostartChecked, nil, false},
+		{"switch off overrides always", []string{"/AUTOSTART=0"}, autostartAlways, nil, false},
+		{"upgrade keeps registration", nil, autostartUnchecked, &installRecord{Autostart: []autostartEntry{{Kind: autostartRun, Location: "tray"}}}, true},
+		{"upgrade keeps decline", nil, autostartChecked, &installRecord{AutostartDeclined: true}, false},
+		{"upgrade from version without autostart", nil, autostartChecked, &installRecord{}, true},
+		{"switch overrides decline", []string{"/AUTOSTART=1"}, autostartChecked, &installRecord{AutostartDeclined: true}, true},
+	}
+	for _, tt := range tests {
+		setTestSwitches(t, tt.args...)
+		m := InstallMeta{Autostart: spec, AutostartChoice: tt.choice}
+		if got := autostartDefault(m, tt.prev); got != tt.want {
+			t.Errorf("%s: autostartDefault = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBuildAutostart(t *testing.T) {
+	dir := t.TempDir()
+	m := InstallMeta{
+		ProductName: "App",
+		Version:     "1.2.0",
+		EntryPoints: []entryPointSpec{
+			{Name: "main", Windows: "app.exe", Linux: "app", Main: true},
+			{Name: "agent", Windows: `bin\agent.exe`, Linux: "bin/agent"},
+		},
+	}
+	hc := hookContext{InstallDir: dir, ProductName: m.ProductName, Version: m.Version}
+
+	c, err := buildAutostart(m, autostartSpec{Args: []string{"--minimized", "--config={InstallDir}/app.conf", "{ProductName} {Version}"}}, hc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "App" || c.Product != "App" || c.Dir != dir {
+		t.Errorf("name, product, dir = %q, %q, %q", c.Name, c.Product, c.Dir)
+	}
+	if want := filepath.Join(dir, filepath.FromSlash(mainEntry(m))); c.Path != want {
+		t.Errorf("path = %q, want %q", c.Path, want)
+	}
+	if want := []string{"--minimized", "--config=" + dir + "/app.conf", "App 1.2.0"}; !reflect.DeepEqual(c.Args, want) {
+		t.Errorf("args = %q, want %q", c.Args, want)
+	}
+
+	c, err = buildAutostart(m, autostartSpec{Name: "Sync", EntryPoint: "agent", IntervalMinutes: 30}, hc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, filepath.FromSlash(entryPoints(m)["agent"])); c.Name != "Sync" || c.Path != want || !c.scheduled() {
+		t.Errorf("agent = %+v, want Sync at %s, scheduled", c, want)
+	}
+
+	if _, err := buildAutostart(m, autostartSpec{EntryPoint: "missing"}, hc); err == nil {
+		t.Error("unknown entry point: want error")
+	}
+}
+
+func TestApplyAutostart(t *testing.T) {
+	setupTestInstall(t)
+	prev := &installRecord{Autostart: []autostartEntry{{Kind: autostartDesktop, Location: "tray"}, {Kind: autostartDesktop, Location: "old"}}}
+	tests := []struct {
+		name     string
+		specs    []autostartSpec
+		fail     string
+		prev     *installRecord
+		enabled  bool
+		calls    []string
+		entries  []string
+		declined bool
+		wantErr  bool
+	}{
+		{"first install", []autostartSpec{{Name: "tray"}}, "", nil, true,
+			[]string{"register tray"}, []string{"tray"}, false, false},
+		{"declined", []autostartSpec{{Name: "tray"}}, "", nil, false,
+			nil, nil, true, false},
+		{"upgrade removes stale", []autostartSpec{{Name: "tray"}}, "", prev, true,
+			[]string{"register tray", "unregister old"}, []string{"tray"}, false, false},
+		{"upgrade declined removes all", []autostartSpec{{Name: "tray"}}, "", prev, false,
+			[]string{"unregister tray", "unregister old"}, nil, true, false},
+		{"dropped by new version", nil, "", prev, true,
+			[]string{"unregister tray", "unregister old"}, nil, false, false},
+		{"register failure continues", []autostartSpec{{Name: "tray"}, {Name: "sync", Daily: "03:00"}}, "tray", nil, true,
+			[]string{"register tray", "register sync"}, []string{"sync"}, false, true},
+	}
+	for _, tt := range tests {
+		f := newFakeAutostarts(t)
+		if tt.fail != "" {
+			f.fail[tt.fail] = true
+		}
+		m := InstallMeta{ProductName: "svc-test", ExeName: "app", Autostart: tt.specs}
+		rec := &installRecord{}
+		err := applyAutostart(m, hookContext{InstallDir: t.TempDir()}, rec, tt.prev, tt.enabled)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		var entries []string
+		for _, e := range rec.Autostart {
+			entries = append(entries, e.Location)
+		}
+		if !reflect.DeepEqual(f.calls, tt.calls) || !reflect.DeepEqual(entries, tt.entries) || rec.AutostartDeclined != tt.declined {
+			t.Errorf("%s: calls %v entries %v declined %v; want %v %v %v",
+				tt.name, f.calls, entries, rec.AutostartDeclined, tt.calls, tt.entries, tt.declined)
+		}
+	}
+}
+
+func TestAutostartAcrossUpgrades(t *testing.T) {
+	dir := setupTestInstall(t)
+	setTestSwitches(t)
+	newFakeServices(t)
+	f := newFakeAutostarts(t)
+	m := InstallMeta{Version: "1.0.0", Autostart: []autostartSpec{{Name: "tray"}}}
+
+	rec := installWithAutostart(t, dir, m, true)
+	if !autostartDefault(m, rec) {
+		t.Error("after enabling: default for the next upgrade is off")
+	}
+
+	// 用户在升级时取消勾选：删除旧项，记住选择
+	m.Version = "2.0.0"
+	rec = installWithAutostart(t, dir, m, false)
+	if !rec.AutostartDeclined || len(rec.Autostart) != 0 {
+		t.Errorf("declined record = %+v", rec)
+	}
+	if autostartDefault(m, rec) {
+		t.Error("after declining: default for the next upgrade is on")
+	}
+
+	// 重新勾选后新版本改名，之后的版本不再提供自动启动
+	m.Version, m.Autostart = "3.0.0", []autostartSpec{{Name: "agent"}}
+	installWithAutostart(t, dir, m, true)
+	m.Version, m.Autostart = "4.0.0", nil
+	rec = installWithAutostart(t, dir, m, true)
+	if len(rec.Autostart) != 0 {
+		t.Errorf("entries after autostart was dropped = %v", rec.Autostart)
+	}
+
+	want := []string{"register tray", "unregister tray", "register agent", "unregister agent"}
+	if !reflect.DeepEqual(f.calls, want) {
+		t.Errorf("calls = %v, want %v", f.calls, want)
+	}
+}
@@ -0,0 +1,105 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+const runKey = `Software\Microsoft\Windows\CurrentVersion\Run`
+
+// commandLine 将路径与参数拼接为 Windows 命令行
+func commandLine(path string, args []string) string {
+	s := syscall.EscapeArg(path)
+	for _, a := range args {
+		s += " " + syscall.EscapeArg(a)
+	}
+	return s
+}
+
+func (osAutostartManager) Register(c autostartCommand) (autostartEntry, error) {
+	switch {
+	case c.scheduled():
+		name := sanitizeFilename(c.Product) + `\` + sanitizeFilename(c.Name)
+		args := []string{"/Create", "/F", "/TN", name, "/TR", commandLine(c.Path, c.Args)}
+		switch {
+		case c.Daily != "":
+			args = append(args, "/SC", "DAILY", "/ST", c.Daily)
+		case c.IntervalMinutes%1440 == 0:
+			args = append(args, "/SC", "DAILY", "/MO", fmt.Sprint(c.IntervalMinutes/1440))
+		default:
+			args = append(args, "/SC", "MINUTE", "/MO", fmt.Sprint(c.IntervalMinutes))
+		}
+		return autostartEntry{Kind: autostartTask, Location: name}, schtasks(args...)
+	case c.StartupFolder:
+		dir, err := knownFolders.Path(folderStartup)
+		if err != nil {
+			return autostartEntry{}, err
+		}
+		link := filepath.Join(dir, sanitizeFilename(c.Name)+".lnk")
+		var args []string
+		for _, a := range c.Args {
+			args = append(args, syscall.EscapeArg(a))
+		}
+		return autostartEntry{Kind: autostartStartup, Location: link}, createShortcutArgs(link, c.Path, c.Dir, "", strings.Join(args, " "))
+	default:
+		k, _, err := registry.CreateKey(registry.CURRENT_USER, runKey, registry.SET_VALUE)
+		if err != nil {
+			return autostartEntry{}, err
+		}
+		defer k.Close()
+		return autostartEntry{Kind: autostartRun, Location: c.Name}, k.SetStringValue(c.Name, commandLine(c.Path, c.Args))
+	}
+}
+
+func (osAutostartManager) Unregister(e autostartEntry) error {
+	switch e.Kind {
+	case autostartTask:
+		if schtasks("/Query", "/TN", e.Location) != nil {
+			return nil // 任务不存在
+		}
+		return schtasks("/Delete", "/F", "/TN", e.Location)
+	case autostartStartup:
+		err := os.Remove(e.Location)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		logFileOp("remove", e.Location, err)
+		return err
+	case autostartRun:
+		k, err := registry.OpenKey(registry.CURRENT_USER, runKey, registry.SET_VALUE)
+		if err != nil {
+			return nil
+		}
+		defer k.Close()
+		err = k.DeleteValue(e.Location)
+		if errors.Is(err, registry.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// schtasks 隐藏窗口运行 schtasks.exe，错误中附带其输出
+func schtasks(args ...string) error {
+	cmd := exec.Command("schtasks.exe", args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true, CreationFlags: windows.CREATE_NO_WINDOW}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("schtasks %s: %s", args[0], msg)
+		}
+		return fmt.Errorf("schtasks %s: %w", args[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, p, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readTestFile(t *testing.T, p string) string {
+	t.Helper()
+	data, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRollbackKeepsKeptEntries(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "app")
+	writeTestFile(t, filepath.Join(dir, "app"), "old")
+	writeTestFile(t, filepath.Join(dir, "lib", "a.so"), "old lib")
+	writeTestFile(t, filepath.Join(dir, "uninstall"), "maintenance")
+
+	b, err := cleanInstallDir(&cleanPlan{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 模拟运行中的维护程序：移回原处并标记为保留
+	if err := os.Rename(filepath.Join(b.backup, "uninstall"), filepath.Join(dir, "uninstall")); err != nil {
+		t.Fatal(err)
+	}
+	b.kept["uninstall"] = true
+	writeTestFile(t, filepath.Join(dir, "app"), "new")
+	writeTestFile(t, filepath.Join(dir, "extra"), "new only")
+
+	backup := b.backup
+	if err := b.rollback(); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if got := readTestFile(t, filepath.Join(dir, "app")); got != "old" {
+		t.Errorf("app = %q, want old", got)
+	}
+	if got := readTestFile(t, filepath.Join(dir, "lib", "a.so")); got != "old lib" {
+		t.Errorf("lib/a.so = %q", got)
+	}
+	if got := readTestFile(t, filepath.Join(dir, "uninstall")); got != "maintenance" {
+		t.Errorf("kept uninstall = %q, want it untouched", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "extra")); !os.IsNotExist(err) {
+		t.Errorf("new file extra not removed: %v", err)
+	}
+	if _, err := os.Stat(backup); !os.IsNotExist(err) {
+		t.Errorf("backup dir not removed: %v", err)
+	}
+}
+
+func TestRollbackContinuesAfterFailure(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "app")
+	writeTestFile(t, filepath.Join(dir, "app"), "old")
+	writeTestFile(t, filepath.Join(dir, "data", "old.db"), "old db")
+
+	b, err := cleanInstallDir(&cleanPlan{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 保留的 data 目录非空，旧的 data 无法移回
+	writeTestFile(t, filepath.Join(dir, "data", "locked.db"), "locked")
+	b.kept["data"] = true
+	writeTestFile(t, filepath.Join(dir, "app"), "new")
+
+	backup := b.backup
+	if err := b.rollback(); err == nil {
+		t.Fatal("rollback: want error for data")
+	}
+	if got := readTestFile(t, filepath.Join(dir, "app")); got != "old" {
+		t.Errorf("app = %q, want old restored despite the data failure", got)
+	}
+	if got := readTestFile(t, filepath.Join(backup, "data", "old.db")); got != "old db" {
+		t.Errorf("unrestored data should stay in the backup, got %q", got)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"exe_installer/installer/internal/version"
+)
+
+// checkPlatform 在显示向导之前检查 CPU 架构与最低系统版本
+func checkPlatform(m InstallMeta) error {
+	if m.Arch != "" {
+		if native := nativeArch(); !archCanRun(native, m.Arch) {
+			return withExitCode(exitArchMismatch, errors.New(T("check.arch", m.Arch, native)))
+		}
+	}
+	if m.MinOSVersion != "" {
+		if cur := osVersion(); cur != "" && version.CompareNumbers(cur, m.MinOSVersion) < 0 {
+			return withExitCode(exitOldOSVersion, errors.New(T("check.os_version", m.MinOSVersion, cur)))
+		}
+	}
+	return nil
+}
+
+// checkTarget 在清理旧文件之前检查安装目录可写且所在卷有足够空间。
+// 旧文件被移动到同一卷上的备份目录，不会腾出空间，因此需要完整的 required 字节。
+func checkTarget(dir string, required int64) error {
+	existing := nearestExistingDir(dir)
+	f, err := os.CreateTemp(existing, ".setup-write-test-")
+	if err != nil {
+		return withExitCode(exitAccessDenied, errors.New(T("check.not_writable", dir, err)))
+	}
+	f.Close()
+	_ = os.Remove(f.Name())
+
+	need := required + required/20 // 预留 5% 文件系统开销
+	if free, err := freeDiskSpace(existing); err == nil && free < uint64(need) {
+		return withExitCode(exitDiskFull, errors.New(T("check.disk_space", existing, formatSize(need), formatSize(int64(free)))))
+	}
+	return nil
+}
+
+// nearestExistingDir 返回 dir 自身或最近的已存在上级目录
+func nearestExistingDir(dir string) string {
+	for {
+		if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// archCanRun 判断本机架构能否运行为 target 构建的程序（含系统自带的模拟层）
+func archCanRun(native, target string) bool {
+	switch {
+	case native == target:
+		return true
+	case native == "amd64":
+		return target == "386"
+	case native == "arm64":
+		// Windows 11 on ARM 可模拟 x86 与 x64
+		return target == "arm" || runtime.GOOS == "windows" && (target == "386" || target == "amd64")
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+//go:build !windows
+
+package main
+
+import (
+	"runtime"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// freeDiskSpace 返回 dir 所在卷对当前用户可用的字节数
+func freeDiskSpace(dir string) (uint64, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(dir, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
+
+// osVersion 返回内核版本（uname -r）
+func osVersion() string {
+	var u unix.Utsname
+	if err := unix.Uname(&u); err != nil {
+		return ""
+	}
+	return unix.ByteSliceToString(u.Release[:])
+}
+
+// nativeArch 返回本机 CPU 架构（GOARCH 命名）
+func nativeArch() string {
+	var u unix.Utsname
+	if err := unix.Uname(&u); err != nil {
+		return runtime.GOARCH
+	}
+	switch m := unix.ByteSliceToString(u.Machine[:]); {
+	case m == "x86_64" || m == "amd64":
+		return "amd64"
+	case m == "aarch64" || m == "arm64":
+		return "arm64"
+	case m == "i386" || m == "i686":
+		return "386"
+	case strings.HasPrefix(m, "arm"):
+		return "arm"
+	}
+	return runtime.GOARCH
+}
@@ -0,0 +1,53 @@
+//go:build windows
+
+package main
+
+import (
+	"debug/pe"
+	"fmt"
+	"runtime"
+
+	"golang.org/x/sys/windows"
+)
+
+// freeDiskSpace 返回 dir 所在卷对当前用户可用的字节数
+func freeDiskSpace(dir string) (uint64, error) {
+	p, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, err
+	}
+	var avail, total, totalFree uint64
+	if err := windows.GetDiskFreeSpaceEx(p, &avail, &total, &totalFree); err != nil {
+		return 0, err
+	}
+	return avail, nil
+}
+
+// osVersion 返回 "主.次.内部版本号"，例如 "10.0.22631"（RtlGetVersion 不受兼容性清单影响）
+func osVersion() string {
+	v := windows.RtlGetVersion()
+	return fmt.Sprintf("%d.%d.%d", v.MajorVersion, v.MinorVersion, v.BuildNumber)
+}
+
+// nativeArch 返回本机 CPU 架构；32 位或模拟运行的 stub 也能得到真实架构
+func nativeArch() string {
+	var process, native uint16
+	if err := windows.IsWow64Process2(windows.CurrentProcess(), &process, &native); err == nil {
+		switch native {
+		case pe.IMAGE_FILE_MACHINE_AMD64:
+			return "amd64"
+		case pe.IMAGE_FILE_MACHINE_ARM64:
+			return "arm64"
+		case pe.IMAGE_FILE_MACHINE_I386:
+			return "386"
+		case pe.IMAGE_FILE_MACHINE_ARMNT:
+			return "arm"
+		}
+	}
+	// Windows 10 1709 之前没有 IsWow64Process2
+	var wow64 bool
+	if runtime.GOARCH == "386" && windows.IsWow64Process(windows.CurrentProcess(), &wow64) == nil && wow64 {
+		return "amd64"
+	}
+	return runtime.GOARCH
+}
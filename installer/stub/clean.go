@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 安装目录清理策略：
+//  1. 拒绝系统与用户的已知目录（主目录、桌面、文档、Windows、/usr 等）及其上级目录；
+//  2. 带有本产品安装记录（或旧版本 meta.json）的目录可直接清理；
+//  3. 属于其他产品的目录拒绝清理；
+//  4. 其他非空目录需用户确认，静默安装时拒绝。
+// /DRYRUN 只列出将被删除的内容，不做任何修改。
+
+// cleanPlan 对安装目录的评估结果
+type cleanPlan struct {
+	Dir     string
+	Entries []string // 现有条目，目录以 / 结尾
+	Owned   bool     // 带有本产品的安装标记
+}
+
+// planClean 按清理策略评估安装目录；目录不存在或为空时 Entries 为空
+func planClean(dir string) (*cleanPlan, error) {
+	dir = filepath.Clean(dir)
+	if protectedDir(dir) {
+		return nil, errors.New(T("clean.protected", dir))
+	}
+	p := &cleanPlan{Dir: dir}
+	info, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, errors.New(T("clean.not_dir", dir))
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() {
+			name += "/"
+		}
+		p.Entries = append(p.Entries, name)
+	}
+	if len(p.Entries) == 0 {
+		return p, nil
+	}
+	switch owner := installOwner(dir); {
+	case owner != "" && owner == meta.ProductName:
+		p.Owned = true
+	case owner != "":
+		return nil, errors.New(T("clean.other_product", dir, owner))
+	}
+	return p, nil
+}
+
+// confirmClean 对未知的非空目录请求确认；静默安装时默认取消
+func confirmClean(p *cleanPlan) error {
+	if p.Owned || len(p.Entries) == 0 {
+		return nil
+	}
+	choice := ui.Choose(T("clean.confirm_unknown", p.Dir, len(p.Entries), previewEntries(p.Entries, 10)),
+		[]string{T("clean.choice_delete"), T("clean.choice_cancel")}, 1)
+	if choice != 0 {
+		return withExitCode(exitCancelled, errors.New(T("clean.cancelled", p.Dir)))
+	}
+	logT("clean.confirmed", p.Dir)
+	return nil
+}
+
+// installOwner 返回目录中安装记录（或旧版本 meta.json）所属的产品名，没有时为空
+func installOwner(dir string) string {
+	if rec, err := loadInstallRecord(dir); err == nil {
+		return rec.ProductName
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "meta.json"))
+	if err != nil {
+		return ""
+	}
+	var m struct {
+		ProductName string `json:"productName"`
+	}
+	if json.Unmarshal(data, &m) != nil {
+		return ""
+	}
+	return m.ProductName
+}
+
+// protectedDir 判断 dir 是否为盘符根目录、已知目录，或已知目录的上级目录（例如 C:\Users、/home）
+func protectedDir(dir string) bool {
+	d := normPath(dir)
+	if filepath.Dir(filepath.Clean(dir)) == filepath.Clean(dir) {
+		return true
+	}
+	sep := string(filepath.Separator)
+	for _, p := range protectedDirs() {
+		if p == "" {
+			continue
+		}
+		pn := normPath(p)
+		if d == pn || strings.HasPrefix(pn, strings.TrimSuffix(d, sep)+sep) {
+			return true
+		}
+	}
+	return false
+}
+
+// previewEntries 列出前 max 个条目
+func previewEntries(entries []string, max int) string {
+	var b strings.Builder
+	for i, e := range entries {
+		if i == max {
+			b.WriteString("  " + T("clean.more", len(entries)-max) + "\n")
+			break
+		}
+		b.WriteString("  " + e + "\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// runCleanDryRun 对应 /DRYRUN：列出安装时将被删除的内容，不做任何修改
+func runCleanDryRun(dir string) int {
+	p, err := planClean(dir)
+	if err != nil {
+		reportError(err.Error())
+		runLog.Close(err)
+		return exitCodeOf(err)
+	}
+	var msg string
+	switch {
+	case len(p.Entries) == 0:
+		msg = T("clean.dryrun_nothing", p.Dir)
+	case p.Owned:
+		msg = T("clean.dryrun_owned", p.Dir, previewEntries(p.Entries, len(p.Entries)))
+	default:
+		msg = T("clean.dryrun_unknown", p.Dir, previewEntries(p.Entries, len(p.Entries)))
+	}
+	runLog.write(logEntry{Level: levelInfo, Msg: msg})
+	runLog.Close(nil)
+	ui.Info(msg)
+	return exitSuccess
+}
@@ -0,0 +1,32 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// systemDirs 不能作为安装目录清理的系统目录
+var systemDirs = []string{
+	"/bin", "/boot", "/dev", "/etc", "/home", "/lib", "/lib32", "/lib64", "/media", "/mnt", "/opt",
+	"/proc", "/root", "/run", "/sbin", "/srv", "/sys", "/tmp", "/usr", "/usr/bin", "/usr/lib",
+	"/usr/local", "/usr/local/bin", "/usr/share", "/var", "/var/lib", "/var/tmp",
+}
+
+func protectedDirs() []string {
+	dirs := append([]string{}, systemDirs...)
+	for _, id := range []string{folderUserHome, folderLocalAppData, folderAppData, folderDesktop, folderDocuments, folderDownloads} {
+		if p, err := knownFolders.Path(id); err == nil {
+			dirs = append(dirs, p)
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		for _, d := range []string{"Music", "Pictures", "Videos", "bin", ".local", ".local/bin", ".cache", ".config"} {
+			dirs = append(dirs, filepath.Join(home, d))
+		}
+	}
+	return dirs
+}
+
+func normPath(p string) string { return filepath.Clean(p) }
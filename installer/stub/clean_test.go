@@ -0,0 +1,192 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// recordingUI 静默控制台，另外记录 Info 与 Error 显示的内容
+type recordingUI struct {
+	consoleUI
+	infos, errors []string
+}
+
+func (r *recordingUI) Info(msg string)  { r.infos = append(r.infos, msg) }
+func (r *recordingUI) Error(msg string) { r.errors = append(r.errors, msg) }
+
+// setupCleanTest 准备用户目录与已知目录，当前产品为 clean-test，返回主目录
+func setupCleanTest(t *testing.T) string {
+	t.Helper()
+	setupTestInstall(t)
+	meta.ProductName = "clean-test"
+	home := os.Getenv("HOME")
+	setFakeKnownFolders(t, fakeKnownFolders{
+		folderUserHome:  home,
+		folderDesktop:   filepath.Join(home, "Desktop"),
+		folderDocuments: filepath.Join(home, "Documents"),
+		folderWindows:   filepath.Join(home, "Windows"),
+	})
+	return home
+}
+
+func TestProtectedDir(t *testing.T) {
+	home := setupCleanTest(t)
+	type dirCase struct {
+		dir  string
+		want bool
+	}
+	tests := []dirCase{
+		{home, true},
+		{filepath.Dir(home), true},
+		{filepath.Join(home, "Desktop"), true},
+		{filepath.Join(home, "Documents"), true},
+		{filepath.Join(home, "Documents") + string(filepath.Separator), true},
+		{filepath.Join(home, "Desktop", "MyApp"), false},
+		{filepath.Join(home, "Apps", "MyApp"), false},
+	}
+	if runtime.GOOS == "windows" {
+		tests = append(tests, dirCase{filepath.Join(home, "Windows"), true}, dirCase{`C:\`, true})
+	} else {
+		tests = append(tests, dirCase{"/", true}, dirCase{"/usr", true}, dirCase{"/usr/", true}, dirCase{"/opt/myapp", false})
+	}
+	for _, tt := range tests {
+		if got := protectedDir(tt.dir); got != tt.want {
+			t.Errorf("protectedDir(%q) = %v, want %v", tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestPlanClean(t *testing.T) {
+	home := setupCleanTest(t)
+	root := t.TempDir()
+	withRecord := func(product string) func(string) {
+		return func(dir string) {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := saveInstallRecord(&installRecord{ProductName: product, InstallDir: dir}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	tests := []struct {
+		name    string
+		dir     string
+		setup   func(dir string)
+		entries []string
+		owned   bool
+		wantErr bool
+	}{
+		{"protected home", home, nil, nil, false, true},
+		{"protected documents", filepath.Join(home, "Documents"), nil, nil, false, true},
+		{"missing", filepath.Join(root, "missing"), nil, nil, false, false},
+		{"empty", filepath.Join(root, "empty"), func(dir string) {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+		}, nil, false, false},
+		{"our record", filepath.Join(root, "ours"), withRecord("clean-test"), []string{recordFileName}, true, false},
+		{"our legacy meta.json", filepath.Join(root, "legacy"), func(dir string) {
+			writeTestFile(t, filepath.Join(dir, "meta.json"), `{"productName":"clean-test"}`)
+		}, []string{"meta.json"}, true, false},
+		{"other product", filepath.Join(root, "other"), withRecord("other-app"), nil, false, true},
+		{"other legacy meta.json", filepath.Join(root, "other-legacy"), func(dir string) {
+			writeTestFile(t, filepath.Join(dir, "meta.json"), `{"productName":"other-app"}`)
+		}, nil, false, true},
+		{"unknown", filepath.Join(root, "unknown"), func(dir string) {
+			writeTestFile(t, filepath.Join(dir, "notes.txt"), "x")
+			writeTestFile(t, filepath.Join(dir, "sub", "a.txt"), "x")
+		}, []string{"notes.txt", "sub/"}, false, false},
+		{"file", filepath.Join(root, "file"), func(dir string) { writeTestFile(t, dir, "x") }, nil, false, true},
+	}
+	for _, tt := range tests {
+		if tt.setup != nil {
+			tt.setup(tt.dir)
+		}
+		p, err := planClean(tt.dir)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: planClean err = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if strings.Join(p.Entries, ",") != strings.Join(tt.entries, ",") || p.Owned != tt.owned {
+			t.Errorf("%s: plan = %+v, want entries %v owned %v", tt.name, p, tt.entries, tt.owned)
+		}
+	}
+}
+
+func TestConfirmClean(t *testing.T) {
+	setupCleanTest(t)
+	unknown := &cleanPlan{Dir: "/opt/unknown", Entries: []string{"notes.txt"}}
+	tests := []struct {
+		name   string
+		plan   *cleanPlan
+		silent bool
+		input  string
+		ok     bool
+	}{
+		{"owned", &cleanPlan{Dir: "/opt/ours", Entries: []string{"app"}, Owned: true}, true, "", true},
+		{"empty", &cleanPlan{Dir: "/opt/empty"}, true, "", true},
+		{"unknown silent", unknown, true, "", false},
+		{"unknown confirmed", unknown, false, "1\n", true},
+		{"unknown cancelled", unknown, false, "2\n", false},
+		{"unknown default", unknown, false, "\n", false},
+		{"unknown eof", unknown, false, "", false},
+	}
+	for _, tt := range tests {
+		ui = &consoleUI{silent: tt.silent}
+		setTestStdin(t, tt.input)
+		err := confirmClean(tt.plan)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: confirmClean = %v, want ok %v", tt.name, err, tt.ok)
+		}
+		if err != nil && exitCodeOf(err) != exitCancelled {
+			t.Errorf("%s: exit code = %d, want %d", tt.name, exitCodeOf(err), exitCancelled)
+		}
+	}
+}
+
+func TestRunCleanDryRun(t *testing.T) {
+	home := setupCleanTest(t)
+	dir := filepath.Join(t.TempDir(), "app")
+	writeTestFile(t, filepath.Join(dir, "notes.txt"), "x")
+	writeTestFile(t, filepath.Join(dir, "data", "a.db"), "x")
+	r := &recordingUI{consoleUI: consoleUI{silent: true}}
+	ui = r
+
+	if code := runCleanDryRun(dir); code != exitSuccess {
+		t.Fatalf("exit code = %d, want %d", code, exitSuccess)
+	}
+	want := T("clean.dryrun_unknown", dir, "  data/\n  notes.txt")
+	if len(r.infos) != 1 || r.infos[0] != want {
+		t.Errorf("listing = %q, want %q", r.infos, want)
+	}
+	// 只列出，不删除
+	if got := readTestFile(t, filepath.Join(dir, "notes.txt")); got != "x" {
+		t.Errorf("notes.txt = %q after dry run", got)
+	}
+
+	// 带有本产品安装记录的目录
+	r.infos = nil
+	if err := saveInstallRecord(&installRecord{ProductName: "clean-test", InstallDir: dir}); err != nil {
+		t.Fatal(err)
+	}
+	runCleanDryRun(dir)
+	want = T("clean.dryrun_owned", dir, "  data/\n  "+recordFileName+"\n  notes.txt")
+	if len(r.infos) != 1 || r.infos[0] != want {
+		t.Errorf("owned listing = %q, want %q", r.infos, want)
+	}
+
+	r.infos = nil
+	if code := runCleanDryRun(home); code == exitSuccess {
+		t.Error("dry run of the home directory succeeded")
+	}
+	if len(r.errors) != 1 || len(r.infos) != 0 {
+		t.Errorf("home: errors %q, infos %q; want one error", r.errors, r.infos)
+	}
+}
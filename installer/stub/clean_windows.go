@@ -0,0 +1,33 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func protectedDirs() []string {
+	var dirs []string
+	for _, id := range []string{
+		folderProgramFiles64, folderProgramFilesX86, folderLocalAppData, folderAppData, folderUserHome,
+		folderDesktop, folderPrograms, folderDocuments, folderDownloads, folderWindows, folderSystem,
+		folderProgramData, folderPublic,
+	} {
+		if p, err := knownFolders.Path(id); err == nil {
+			dirs = append(dirs, p)
+		}
+	}
+	for _, env := range []string{"SystemRoot", "ProgramFiles", "ProgramFiles(x86)", "ProgramW6432", "ProgramData", "TEMP"} {
+		if p := os.Getenv(env); p != "" {
+			dirs = append(dirs, p)
+		}
+	}
+	if local, err := knownFolders.Path(folderLocalAppData); err == nil {
+		dirs = append(dirs, filepath.Join(local, "Programs"), filepath.Join(local, "Temp"))
+	}
+	return dirs
+}
+
+func normPath(p string) string { return strings.ToLower(filepath.Clean(p)) }
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// switches 保存命令行开关，形如 /S、/UNINSTALL、/KEY=VALUE。
+// 开关名大小写不敏感，统一转为大写；同时接受 - 与 -- 前缀。
+var switches = parseSwitches(os.Args[1:])
+
+func parseSwitches(args []string) map[string]string {
+	out := make(map[string]string)
+	for _, a := range args {
+		var name string
+		switch {
+		case strings.HasPrefix(a, "--"):
+			name = a[2:]
+		case strings.HasPrefix(a, "/"), strings.HasPrefix(a, "-"):
+			name = a[1:]
+		default:
+			continue // 非开关参数忽略
+		}
+		value := ""
+		if i := strings.IndexAny(name, "=:"); i >= 0 {
+			name, value = name[:i], name[i+1:]
+		}
+		if name == "" {
+			continue
+		}
+		out[strings.ToUpper(name)] = value
+	}
+	return out
+}
+
+func hasSwitch(name string) bool {
+	_, ok := switches[strings.ToUpper(name)]
+	return ok
+}
+
+func switchValue(name string) (string, bool) {
+	v, ok := switches[strings.ToUpper(name)]
+	return v, ok
+}
+
+// silentMode 对应 /S：不等待用户输入
+func silentMode() bool { return hasSwitch("S") }
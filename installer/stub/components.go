@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// componentSpec 与 meta.json 中 components 的元素对应
+type componentSpec struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Files       []string            `json:"files"` // 归档中的路径，即安装目录内的相对路径
+	Size        int64               `json:"size"`
+	Shortcuts   []componentShortcut `json:"shortcuts"`
+	Registry    []registryValue     `json:"registry"`
+	Default     bool                `json:"default"`
+	Required    bool                `json:"required"`
+	Depends     []string            `json:"depends"`
+}
+
+type componentShortcut struct {
+	Name      string `json:"name"`
+	Target    string `json:"target"` // 相对安装目录
+	Desktop   bool   `json:"desktop"`
+	StartMenu bool   `json:"startMenu"`
+}
+
+// registryValue 组件写入的注册表值（HKCU），同时记录在安装记录中供卸载删除
+type registryValue struct {
+	Key   string `json:"key"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Type  string `json:"type,omitempty"` // string / expand / dword
+}
+
+// initialComponents 生成组件页的初始选择：/COMPONENTS= 开关优先，其次沿用上次安装的选择
+// （上次未提供的新组件按 Default），都没有时按 Default；最后补齐依赖。
+func initialComponents(specs []componentSpec, prev *installRecord) []wizardComponent {
+	chosen := map[string]bool{}
+	explicit, hasExplicit := switchValue("COMPONENTS")
+	if hasExplicit {
+		for _, n := range strings.Split(explicit, ",") {
+			chosen[strings.TrimSpace(n)] = true
+		}
+	}
+	offered := map[string]bool{}
+	if prev != nil {
+		for _, n := range prev.OfferedComponents {
+			offered[n] = true
+		}
+		for _, n := range prev.Components {
+			offered[n] = true
+		}
+	}
+
+	out := make([]wizardComponent, len(specs))
+	for i, s := range specs {
+		sel := s.Default
+		switch {
+		case hasExplicit:
+			sel = chosen[s.Name]
+		case offered[s.Name]:
+			sel = prev.hasComponent(s.Name)
+		}
+		out[i] = wizardComponent{
+			Name:        s.Name,
+			Description: s.Description,
+			Size:        s.Size,
+			Depends:     s.Depends,
+			Selected:    sel || s.Required,
+			Required:    s.Required,
+		}
+	}
+	resolveDepends(out)
+	return out
+}
+
+// selectedComponentNames 返回已选（含必选）组件的名称
+func selectedComponentNames(comps []wizardComponent) []string {
+	var out []string
+	for _, c := range comps {
+		if c.Selected || c.Required {
+			out = append(out, c.Name)
+		}
+	}
+	return out
+}
+
+// resolveDepends 选中已选组件依赖的所有组件
+func resolveDepends(comps []wizardComponent) {
+	idx := map[string]int{}
+	for i, c := range comps {
+		idx[c.Name] = i
+	}
+	var sel func(i int)
+	sel = func(i int) {
+		for _, d := range comps[i].Depends {
+			if j, ok := idx[d]; ok && !comps[j].Selected {
+				comps[j].Selected = true
+				sel(j)
+			}
+		}
+	}
+	for i := range comps {
+		if comps[i].Selected {
+			sel(i)
+		}
+	}
+}
+
+// componentFiles 去掉未选组件的文件；不属于任何组件的文件总是保留
+func componentFiles(files []*inMemoryFile, specs []componentSpec, selected []string) []*inMemoryFile {
+	skip := unselectedFiles(specs, selected)
+	out := make([]*inMemoryFile, 0, len(files))
+	for _, f := range files {
+		if !skip[f.Name] {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// unselectedFiles 返回未选组件的文件（归档中的路径）
+func unselectedFiles(specs []componentSpec, selected []string) map[string]bool {
+	keep := map[string]bool{}
+	for _, n := range selected {
+		keep[n] = true
+	}
+	skip := map[string]bool{}
+	for _, s := range specs {
+		if keep[s.Name] {
+			continue
+		}
+		for _, f := range s.Files {
+			skip[f] = true
+		}
+	}
+	return skip
+}
+
+// selectedSpecs 返回已选组件的描述
+func selectedSpecs(specs []componentSpec, selected []string) []componentSpec {
+	keep := map[string]bool{}
+	for _, n := range selected {
+		keep[n] = true
+	}
+	var out []componentSpec
+	for _, s := range specs {
+		if keep[s.Name] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func componentNames(specs []componentSpec) []string {
+	out := make([]string, len(specs))
+	for i, s := range specs {
+		out[i] = s.Name
+	}
+	return out
+}
+
+// formatSize 以 KB/MB/GB 显示组件大小
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	default:
+		return fmt.Sprintf("%d KB", (n+1023)/1024)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package main
+
+// applyComponents 组件快捷方式与注册表值仅在 Windows 上生效
+func applyComponents(specs []componentSpec, hc hookContext, rec *installRecord) error { return nil }
+
+// removeStaleEntries 仅 Windows 有快捷方式与注册表需要清理
+func removeStaleEntries(prev, rec *installRecord) {}
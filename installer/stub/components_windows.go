@@ -0,0 +1,115 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// applyComponents 创建已选组件的快捷方式并写入注册表值，结果追加到安装记录
+func applyComponents(specs []componentSpec, hc hookContext, rec *installRecord) error {
+	var errs []string
+	for _, s := range specs {
+		for _, sc := range s.Shortcuts {
+			target := filepath.Join(hc.InstallDir, filepath.FromSlash(sc.Target))
+			name := sanitizeFilename(sc.Name) + ".lnk"
+			var links []string
+			if sc.Desktop {
+				if p, err := desktopDir(); err == nil {
+					links = append(links, filepath.Join(p, name))
+				}
+			}
+			if sc.StartMenu {
+				if p, err := startMenuDir(sanitizeFilename(rec.ShortcutName)); err == nil {
+					links = append(links, filepath.Join(p, name))
+				}
+			}
+			for _, link := range links {
+				if err := createShortcut(link, target, hc.InstallDir, ""); err != nil {
+					errs = append(errs, s.Name+": "+err.Error())
+					continue
+				}
+				rec.Shortcuts = append(rec.Shortcuts, link)
+			}
+		}
+		for _, r := range s.Registry {
+			r.Value = hc.expand(r.Value)
+			if err := setRegistryValue(r); err != nil {
+				errs = append(errs, s.Name+": "+r.Key+": "+err.Error())
+				continue
+			}
+			rec.Registry = append(rec.Registry, r)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func setRegistryValue(r registryValue) error {
+	k, _, err := registry.CreateKey(registry.CURRENT_USER, r.Key, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+	switch r.Type {
+	case "dword":
+		v, err := strconv.ParseUint(r.Value, 0, 32)
+		if err != nil {
+			return err
+		}
+		return k.SetDWordValue(r.Name, uint32(v))
+	case "expand":
+		return k.SetExpandStringValue(r.Name, r.Value)
+	default:
+		return k.SetStringValue(r.Name, r.Value)
+	}
+}
+
+// deleteRegistryValues 删除记录的注册表值；键中不再有任何值与子键时一并删除
+func deleteRegistryValues(values []registryValue) {
+	for _, r := range values {
+		k, err := registry.OpenKey(registry.CURRENT_USER, r.Key, registry.SET_VALUE|registry.QUERY_VALUE)
+		if err != nil {
+			continue
+		}
+		_ = k.DeleteValue(r.Name)
+		info, err := k.Stat()
+		k.Close()
+		if err == nil && info.SubKeyCount == 0 && info.ValueCount == 0 {
+			_ = registry.DeleteKey(registry.CURRENT_USER, r.Key)
+		}
+	}
+}
+
+// removeStaleEntries 升级或更改组件后，删除上次安装创建但本次不再创建的快捷方式与注册表值
+func removeStaleEntries(prev, rec *installRecord) {
+	links := map[string]bool{}
+	for _, l := range rec.Shortcuts {
+		links[strings.ToLower(l)] = true
+	}
+	for _, l := range prev.Shortcuts {
+		if !links[strings.ToLower(l)] {
+			_ = os.Remove(l)
+		}
+	}
+
+	values := map[registryValue]bool{}
+	for _, r := range rec.Registry {
+		values[registryValue{Key: strings.ToLower(r.Key), Name: strings.ToLower(r.Name)}] = true
+	}
+	var stale []registryValue
+	for _, r := range prev.Registry {
+		if !values[registryValue{Key: strings.ToLower(r.Key), Name: strings.ToLower(r.Name)}] {
+			stale = append(stale, r)
+		}
+	}
+	deleteRegistryValues(stale)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// entryPointSpec 与 meta.json 中 entryPoints 的元素对应
+type entryPointSpec struct {
+	Name    string `json:"name"`
+	Windows string `json:"windows"`
+	Linux   string `json:"linux"`
+	Main    bool   `json:"main"`
+}
+
+// path 返回当前平台上相对安装目录的文件（以 / 分隔，与归档中的路径一致）
+func (e entryPointSpec) path() string {
+	p := e.Linux
+	if runtime.GOOS == "windows" {
+		p = e.Windows
+	}
+	return strings.ReplaceAll(p, `\`, "/")
+}
+
+// entryPoints 返回当前平台的入口：名称 -> 相对安装目录的文件。
+// 旧版本打包的 setup 没有 entryPoints，以 ExeName 作为唯一的主程序。
+func entryPoints(m InstallMeta) map[string]string {
+	out := map[string]string{}
+	for _, e := range m.EntryPoints {
+		if p := e.path(); p != "" {
+			out[e.Name] = p
+		}
+	}
+	if len(m.EntryPoints) == 0 && m.ExeName != "" {
+		out["main"] = m.ExeName
+	}
+	return out
+}
+
+// mainEntry 返回当前平台主程序相对安装目录的文件，未定义时为空
+func mainEntry(m InstallMeta) string {
+	for _, e := range m.EntryPoints {
+		if e.Main {
+			return e.path()
+		}
+	}
+	if len(m.EntryPoints) == 0 {
+		return m.ExeName
+	}
+	return ""
+}
+
+// entryPointPath 返回入口在安装目录中的绝对路径，name 为空时为主程序
+func entryPointPath(m InstallMeta, name, installDir string) (string, error) {
+	rel := mainEntry(m)
+	if name != "" {
+		rel = entryPoints(m)[name]
+	}
+	if rel == "" {
+		return "", errors.New(T("launch.no_entry", name))
+	}
+	return filepath.Join(installDir, filepath.FromSlash(rel)), nil
+}
+
+// checkEntryPoints 在写入任何文件之前确认主程序与各入口都在待安装的文件中，不做任何猜测。
+// 属于未选组件的入口不会安装，不做检查；主程序总是需要。
+func checkEntryPoints(m InstallMeta, files []*inMemoryFile, selected []string) error {
+	main := mainEntry(m)
+	if main == "" {
+		return errors.New(T("install.no_main_entry"))
+	}
+	skipped := unselectedFiles(m.Components, selected)
+	for name, p := range entryPoints(m) {
+		if p != main && skipped[p] {
+			continue
+		}
+		if findFile(files, p) == nil {
+			return errors.New(T("install.entry_missing", name, p))
+		}
+	}
+	return nil
+}
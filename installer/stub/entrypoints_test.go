@@ -0,0 +1,48 @@
+package main
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestCheckEntryPointsOptionalComponent(t *testing.T) {
+	m := InstallMeta{
+		EntryPoints: []entryPointSpec{
+			{Name: "main", Windows: "app.exe", Linux: "app", Main: true},
+			{Name: "cli", Windows: `bin\cli.exe`, Linux: "bin/cli"},
+		},
+		Components: []componentSpec{{Name: "tools", Files: []string{"bin/cli", "bin/cli.exe"}}},
+	}
+	all := []*inMemoryFile{{Name: "app"}, {Name: "app.exe"}, {Name: "bin/cli"}, {Name: "bin/cli.exe"}}
+
+	tests := []struct {
+		name     string
+		selected []string
+		wantErr  bool
+	}{
+		{"selected", []string{"tools"}, false},
+		{"not selected", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := componentFiles(all, m.Components, tt.selected)
+			if err := checkEntryPoints(m, files, tt.selected); (err != nil) != tt.wantErr {
+				t.Fatalf("checkEntryPoints = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// 已选组件中缺少入口文件时仍然报错
+	if err := checkEntryPoints(m, []*inMemoryFile{{Name: "app"}, {Name: "app.exe"}}, []string{"tools"}); err == nil {
+		t.Error("missing cli in selected component: want error")
+	}
+	// 主程序总是需要
+	main := "app"
+	if runtime.GOOS == "windows" {
+		main = "app.exe"
+	}
+	m.Components = append(m.Components, componentSpec{Name: "core", Files: []string{main}})
+	if err := checkEntryPoints(m, nil, nil); err == nil {
+		t.Error("missing main program: want error")
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// 环境变量的修改方式，对应 meta 中 environment 的 action
+const (
+	envSet     = "set"     // 设置为 Value
+	envAppend  = "append"  // 将 Value 作为列表项加到末尾（PATH 语义）
+	envPrepend = "prepend" // 将 Value 作为列表项加到开头
+)
+
+// envSpec 与 meta.json 中 environment 的元素对应
+type envSpec struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	Action  string `json:"action"`
+	Machine bool   `json:"machine"`
+}
+
+// envChange 已应用的一项修改，记录在安装记录中，卸载时据此精确撤销
+type envChange struct {
+	Name     string  `json:"name"`
+	Action   string  `json:"action"`
+	Machine  bool    `json:"machine,omitempty"`
+	Value    string  `json:"value"`              // 设置的值或加入的列表项（已替换占位符）
+	Previous *string `json:"previous,omitempty"` // set 覆盖前的值，nil 表示原先不存在
+	Added    bool    `json:"added,omitempty"`    // append/prepend：该项由本次安装加入，撤销时只移除它
+	File     string  `json:"file,omitempty"`     // 非 Windows：写入的片段文件
+}
+
+// environmentStore 持久化环境变量修改：Windows 为注册表 Environment 键（并广播 WM_SETTINGCHANGE），
+// 其他平台为产品独占的 profile.d / environment.d 片段。便于替换为假实现进行测试。
+type environmentStore interface {
+	// Apply 应用 changes 并返回补全了撤销信息的记录；prev 为上次安装的记录，
+	// 其中本次不再包含的修改会被撤销
+	Apply(product string, changes, prev []envChange) ([]envChange, error)
+	// Revert 撤销 changes
+	Revert(product string, changes []envChange) error
+}
+
+// envStore 当前使用的实现，默认为操作系统实现
+var envStore environmentStore = osEnvironmentStore{}
+
+type osEnvironmentStore struct{}
+
+// planEnvironment 替换占位符，生成待应用的修改
+func planEnvironment(specs []envSpec, hc hookContext) []envChange {
+	var out []envChange
+	for _, s := range specs {
+		action := s.Action
+		if action == "" {
+			action = envSet
+		}
+		v := hc.expand(s.Value)
+		if action != envSet {
+			v = filepath.Clean(filepath.FromSlash(v))
+		}
+		out = append(out, envChange{Name: s.Name, Action: action, Machine: s.Machine, Value: v})
+	}
+	return out
+}
+
+// applyEnvironment 应用 meta 中的环境变量并写入安装记录
+func applyEnvironment(m InstallMeta, hc hookContext, rec, prev *installRecord) error {
+	var prevChanges []envChange
+	if prev != nil {
+		prevChanges = prev.Environment
+	}
+	changes := planEnvironment(m.Environment, hc)
+	if len(changes) == 0 && len(prevChanges) == 0 {
+		return nil
+	}
+	applied, err := envStore.Apply(m.ProductName, changes, prevChanges)
+	rec.Environment = applied
+	for _, c := range applied {
+		logT("env.applied", c.Action, c.Name, c.Value)
+	}
+	return err
+}
+
+// revertEnvironment 卸载时撤销安装记录中的环境变量修改
+func revertEnvironment(rec *installRecord) {
+	if len(rec.Environment) == 0 {
+		return
+	}
+	if err := envStore.Revert(rec.ProductName, rec.Environment); err != nil {
+		warnT("uninstall.env_failed", err)
+		return
+	}
+	logT("env.reverted", len(rec.Environment))
+}
+
+// sameChange 判断两项修改是否为同一修改：set 只看变量（升级时值可能改变，原值须沿用旧记录），
+// append/prepend 还需是同一列表项
+func sameChange(a, b envChange) bool {
+	if !envNameEqual(a.Name, b.Name) || a.Action != b.Action || a.Machine != b.Machine {
+		return false
+	}
+	return a.Action == envSet || sameListItem(a.Value, b.Value)
+}
+
+// findChange 在 list 中查找与 c 相同的修改
+func findChange(list []envChange, c envChange) *envChange {
+	for i := range list {
+		if sameChange(list[i], c) {
+			return &list[i]
+		}
+	}
+	return nil
+}
+
+// staleChanges 返回 prev 中本次不再包含的修改
+func staleChanges(prev, changes []envChange) []envChange {
+	var out []envChange
+	for _, p := range prev {
+		if findChange(changes, p) == nil {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// envListAdd 将 item 加入以系统路径分隔符分隔的列表，已存在（忽略大小写与末尾分隔符，仅 Windows 忽略大小写）时不重复添加
+func envListAdd(list, item string, prepend bool) (string, bool) {
+	sep := string(os.PathListSeparator)
+	var parts []string
+	for _, p := range strings.Split(list, sep) {
+		if p == "" {
+			continue
+		}
+		if sameListItem(p, item) {
+			return list, false
+		}
+		parts = append(parts, p)
+	}
+	if prepend {
+		parts = append([]string{item}, parts...)
+	} else {
+		parts = append(parts, item)
+	}
+	return strings.Join(parts, sep), true
+}
+
+// envListRemove 从列表中移除 item，其余项保持原样与原顺序
+func envListRemove(list, item string) string {
+	sep := string(os.PathListSeparator)
+	var parts []string
+	for _, p := range strings.Split(list, sep) {
+		if p != "" && !sameListItem(p, item) {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, sep)
+}
+
+func sameListItem(a, b string) bool {
+	a = strings.TrimRight(filepath.Clean(a), `\/`)
+	b = strings.TrimRight(filepath.Clean(b), `\/`)
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// envNameEqual Windows 的环境变量名不区分大小写
+func envNameEqual(a, b string) bool {
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
@@ -0,0 +1,147 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 没有可读写的全局环境变量存储，改为写入产品独占的片段文件，卸载时整体删除即可精确撤销：
+// 系统范围为 /etc/profile.d/<产品>.sh（登录 shell 读取），
+// 用户范围为 ~/.config/environment.d/60-<产品>.conf（systemd 用户会话与桌面会话读取）。
+
+func envSnippetPath(product string, machine bool) (string, error) {
+	name := strings.ToLower(sanitizeLogName(product))
+	if machine {
+		return filepath.Join("/etc/profile.d", name+".sh"), nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "environment.d", "60-"+name+".conf"), nil
+}
+
+func (osEnvironmentStore) Apply(product string, changes, prev []envChange) ([]envChange, error) {
+	var errs []string
+	var applied []envChange
+	written := map[string]bool{}
+	for _, machine := range []bool{true, false} {
+		var scoped []envChange
+		for _, c := range changes {
+			if c.Machine == machine {
+				scoped = append(scoped, c)
+			}
+		}
+		if len(scoped) == 0 {
+			continue
+		}
+		path, err := envSnippetPath(product, machine)
+		if err == nil {
+			err = writeEnvSnippet(path, product, scoped, machine)
+		}
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		written[path] = true
+		for _, c := range scoped {
+			c.File = path
+			c.Added = c.Action != envSet
+			applied = append(applied, c)
+		}
+	}
+	// 上次安装写入、本次不再需要的片段
+	var stale []envChange
+	for _, p := range prev {
+		if !written[p.File] {
+			stale = append(stale, p)
+		}
+	}
+	if err := removeEnvSnippets(stale); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return applied, errors.New(strings.Join(errs, "; "))
+	}
+	return applied, nil
+}
+
+func (osEnvironmentStore) Revert(product string, changes []envChange) error {
+	return removeEnvSnippets(changes)
+}
+
+func removeEnvSnippets(changes []envChange) error {
+	var errs []string
+	seen := map[string]bool{}
+	for _, c := range changes {
+		if c.File == "" || seen[c.File] {
+			continue
+		}
+		seen[c.File] = true
+		err := os.Remove(c.File)
+		if os.IsNotExist(err) {
+			continue
+		}
+		logFileOp("remove", c.File, err)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func writeEnvSnippet(path, product string, changes []envChange, machine bool) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s: generated by setup, removed on uninstall\n", product)
+	for _, c := range changes {
+		if machine {
+			b.WriteString(shellEnvLine(c))
+		} else {
+			b.WriteString(envdLine(c))
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	err := os.WriteFile(path, []byte(b.String()), 0o644)
+	logFileOp("write", path, err)
+	return err
+}
+
+// shellEnvLine 生成 POSIX shell 语句；列表项已存在时不重复添加
+func shellEnvLine(c envChange) string {
+	v := shellQuote(c.Value)
+	switch c.Action {
+	case envAppend:
+		return fmt.Sprintf("case \":${%[1]s}:\" in *:%[2]s:*) ;; *) %[1]s=\"${%[1]s:+${%[1]s}:}\"%[2]s ;; esac; export %[1]s\n", c.Name, v)
+	case envPrepend:
+		return fmt.Sprintf("case \":${%[1]s}:\" in *:%[2]s:*) ;; *) %[1]s=%[2]s\"${%[1]s:+:${%[1]s}}\" ;; esac; export %[1]s\n", c.Name, v)
+	default:
+		return fmt.Sprintf("export %s=%s\n", c.Name, v)
+	}
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// envdLine 生成 environment.d 格式（见 environment.d(5)）的赋值
+func envdLine(c envChange) string {
+	v := strings.NewReplacer(`\`, `\\`, `$`, `\$`).Replace(c.Value)
+	switch c.Action {
+	case envAppend:
+		return fmt.Sprintf("%[1]s=${%[1]s:+${%[1]s}:}%[2]s\n", c.Name, v)
+	case envPrepend:
+		return fmt.Sprintf("%[1]s=%[2]s${%[1]s:+:${%[1]s}}\n", c.Name, v)
+	default:
+		return fmt.Sprintf("%s=%s\n", c.Name, v)
+	}
+}
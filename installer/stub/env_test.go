@@ -0,0 +1,38 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindChangeSetIgnoresValue(t *testing.T) {
+	user := "original"
+	prev := []envChange{{Name: "FOO", Action: envSet, Value: "a", Previous: &user}}
+	c := envChange{Name: "FOO", Action: envSet, Value: "b"}
+	old := findChange(prev, c)
+	if old == nil || old.Previous == nil || *old.Previous != "original" {
+		t.Fatalf("findChange = %+v, want the v1 entry with the user's original value", old)
+	}
+	if stale := staleChanges(prev, []envChange{c}); len(stale) != 0 {
+		t.Errorf("staleChanges = %v, want none: the variable is still set by this product", stale)
+	}
+}
+
+func TestStaleChanges(t *testing.T) {
+	bin1, bin2 := filepath.FromSlash("/opt/app/bin"), filepath.FromSlash("/opt/app/tools")
+	prev := []envChange{
+		{Name: "FOO", Action: envSet, Value: "a"},
+		{Name: "FOO", Action: envSet, Value: "a", Machine: true},
+		{Name: "PATH", Action: envAppend, Value: bin1, Added: true},
+		{Name: "PATH", Action: envAppend, Value: bin2, Added: true},
+	}
+	changes := []envChange{
+		{Name: "FOO", Action: envSet, Value: "b"},
+		{Name: "PATH", Action: envAppend, Value: bin1 + string(filepath.Separator)},
+	}
+	want := []envChange{prev[1], prev[3]}
+	if got := staleChanges(prev, changes); !reflect.DeepEqual(got, want) {
+		t.Errorf("staleChanges = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,157 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+const machineEnvKey = `SYSTEM\CurrentControlSet\Control\Session Manager\Environment`
+
+var procSendMessageTimeoutW = user32.NewProc("SendMessageTimeoutW")
+
+func envKey(machine bool) (registry.Key, error) {
+	if machine {
+		k, _, err := registry.CreateKey(registry.LOCAL_MACHINE, machineEnvKey, registry.QUERY_VALUE|registry.SET_VALUE)
+		return k, err
+	}
+	k, _, err := registry.CreateKey(registry.CURRENT_USER, "Environment", registry.QUERY_VALUE|registry.SET_VALUE)
+	return k, err
+}
+
+// readEnv 读取未展开的原始值与类型
+func readEnv(k registry.Key, name string) (string, uint32, bool) {
+	v, typ, err := k.GetStringValue(name)
+	if err != nil {
+		return "", 0, false
+	}
+	return v, typ, true
+}
+
+// writeEnv 保留原有的值类型；新变量含 % 时写为 REG_EXPAND_SZ
+func writeEnv(k registry.Key, name, value string, typ uint32) error {
+	if typ == registry.EXPAND_SZ || (typ == 0 && strings.Contains(value, "%")) {
+		return k.SetExpandStringValue(name, value)
+	}
+	return k.SetStringValue(name, value)
+}
+
+func (osEnvironmentStore) Apply(product string, changes, prev []envChange) ([]envChange, error) {
+	var errs []string
+	var applied []envChange
+	for _, c := range changes {
+		if err := applyEnvChange(&c, findChange(prev, c)); err != nil {
+			errs = append(errs, c.Name+": "+err.Error())
+			continue
+		}
+		applied = append(applied, c)
+	}
+	if err := revertEnvChanges(staleChanges(prev, changes)); err != nil {
+		errs = append(errs, err.Error())
+	}
+	broadcastEnvChange()
+	if len(errs) > 0 {
+		return applied, errors.New(strings.Join(errs, "; "))
+	}
+	return applied, nil
+}
+
+// applyEnvChange 应用一项修改并补全撤销信息；old 为上次安装的同一修改，升级时沿用其原值
+func applyEnvChange(c *envChange, old *envChange) error {
+	k, err := envKey(c.Machine)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+	cur, typ, exists := readEnv(k, c.Name)
+	switch c.Action {
+	case envSet:
+		if old != nil {
+			c.Previous = old.Previous
+		} else if exists {
+			c.Previous = &cur
+		}
+		return writeEnv(k, c.Name, c.Value, typ)
+	default:
+		list, added := envListAdd(cur, c.Value, c.Action == envPrepend)
+		c.Added = added || (old != nil && old.Added)
+		if !added {
+			return nil
+		}
+		return writeEnv(k, c.Name, list, typ)
+	}
+}
+
+func (osEnvironmentStore) Revert(product string, changes []envChange) error {
+	err := revertEnvChanges(changes)
+	broadcastEnvChange()
+	return err
+}
+
+// revertEnvChanges 逆序撤销：set 仅在值仍为安装时写入的值时恢复原值，
+// append/prepend 仅移除本次加入的列表项，不影响其他程序或用户后来的修改
+func revertEnvChanges(changes []envChange) error {
+	var errs []string
+	for i := len(changes) - 1; i >= 0; i-- {
+		c := changes[i]
+		if err := revertEnvChange(c); err != nil {
+			errs = append(errs, c.Name+": "+err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func revertEnvChange(c envChange) error {
+	k, err := envKey(c.Machine)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+	cur, typ, exists := readEnv(k, c.Name)
+	if !exists {
+		return nil
+	}
+	switch c.Action {
+	case envSet:
+		if cur != c.Value {
+			return nil
+		}
+		if c.Previous != nil {
+			return writeEnv(k, c.Name, *c.Previous, typ)
+		}
+		return k.DeleteValue(c.Name)
+	default:
+		if !c.Added {
+			return nil
+		}
+		list := envListRemove(cur, c.Value)
+		if list == cur {
+			return nil
+		}
+		if list == "" && !strings.EqualFold(c.Name, "PATH") {
+			return k.DeleteValue(c.Name)
+		}
+		return writeEnv(k, c.Name, list, typ)
+	}
+}
+
+// broadcastEnvChange 通知资源管理器等顶层窗口重新加载环境变量，新启动的程序即可看到修改
+func broadcastEnvChange() {
+	const (
+		hwndBroadcast   = 0xffff
+		wmSettingChange = 0x001A
+		smtoAbortIfHung = 0x0002
+	)
+	env, _ := windows.UTF16PtrFromString("Environment")
+	var result uintptr
+	procSendMessageTimeoutW.Call(hwndBroadcast, wmSettingChange, 0, uintptr(unsafe.Pointer(env)),
+		smtoAbortIfHung, 5000, uintptr(unsafe.Pointer(&result)))
+}
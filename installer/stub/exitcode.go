@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"runtime"
+)
+
+// 进程退出码，沿用 Windows / MSI 的错误码以便部署工具识别
+const (
+	exitSuccess        = 0
+	exitAccessDenied   = 5    // ERROR_ACCESS_DENIED：安装目录不可写
+	exitDiskFull       = 112  // ERROR_DISK_FULL：磁盘空间不足
+	exitArchMismatch   = 216  // ERROR_EXE_MACHINE_TYPE_MISMATCH：CPU 架构不受支持
+	exitOldOSVersion   = 1150 // ERROR_OLD_WIN_VERSION：系统版本过低
+	exitCancelled      = 1602 // ERROR_INSTALL_USEREXIT：用户取消
+	exitProductVersion = 1638 // ERROR_PRODUCT_VERSION：已安装的版本不允许此次安装
+	exitFatal          = 1603 // ERROR_INSTALL_FAILURE：其他安装错误
+	exitAlreadyRunning = 1618 // ERROR_INSTALL_ALREADY_RUNNING：同一产品的另一个安装程序正在运行
+	exitRebootRequired = 3010 // ERROR_SUCCESS_REBOOT_REQUIRED：成功，但需要重启
+)
+
+// unixExitCodes 非 Windows 平台的退出码只有 8 位，改用不会截断冲突的小值（取相近的 errno）
+var unixExitCodes = map[int]int{
+	exitAccessDenied:   13, // EACCES
+	exitDiskFull:       28, // ENOSPC
+	exitArchMismatch:   8,  // ENOEXEC
+	exitOldOSVersion:   95, // EOPNOTSUPP
+	exitCancelled:      2,
+	exitProductVersion: 17, // EEXIST
+	exitAlreadyRunning: 16, // EBUSY
+	exitFatal:          1,
+	exitRebootRequired: 3,
+}
+
+// processExitCode 返回传给 os.Exit 的值
+func processExitCode(code int) int {
+	if runtime.GOOS == "windows" || code == exitSuccess {
+		return code
+	}
+	if c, ok := unixExitCodes[code]; ok {
+		return c
+	}
+	return 1
+}
+
+// exitError 携带退出码的错误
+type exitError struct {
+	Code int
+	Err  error
+}
+
+func (e *exitError) Error() string { return e.Err.Error() }
+
+func (e *exitError) Unwrap() error { return e.Err }
+
+func withExitCode(code int, err error) error { return &exitError{Code: code, Err: err} }
+
+// exitCodeOf 返回错误对应的退出码；未指定时为 exitFatal
+func exitCodeOf(err error) int {
+	if err == nil {
+		return exitSuccess
+	}
+	var ee *exitError
+	if errors.As(err, &ee) {
+		return ee.Code
+	}
+	return exitFatal
+}
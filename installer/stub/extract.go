@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// safeJoin 将归档内路径拼接到 base 下，拒绝绝对路径、盘符与 ".." 逃逸
+func safeJoin(base, name string) (string, error) {
+	clean := path.Clean(strings.ReplaceAll(name, `\`, "/"))
+	if name == "" || clean == "." || path.IsAbs(clean) || filepath.IsAbs(name) || filepath.VolumeName(name) != "" ||
+		clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", errors.New(T("extract.unsafe_path", name))
+	}
+	return filepath.Join(base, filepath.FromSlash(clean)), nil
+}
+
+// payloadFiles 返回实际会写入目标目录的文件：去掉前置组件安装程序与未选组件
+func payloadFiles(files []*inMemoryFile) []*inMemoryFile {
+	_, files = splitPrereqFiles(files)
+	return componentFiles(files, meta.Components, defaultComponents())
+}
+
+// defaultComponents 返回非交互模式下（/EXTRACT、便携模式）选择的组件：默认选择或 /COMPONENTS= 指定
+func defaultComponents() []string {
+	return selectedComponentNames(initialComponents(meta.Components, nil))
+}
+
+// runExtract 对应 /EXTRACT=<目录>：只把载荷解压到目录中，不清理目录、不运行钩子与前置组件、
+// 不创建快捷方式、注册表项与卸载程序。组件按默认选择，可用 /COMPONENTS= 指定。
+func runExtract(dir string) int {
+	files, err := loadPayload()
+	if err != nil {
+		reportError(err.Error())
+		return exitFatal
+	}
+	_ = runLog.Open(meta.ProductName + "-extract")
+	if files, err = selectArchFiles(files); err != nil {
+		reportError(err.Error())
+		runLog.Close(err)
+		return exitCodeOf(err)
+	}
+	if dir == "" {
+		err := errors.New(T("extract.no_dir"))
+		reportError(err.Error())
+		runLog.Close(err)
+		return exitFatal
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	files = payloadFiles(files)
+
+	err = checkTarget(dir, filesSize(files))
+	if err == nil {
+		err = os.MkdirAll(dir, 0o755)
+	}
+	if err == nil {
+		err = writeFilesWithLog(files, dir, nil)
+	}
+	if err != nil {
+		reportError(T("extract.failed", err))
+		runLog.Close(err)
+		return exitCodeOf(err)
+	}
+	runLog.Close(nil)
+	ui.Info(T("extract.done", len(files), dir))
+	return exitSuccess
+}
+
+// portableSwitches 便携版自身使用的开关，其余参数原样传给主程序
+var portableSwitches = []string{"LOG", "LANG", "ARCH"}
+
+// runPortable 便携版：解压到临时目录运行主程序，等待其退出后删除临时目录，
+// 并以主程序的退出码退出（不经过 processExitCode 映射，因此直接调用 os.Exit）
+func runPortable(files []*inMemoryFile) {
+	if hasSwitch("LOG") {
+		_ = runLog.Open(meta.ProductName + "-portable")
+	}
+	code, err := runPortableApp(files)
+	if err != nil {
+		reportError(T("portable.failed", err))
+		runLog.Close(err)
+		os.Exit(processExitCode(exitCodeOf(err)))
+	}
+	runLog.Close(nil)
+	os.Exit(code)
+}
+
+func runPortableApp(files []*inMemoryFile) (int, error) {
+	files, err := selectArchFiles(files)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkPlatform(meta); err != nil {
+		return 0, err
+	}
+	dir, err := os.MkdirTemp("", sanitizeLogName(meta.ProductName)+"-")
+	if err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(dir)
+
+	for _, f := range payloadFiles(files) {
+		if strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		dest, err := safeJoin(dir, f.Name)
+		if err != nil {
+			return 0, err
+		}
+		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+			return 0, err
+		}
+		mode := os.FileMode(f.Mode)
+		if mode == 0 {
+			mode = 0o644
+		}
+		if err := os.WriteFile(dest, f.Data, mode); err != nil {
+			return 0, err
+		}
+		logFileOp("write", dest, nil)
+	}
+
+	if err := checkEntryPoints(meta, files, defaultComponents()); err != nil {
+		return 0, err
+	}
+	cmd := exec.Command(filepath.Join(dir, filepath.FromSlash(mainEntry(meta))), portableArgs(os.Args[1:])...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = append(os.Environ(), "PORTABLE_DIR="+dir)
+	if self, err := os.Executable(); err == nil {
+		cmd.Env = append(cmd.Env, "PORTABLE_LAUNCHER="+self)
+	}
+	err = cmd.Run()
+	var ee *exec.ExitError
+	if errors.As(err, &ee) {
+		return ee.ExitCode(), nil
+	}
+	return 0, err
+}
+
+// portableArgs 去掉便携版自身使用的开关
+func portableArgs(args []string) []string {
+	var out []string
+	for _, a := range args {
+		own := false
+		for k := range parseSwitches([]string{a}) {
+			own = slices.Contains(portableSwitches, k)
+		}
+		if !own {
+			out = append(out, a)
+		}
+	}
+	return out
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 钩子执行阶段
+const (
+	hookPreInstall    = "pre-install"    // 清理旧文件之前（可用于停止服务）
+	hookPostInstall   = "post-install"   // 文件写入之后、创建快捷方式与注册信息之前
+	hookPreUninstall  = "pre-uninstall"  // 删除任何内容之前
+	hookPostUninstall = "post-uninstall" // 文件删除之后；安装目录内的命令会先复制到临时目录
+)
+
+// 钩子失败（非零退出码、超时、无法启动）时的处理策略
+const (
+	hookFailIgnore   = "ignore"   // 记录后继续
+	hookFailAbort    = "abort"    // 停止后续步骤，不撤销已完成的操作；post-install 阶段同 rollback
+	hookFailRollback = "rollback" // 停止并恢复安装前的文件
+)
+
+const defaultHookTimeout = 5 * time.Minute
+
+// outputWaitDelay 超时终止进程后等待其输出管道关闭的时间。进程启动的子进程可能继承并一直占用
+// 输出管道，不设上限时 Wait 会阻塞到子进程退出，超时设置就不起作用。
+const outputWaitDelay = 3 * time.Second
+
+// hookSpec 与打包时 meta.json 中的 hooks 对应
+type hookSpec struct {
+	Stage          string            `json:"stage"`
+	Command        string            `json:"command"`           // 绝对路径、相对安装目录的路径或 PATH 中的命令
+	Bundled        bool              `json:"bundled,omitempty"` // 为 true 时 Command 指向归档中的 hooks/ 文件
+	Args           []string          `json:"args,omitempty"`
+	Env            map[string]string `json:"env,omitempty"`
+	WorkingDir     string            `json:"workingDir,omitempty"`
+	TimeoutSeconds int               `json:"timeoutSeconds,omitempty"`
+	OnFailure      string            `json:"onFailure,omitempty"`
+}
+
+// hookError 表示需要中止流程的钩子失败
+type hookError struct {
+	Hook hookSpec
+	Err  error
+}
+
+func (e *hookError) Error() string {
+	return T("hook.failed", e.Hook.Stage, e.Hook.Command, e.Err)
+}
+
+func (e *hookError) Unwrap() error { return e.Err }
+
+// hookContext 执行钩子时的环境
+type hookContext struct {
+	InstallDir  string
+	BaseDir     string // 解析相对命令路径的目录；为空时使用 InstallDir
+	ProductName string
+	Version     string
+}
+
+// runHooks 依次执行指定阶段的钩子，输出写入安装日志。
+// 策略为 ignore 的失败仅记录；其余失败立即返回 *hookError。
+func runHooks(hooks []hookSpec, stage string, hc hookContext) error {
+	for _, h := range hooks {
+		if h.Stage != stage {
+			continue
+		}
+		logT("hook.running", stage, h.Command, strings.Join(h.Args, " "))
+		err := runHook(h, hc)
+		if err == nil {
+			continue
+		}
+		if h.OnFailure == hookFailIgnore {
+			warnT("hook.failed_ignored", err)
+			continue
+		}
+		return &hookError{Hook: h, Err: err}
+	}
+	return nil
+}
+
+// expand 替换 {InstallDir}、{ProductName}、{Version}
+func (hc hookContext) expand(s string) string {
+	return strings.NewReplacer(
+		"{InstallDir}", hc.InstallDir,
+		"{ProductName}", hc.ProductName,
+		"{Version}", hc.Version,
+	).Replace(s)
+}
+
+func runHook(h hookSpec, hc hookContext) error {
+	base := hc.BaseDir
+	if base == "" {
+		base = hc.InstallDir
+	}
+	expand := hc.expand
+
+	path, err := resolveHookCommand(expand(h.Command), base)
+	if err != nil {
+		return err
+	}
+	args := make([]string, len(h.Args))
+	for i, a := range h.Args {
+		args[i] = expand(a)
+	}
+
+	timeout := defaultHookTimeout
+	if h.TimeoutSeconds > 0 {
+		timeout = time.Duration(h.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.WaitDelay = outputWaitDelay
+	cmd.Dir = hc.InstallDir
+	if h.WorkingDir != "" {
+		cmd.Dir = expand(h.WorkingDir)
+		if !filepath.IsAbs(cmd.Dir) {
+			cmd.Dir = filepath.Join(hc.InstallDir, cmd.Dir)
+		}
+	}
+	if _, err := os.Stat(cmd.Dir); err != nil {
+		cmd.Dir = "" // 例如卸载后的安装目录已不存在
+	}
+	cmd.Env = append(os.Environ(),
+		"INSTALLER_STAGE="+h.Stage,
+		"INSTALLER_INSTALL_DIR="+hc.InstallDir,
+		"INSTALLER_PRODUCT="+hc.ProductName,
+		"INSTALLER_VERSION="+hc.Version,
+	)
+	for k, v := range h.Env {
+		cmd.Env = append(cmd.Env, k+"="+expand(v))
+	}
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	start := time.Now()
+	err = cmd.Run()
+	for _, line := range strings.Split(strings.TrimRight(out.String(), "\r\n"), "\n") {
+		if line != "" {
+			logf("  | %s", strings.TrimRight(line, "\r"))
+		}
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return errors.New(T("hook.timeout", timeout))
+	}
+	if err != nil {
+		return err
+	}
+	logT("hook.done", time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// resolveHookCommand 相对路径优先在 base 中查找，找不到再从 PATH 查找
+func resolveHookCommand(command, base string) (string, error) {
+	if command == "" {
+		return "", fmt.Errorf("empty hook command")
+	}
+	if filepath.IsAbs(command) {
+		return command, nil
+	}
+	if p := filepath.Join(base, command); fileExists(p) {
+		return p, nil
+	}
+	return exec.LookPath(command)
+}
+
+// stageHookFiles 将钩子引用的、位于 srcDir 中的文件复制到临时目录，
+// 用于安装前（文件尚未写入）或卸载后（文件已删除）执行。返回的 cleanup 删除临时目录。
+func stageHookFiles(hooks []hookSpec, stage string, files []*inMemoryFile, srcDir string) (string, func(), error) {
+	if !hasHooks(hooks, stage) {
+		return "", func() {}, nil
+	}
+	tmp, err := os.MkdirTemp("", "hooks-")
+	if err != nil {
+		return "", func() {}, err
+	}
+	cleanup := func() { _ = os.RemoveAll(tmp) }
+	for _, h := range hooks {
+		if h.Stage != stage || filepath.IsAbs(h.Command) {
+			continue
+		}
+		dst := filepath.Join(tmp, h.Command)
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			cleanup()
+			return "", func() {}, err
+		}
+		if f := findFile(files, filepath.ToSlash(h.Command)); f != nil {
+			err = os.WriteFile(dst, f.Data, 0o755)
+		} else if src := filepath.Join(srcDir, h.Command); srcDir != "" && fileExists(src) {
+			err = copyFile(src, dst)
+		}
+		if err != nil {
+			cleanup()
+			return "", func() {}, err
+		}
+	}
+	return tmp, cleanup, nil
+}
+
+func hasHooks(hooks []hookSpec, stage string) bool {
+	for _, h := range hooks {
+		if h.Stage == stage {
+			return true
+		}
+	}
+	return false
+}
+
+func fileExists(p string) bool {
+	info, err := os.Stat(p)
+	return err == nil && !info.IsDir()
+}
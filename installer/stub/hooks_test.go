@@ -0,0 +1,49 @@
+package main
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestRunHookTimeoutWithChildHoldingOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses /bin/sh")
+	}
+	// 钩子启动的子进程继承输出管道并一直运行
+	h := hookSpec{Stage: hookPostInstall, Command: "/bin/sh", Args: []string{"-c", "sleep 60 & sleep 60"}, TimeoutSeconds: 1}
+	start := time.Now()
+	err := runHook(h, hookContext{InstallDir: t.TempDir()})
+	if err == nil {
+		t.Fatal("runHook: want timeout error")
+	}
+	if d := time.Since(start); d > time.Second+outputWaitDelay+2*time.Second {
+		t.Errorf("runHook returned after %s, timeout not enforced", d)
+	}
+}
+
+func TestPostInstallAbortRestoresPreviousVersion(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses /bin/sh")
+	}
+	dir := setupTestInstall(t)
+	newFakeServices(t)
+	installVersion(t, dir, InstallMeta{Version: "1.0.0"}, "v1")
+
+	failing := hookSpec{Stage: hookPostInstall, Command: "/bin/sh", Args: []string{"-c", "exit 1"}, OnFailure: hookFailAbort}
+	if err := tryInstallVersion(dir, InstallMeta{Version: "2.0.0", Hooks: []hookSpec{failing}}, "v2"); err == nil {
+		t.Fatal("install with a failing post-install hook succeeded")
+	}
+	// 旧版本的文件与安装记录都应保留，仍可运行和卸载
+	if got := readTestFile(t, filepath.Join(dir, "app")); got != "v1" {
+		t.Errorf("app = %q, want the restored v1", got)
+	}
+	rec, err := loadInstallRecord(dir)
+	if err != nil {
+		t.Fatalf("install record lost: %v", err)
+	}
+	if rec.Version != "1.0.0" {
+		t.Errorf("record version = %q, want 1.0.0", rec.Version)
+	}
+}
@@ -0,0 +1,561 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// 界面语言：内置英文与中文消息目录，打包时可通过 lang/<代码>.json 追加其他语言
+// （格式为 key -> 文本，缺失的 key 回退到英文）。
+
+// langDir 归档与安装目录中存放翻译文件的目录
+const langDir = "lang"
+
+var catalogs = map[string]map[string]string{
+	"en": messagesEN,
+	"zh": messagesZH,
+}
+
+// lang 当前使用的语言代码（小写，如 "zh"、"pt-br"）
+var lang = "en"
+
+// T 返回当前语言下 key 对应的文本；带参数时按 fmt 格式化
+func T(key string, args ...any) string {
+	msg, ok := catalogs[lang][key]
+	if !ok {
+		msg, ok = catalogs["en"][key]
+	}
+	if !ok {
+		msg = key
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// logT 输出一条已翻译的过程信息
+func logT(key string, args ...any) { logAt(levelInfo, T(key, args...)) }
+
+// normalizeLang 将 "zh_CN.UTF-8"、"zh-Hans-CN" 之类的标签规范为小写并以 "-" 分隔
+func normalizeLang(tag string) string {
+	tag = strings.TrimSpace(tag)
+	if i := strings.IndexAny(tag, ".@"); i >= 0 {
+		tag = tag[:i]
+	}
+	return strings.ToLower(strings.ReplaceAll(tag, "_", "-"))
+}
+
+// addTranslation 合并一份 JSON 翻译（key -> 文本）
+func addTranslation(code string, data []byte) error {
+	var m map[string]string
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("%s: %w", code, err)
+	}
+	code = normalizeLang(code)
+	if catalogs[code] == nil {
+		catalogs[code] = map[string]string{}
+	}
+	for k, v := range m {
+		catalogs[code][k] = v
+	}
+	return nil
+}
+
+// loadTranslations 载入归档中的 lang/*.json
+func loadTranslations(files []*inMemoryFile) {
+	for _, f := range files {
+		if path.Dir(f.Name) != langDir || path.Ext(f.Name) != ".json" {
+			continue
+		}
+		_ = addTranslation(strings.TrimSuffix(path.Base(f.Name), ".json"), f.Data)
+	}
+}
+
+// loadTranslationDir 载入安装目录中的 lang/*.json（供卸载程序使用）
+func loadTranslationDir(installDir string) {
+	matches, _ := filepath.Glob(filepath.Join(installDir, langDir, "*.json"))
+	for _, m := range matches {
+		if data, err := os.ReadFile(m); err == nil {
+			_ = addTranslation(strings.TrimSuffix(filepath.Base(m), ".json"), data)
+		}
+	}
+}
+
+// selectLanguage 依次尝试 /LANG= 开关、preferred（如安装记录中的语言）与系统界面语言，
+// 先精确匹配（zh-cn）再匹配主语言（zh），都没有时使用英文。
+func selectLanguage(preferred ...string) {
+	var candidates []string
+	if v, ok := switchValue("LANG"); ok {
+		candidates = append(candidates, v)
+	}
+	candidates = append(candidates, preferred...)
+	candidates = append(candidates, osLanguages()...)
+	for _, c := range candidates {
+		c = normalizeLang(c)
+		if c == "" || c == "c" || c == "posix" {
+			continue
+		}
+		if _, ok := catalogs[c]; ok {
+			lang = c
+			return
+		}
+		if i := strings.Index(c, "-"); i > 0 {
+			if _, ok := catalogs[c[:i]]; ok {
+				lang = c[:i]
+				return
+			}
+		}
+	}
+	lang = "en"
+}
+
+var messagesEN = map[string]string{
+	"common.cancel": "Cancel",
+
+	"hook.running":        "Running %s hook: %s %s",
+	"hook.failed":         "%s hook %s failed: %v",
+	"hook.failed_ignored": "Hook failed (ignored): %v",
+	"hook.timeout":        "timed out (%s)",
+	"hook.done":           "Hook finished in %s",
+
+	"install.extract_failed":        "Unable to read the embedded archive: %v",
+	"install.license_missing":       "The license agreement %s is missing from the setup package.",
+	"install.unpack_failed":         "Failed to unpack the archive: %v",
+	"install.product":               "Product: %s  Version: %s",
+	"install.cancelled":             "Installation cancelled.",
+	"install.launch_failed":         "Failed to start the application: %v",
+	"maintenance.choose":            "%s is installed. What do you want to do?",
+	"maintenance.modify":            "Change components",
+	"maintenance.repair":            "Repair",
+	"maintenance.not_installed":     "No installation found at %s",
+	"maintenance.source":            "Using setup files from %s",
+	"maintenance.source_invalid":    "Ignoring %s: %v",
+	"maintenance.source_mismatch":   "Ignoring %s: it contains %s %s",
+	"maintenance.no_source":         "The setup for %s %s is required. Run it again, or pass its path with /SOURCE=",
+	"install.cache_failed":          "Failed to cache setup files: %v",
+	"repair.no_digests":             "The installation record has no file checksums; only missing files can be detected.",
+	"repair.checked":                "Checked %d files, %d need repair",
+	"repair.missing":                "Missing: %s",
+	"repair.corrupted":              "Modified or corrupted: %s",
+	"repair.not_in_payload":         "%s is not in the setup files",
+	"repair.still_broken":           "Files still differ after repair: %s",
+	"repair.failed":                 "Repair failed: %v",
+	"repair.intact":                 "All files of %s are intact.",
+	"repair.done":                   "Restored %d file(s). %s has been repaired.",
+	"version.installed":             "Installed version: %s, this setup: %s",
+	"version.unparsable":            "Cannot compare versions %q and %q; continuing as an upgrade",
+	"version.downgrade":             "A newer version (%s) is already installed. This setup contains %s; uninstall the newer version first to downgrade.",
+	"version.uninstall_first":       "Version %s must be uninstalled before installing %s. Uninstall it from %s and run this setup again.",
+	"version.same_installed":        "%s %s is already installed.",
+	"version.same_ask":              "%s %s is already installed. What do you want to do?",
+	"version.reinstall":             "Reinstall",
+	"wizard.version_change":         "Installed version: %s\nNew version: %s",
+	"wizard.same_version":           "Version %s is already installed and will be reinstalled.",
+	"instance.running":              "Another setup or uninstall of %s is already running. Wait for it to finish and try again.",
+	"instance.waiting":              "Another setup of %s is running; waiting up to %d seconds...",
+	"instance.focused":              "Switched to the setup of %s that is already running",
+	"instance.lock_failed":          "Cannot create the setup lock: %v",
+	"install.env_failed":            "Failed to update environment variables (ignored): %v",
+	"uninstall.env_failed":          "Failed to revert environment variables (ignored): %v",
+	"env.applied":                   "Environment: %s %s = %s",
+	"env.reverted":                  "Reverted %d environment change(s)",
+	"install.services_failed":       "Failed to register services (ignored): %v",
+	"service.registered":            "Registered service %s",
+	"service.started":               "Started service %s",
+	"service.stopped":               "Stopped service %s",
+	"service.removed":               "Removed service %s",
+	"service.start_failed":          "Failed to start service %s (ignored): %v",
+	"service.stop_failed":           "Failed to stop service %s: %v",
+	"service.remove_failed":         "Failed to remove service %s (ignored): %v",
+	"install.autostart_failed":      "Failed to register automatic start (ignored): %v",
+	"autostart.registered":          "Registered automatic start (%s): %s",
+	"autostart.removed":             "Removed automatic start (%s): %s",
+	"autostart.remove_failed":       "Failed to remove automatic start %s (ignored): %v",
+	"launch.start":                  "Starting %s",
+	"launch.started":                "Application started (PID %d)",
+	"launch.as_user":                "Starting as the invoking user %s instead of root",
+	"launch.as_shell_user":          "Started as the signed-in user without administrator rights",
+	"launch.no_entry":               "Entry point %q to run after installation is not defined",
+	"launch.no_shell":               "No desktop shell is running to start the application as the signed-in user",
+	"install.mkdir_failed":          "Failed to create the install directory",
+	"install.target_dir":            "Install directory: %s",
+	"install.aborted":               "Installation aborted",
+	"install.still_running":         "Some applications are still running; files in use will be replaced after a restart.",
+	"install.clean_failed":          "Failed to clean the existing directory",
+	"install.clean_done":            "Directory cleaned, writing files...",
+	"install.write_failed":          "Failed to write files",
+	"install.write_done":            "All files written.",
+	"install.backup_cleanup_failed": "Failed to remove the backup of the previous version (ignored): %v",
+	"install.installed_to":          "Installed to: %s",
+	"install.no_main_entry":         "The setup does not declare a main program.",
+	"install.entry_missing":         "Entry point %s (%s) is not in the setup payload.",
+	"install.shortcuts_failed":      "Failed to create shortcuts (ignored): %v",
+	"install.shortcuts_done":        "Shortcuts created.",
+	"install.components_failed":     "Failed to set up component shortcuts or registry values (ignored): %v",
+	"install.uninstaller_failed":    "Failed to create the uninstaller (ignored): %v",
+	"install.record_failed":         "Failed to write the install record (ignored): %v",
+	"install.registry_failed":       "Failed to write registry entries (ignored): %v",
+	"install.registry_done":         "Registry entries written.",
+	"install.arch":                  "Selected %s payload for this %s system.",
+	"install.pending_reboot":        "%d file(s) are in use and will be replaced after you restart the computer.",
+	"install.reboot_required":       "Restart the computer to complete the installation.",
+	"install.restoring":             "Restoring the previous files...",
+	"install.restore_failed":        "Restore failed: %v (previous files kept in %s)",
+	"install.rollback_incomplete":   "Previous files restored, but some new files could not be removed: %v",
+	"install.mkdir_entry":           "[%d/%d] Created directory: %s",
+	"install.file_locked":           "[%d/%d] File in use, will be replaced after restart: %s",
+	"install.file_written":          "[%d/%d] Wrote file: %s (%d bytes)",
+
+	"progress.preparing":     "Preparing installation...",
+	"progress.prerequisites": "Installing prerequisites...",
+	"progress.pre_install":   "Running pre-install tasks...",
+	"progress.check_running": "Checking for running applications...",
+	"progress.clean":         "Removing files of the previous version...",
+	"progress.copy":          "Copying files...",
+	"progress.post_install":  "Running post-install tasks...",
+	"progress.shortcuts":     "Creating shortcuts...",
+	"progress.register":      "Registering the application...",
+	"progress.done":          "Installation complete",
+
+	"check.arch":         "This product is built for %s and cannot run on this %s computer.",
+	"check.os_version":   "This product requires operating system version %s or later (found %s).",
+	"check.not_writable": "The install directory %s is not writable: %v",
+	"check.disk_space":   "Not enough free disk space on %s: %s required, %s available.",
+
+	"installdir.previous":        "Using the previous install directory: %s",
+	"installdir.template_failed": "Could not resolve install directory %q (%v); using the default.",
+	"installdir.remember_failed": "Could not remember the install directory: %v",
+
+	"extract.done":        "Extracted %d file(s) to %s",
+	"extract.failed":      "Extraction failed: %v",
+	"extract.no_dir":      "/EXTRACT requires a target directory, e.g. /EXTRACT=C:\\temp\\app",
+	"extract.unsafe_path": "unsafe path in archive: %q",
+	"portable.failed":     "Could not start the application: %v",
+
+	"prereq.present":           "Prerequisite already installed: %s",
+	"prereq.installing":        "Installing prerequisite: %s",
+	"prereq.done":              "Prerequisite installed: %s",
+	"prereq.reboot":            "Prerequisite %s requires a restart to complete.",
+	"prereq.failed":            "Failed to install prerequisite %s: %v",
+	"prereq.failed_ignored":    "Optional prerequisite %s failed (ignored): %v",
+	"prereq.exit_code":         "exit code %d",
+	"prereq.missing_installer": "installer not found: %s",
+
+	"clean.not_dir":         "target path exists but is not a directory: %s",
+	"clean.protected":       "refusing to install into a system or user folder: %s",
+	"clean.other_product":   "%s contains an installation of %s, refusing to clean it",
+	"clean.confirm_unknown": "%s is not empty and was not created by this setup (%d item(s)):\n%s\nAll of its contents will be deleted.",
+	"clean.choice_delete":   "Delete the contents and install",
+	"clean.choice_cancel":   "Cancel",
+	"clean.cancelled":       "installation cancelled: %s is not empty",
+	"clean.confirmed":       "User confirmed deleting the contents of %s",
+	"clean.more":            "... and %d more",
+	"clean.dryrun_nothing":  "Dry run: %s does not exist or is empty; nothing would be deleted.",
+	"clean.dryrun_owned":    "Dry run: the following contents of %s (previous installation) would be deleted:\n%s",
+	"clean.dryrun_unknown":  "Dry run: %s was not created by this setup; after confirmation the following would be deleted:\n%s",
+	"clean.locked":          "File in use, left in place: %s",
+	"clean.move_failed":     "failed to move %s",
+
+	"procs.list_failed":      "Unable to enumerate processes (ignored): %v",
+	"procs.running":          "The following applications are running and must be closed before continuing:%s\n\nClose them and click \"Retry\", or choose \"Force close\". If you choose \"Ignore\", files in use will be replaced after a restart.",
+	"procs.retry":            "Retry",
+	"procs.force":            "Force close",
+	"procs.ignore":           "Ignore",
+	"procs.all_closed":       "All related applications have been closed.",
+	"procs.not_responding":   "Application [%d] is not responding, terminating it.",
+	"procs.terminate_failed": "Failed to terminate process [%d]: %v",
+
+	"shortcut.desktop_creating":   " - Creating desktop shortcut...",
+	"shortcut.desktop_failed":     "   × Desktop shortcut failed: %v",
+	"shortcut.desktop_done":       "   √ Desktop shortcut: %s",
+	"shortcut.startmenu_creating": " - Creating Start menu shortcut...",
+	"shortcut.startmenu_failed":   "   × Start menu shortcut failed: %v",
+	"shortcut.startmenu_done":     "   √ Start menu shortcut: %s",
+
+	"wizard.license_required": "You must accept the license agreement to continue.",
+	"wizard.dir_invalid":      "Please enter a valid install directory (absolute path).",
+	"wizard.dir_protected":    "This is a system or user folder. Choose a subfolder for the application instead.",
+
+	"console.press_enter":      "Press Enter to exit...",
+	"console.choose":           "Select [%d]: ",
+	"console.welcome":          "Welcome to %s %s Setup",
+	"console.accept_license":   "Do you accept the license agreement above? (Y/N): ",
+	"console.accept_eula_hint": "Silent installation requires the /ACCEPTEULA switch to accept the license agreement.",
+	"console.install_dir":      "Install directory [%s]: ",
+	"console.install_failed":   "Installation failed: %v",
+	"console.install_done":     "Installation complete. Enjoy!",
+	"console.launch":           "Run the application now? (Y/N): ",
+	"console.autostart":        "Start %s automatically? (Y/N): ",
+	"console.components":       "Enter component numbers to toggle (comma separated), or press Enter to continue: ",
+
+	"gui.title":            "%s %s Setup",
+	"gui.back":             "< Back",
+	"gui.next":             "Next >",
+	"gui.install":          "Install",
+	"gui.finish":           "Finish",
+	"gui.browse":           "Browse...",
+	"gui.browse_title":     "Choose the install folder",
+	"gui.welcome_title":    "Welcome",
+	"gui.welcome_text":     "Welcome to the %s %s Setup Wizard.\n\nThis wizard will guide you through the installation. It is recommended that you close all other applications before continuing.\n\nClick Next to continue.",
+	"gui.license_title":    "License Agreement",
+	"gui.license_intro":    "Please read the following license agreement:",
+	"gui.license_accept":   "I accept the terms of the license agreement",
+	"gui.dir_title":        "Choose Install Location",
+	"gui.dir_text":         "Setup will install %s in the following folder.\nTo install in a different folder, click Browse.",
+	"gui.components_title": "Choose Components",
+	"gui.progress_title":   "Installing",
+	"gui.finish_title":     "Installation Complete",
+	"gui.finish_text":      "%s has been installed to:\n%s",
+	"gui.failed_title":     "Installation Failed",
+	"gui.failed_text":      "Setup could not complete:\n%v",
+	"gui.launch":           "Run %s",
+	"gui.autostart":        "Start %s automatically",
+	"gui.confirm_exit":     "Are you sure you want to quit Setup?",
+
+	"uninstall.start":                 "Uninstalling...",
+	"uninstall.locate_failed":         "Unable to locate the uninstaller: %v",
+	"uninstall.no_record":             "No install record in %s (%v); nothing was removed.",
+	"uninstall.wrong_product":         "%s contains %s, not %s; nothing was removed.",
+	"uninstall.no_meta":               "This uninstaller does not identify its product (%v); nothing was removed.",
+	"uninstall.record_mismatch":       "The install record in %s was written for %s; nothing was removed.",
+	"uninstall.confirm":               "Are you sure you want to completely remove %s and all of its components?",
+	"uninstall.button":                "Uninstall",
+	"uninstall.cancelled":             "Uninstall cancelled.",
+	"uninstall.aborted":               "Uninstall aborted: %v",
+	"uninstall.still_running":         "Some applications are still running; related files will be deleted after a restart.",
+	"uninstall.post_hooks_failed":     "Failed to prepare post-uninstall hooks (ignored): %v",
+	"uninstall.self_delete_failed":    "Failed to schedule removal of the uninstaller (delete the folder manually): %v",
+	"uninstall.self_delete_scheduled": "Scheduled removal of the uninstaller and install directory...",
+	"uninstall.wait_parent_failed":    "Waiting for the uninstaller to exit failed: %v",
+	"uninstall.done":                  "%s has been removed from your computer.",
+
+	"log.see": "See the log for details: %s",
+}
+
+var messagesZH = map[string]string{
+	"common.cancel": "取消",
+
+	"hook.running":        "执行 %s 钩子: %s %s",
+	"hook.failed":         "%s 钩子 %s 失败: %v",
+	"hook.failed_ignored": "钩子失败（忽略）：%v",
+	"hook.timeout":        "超时 (%s)",
+	"hook.done":           "钩子完成，用时 %s",
+
+	"install.extract_failed":        "无法提取内置归档: %v",
+	"install.license_missing":       "安装包中缺少许可协议 %s。",
+	"install.unpack_failed":         "解包归档失败: %v",
+	"install.product":               "产品: %s  版本: %s",
+	"install.cancelled":             "安装已取消。",
+	"install.launch_failed":         "启动程序失败: %v",
+	"maintenance.choose":            "%s 已安装，要执行什么操作？",
+	"maintenance.modify":            "更改组件",
+	"maintenance.repair":            "修复",
+	"maintenance.not_installed":     "在 %s 未找到安装",
+	"maintenance.source":            "使用安装文件: %s",
+	"maintenance.source_invalid":    "忽略 %s: %v",
+	"maintenance.source_mismatch":   "忽略 %s: 其中为 %s %s",
+	"maintenance.no_source":         "需要 %s %s 的安装程序。请重新运行安装程序，或通过 /SOURCE= 指定其路径",
+	"install.cache_failed":          "缓存安装文件失败: %v",
+	"repair.no_digests":             "安装记录中没有文件校验值，只能检查缺失的文件。",
+	"repair.checked":                "已检查 %d 个文件，%d 个需要修复",
+	"repair.missing":                "缺失: %s",
+	"repair.corrupted":              "已修改或损坏: %s",
+	"repair.not_in_payload":         "安装文件中没有 %s",
+	"repair.still_broken":           "修复后文件仍不一致: %s",
+	"repair.failed":                 "修复失败: %v",
+	"repair.intact":                 "%s 的所有文件均完好。",
+	"repair.done":                   "已恢复 %d 个文件，%s 已修复。",
+	"version.installed":             "已安装版本: %s，本安装程序: %s",
+	"version.unparsable":            "无法比较版本 %q 与 %q，按升级继续",
+	"version.downgrade":             "已安装更新的版本（%s），本安装程序为 %s；如需降级请先卸载新版本。",
+	"version.uninstall_first":       "安装 %[2]s 之前需要先卸载 %[1]s。请从 %[3]s 卸载后重新运行安装程序。",
+	"version.same_installed":        "%s %s 已安装。",
+	"version.same_ask":              "%s %s 已安装，要执行什么操作？",
+	"version.reinstall":             "重新安装",
+	"wizard.version_change":         "已安装版本: %s\n新版本: %s",
+	"wizard.same_version":           "已安装版本 %s，将重新安装。",
+	"instance.running":              "%s 的另一个安装或卸载程序正在运行，请等待其完成后重试。",
+	"instance.waiting":              "%s 的另一个安装程序正在运行，最多等待 %d 秒...",
+	"instance.focused":              "已切换到正在运行的 %s 安装程序",
+	"instance.lock_failed":          "无法创建安装锁: %v",
+	"install.env_failed":            "更新环境变量失败（忽略）：%v",
+	"uninstall.env_failed":          "撤销环境变量修改失败（忽略）：%v",
+	"env.applied":                   "环境变量：%s %s = %s",
+	"env.reverted":                  "已撤销 %d 项环境变量修改",
+	"install.services_failed":       "注册服务失败（忽略）：%v",
+	"service.registered":            "已注册服务 %s",
+	"service.started":               "已启动服务 %s",
+	"service.stopped":               "已停止服务 %s",
+	"service.removed":               "已删除服务 %s",
+	"service.start_failed":          "启动服务 %s 失败（忽略）：%v",
+	"service.stop_failed":           "停止服务 %s 失败：%v",
+	"service.remove_failed":         "删除服务 %s 失败（忽略）：%v",
+	"install.autostart_failed":      "注册自动启动失败（忽略）：%v",
+	"autostart.registered":          "已注册自动启动（%s）：%s",
+	"autostart.removed":             "已删除自动启动（%s）：%s",
+	"autostart.remove_failed":       "删除自动启动 %s 失败（忽略）：%v",
+	"launch.start":                  "启动 %s",
+	"launch.started":                "程序已启动（PID %d）",
+	"launch.as_user":                "以调用者 %s 而不是 root 的身份启动",
+	"launch.as_shell_user":          "已以登录用户的非管理员身份启动",
+	"launch.no_entry":               "未定义安装后运行的入口 %q",
+	"launch.no_shell":               "没有运行中的桌面外壳，无法以登录用户身份启动程序",
+	"install.mkdir_failed":          "创建安装目录失败",
+	"install.target_dir":            "目标安装目录: %s",
+	"install.aborted":               "安装已中止",
+	"install.still_running":         "部分程序仍在运行，被占用的文件将在重启后替换。",
+	"install.clean_failed":          "清理已有目录失败",
+	"install.clean_done":            "目录清理完成，开始写入文件...",
+	"install.write_failed":          "写文件失败",
+	"install.write_done":            "文件写入完成。",
+	"install.backup_cleanup_failed": "删除旧版本备份失败（忽略）：%v",
+	"install.installed_to":          "已安装到: %s",
+	"install.no_main_entry":         "安装包未声明主程序。",
+	"install.entry_missing":         "入口 %s（%s）不在安装包中。",
+	"install.shortcuts_failed":      "创建快捷方式失败（忽略）：%v",
+	"install.shortcuts_done":        "快捷方式创建完成。",
+	"install.components_failed":     "创建组件快捷方式或注册表值失败（忽略）：%v",
+	"install.uninstaller_failed":    "创建卸载程序失败（忽略）：%v",
+	"install.record_failed":         "写入安装记录失败（忽略）：%v",
+	"install.registry_failed":       "写入注册表失败（忽略）：%v",
+	"install.registry_done":         "已写入注册表信息。",
+	"install.arch":                  "已为本机（%[2]s）选择 %[1]s 版本的主程序。",
+	"install.pending_reboot":        "有 %d 个文件被占用，将在重启计算机后替换。",
+	"install.reboot_required":       "请重启计算机以完成安装。",
+	"install.restoring":             "正在恢复安装前的文件...",
+	"install.restore_failed":        "恢复失败: %v（旧文件保留在 %s）",
+	"install.rollback_incomplete":   "旧文件已恢复，但部分新文件未能删除: %v",
+	"install.mkdir_entry":           "[%d/%d] 创建目录: %s",
+	"install.file_locked":           "[%d/%d] 文件被占用，重启后替换: %s",
+	"install.file_written":          "[%d/%d] 写入文件: %s (%d bytes)",
+
+	"progress.preparing":     "准备安装...",
+	"progress.prerequisites": "正在安装必备组件...",
+	"progress.pre_install":   "执行安装前任务...",
+	"progress.check_running": "检查正在运行的程序...",
+	"progress.clean":         "清理旧版本文件...",
+	"progress.copy":          "正在复制文件...",
+	"progress.post_install":  "执行安装后任务...",
+	"progress.shortcuts":     "正在创建快捷方式...",
+	"progress.register":      "正在注册程序...",
+	"progress.done":          "安装完成",
+
+	"check.arch":         "本产品为 %s 架构构建，无法在此 %s 计算机上运行。",
+	"check.os_version":   "本产品需要 %s 或更高版本的操作系统（当前为 %s）。",
+	"check.not_writable": "安装目录 %s 不可写：%v",
+	"check.disk_space":   "%s 所在磁盘空间不足：需要 %s，可用 %s。",
+
+	"installdir.previous":        "沿用上次的安装目录：%s",
+	"installdir.template_failed": "无法解析安装目录 %q（%v），使用默认目录。",
+	"installdir.remember_failed": "无法记录安装目录：%v",
+
+	"extract.done":        "已将 %d 个文件解压到 %s",
+	"extract.failed":      "解压失败：%v",
+	"extract.no_dir":      "/EXTRACT 需要指定目标目录，例如 /EXTRACT=C:\\temp\\app",
+	"extract.unsafe_path": "归档中的路径不安全：%q",
+	"portable.failed":     "无法启动应用程序：%v",
+
+	"prereq.present":           "必备组件已安装：%s",
+	"prereq.installing":        "正在安装必备组件：%s",
+	"prereq.done":              "必备组件安装完成：%s",
+	"prereq.reboot":            "必备组件 %s 需要重启计算机才能完成安装。",
+	"prereq.failed":            "安装必备组件 %s 失败：%v",
+	"prereq.failed_ignored":    "可选必备组件 %s 安装失败（忽略）：%v",
+	"prereq.exit_code":         "退出码 %d",
+	"prereq.missing_installer": "找不到安装程序：%s",
+
+	"clean.not_dir":         "目标路径存在但不是目录: %s",
+	"clean.protected":       "拒绝安装到系统或用户目录: %s",
+	"clean.other_product":   "%s 中安装有 %s，拒绝清理",
+	"clean.confirm_unknown": "%s 不为空且不是由本安装程序创建的（%d 项）：\n%s\n其中的全部内容将被删除。",
+	"clean.choice_delete":   "删除其中内容并安装",
+	"clean.choice_cancel":   "取消",
+	"clean.cancelled":       "安装已取消：%s 不为空",
+	"clean.confirmed":       "用户确认删除 %s 中的内容",
+	"clean.more":            "……以及另外 %d 项",
+	"clean.dryrun_nothing":  "演练：%s 不存在或为空，不会删除任何内容。",
+	"clean.dryrun_owned":    "演练：将删除 %s 中的以下内容（上次安装）：\n%s",
+	"clean.dryrun_unknown":  "演练：%s 不是由本安装程序创建的，确认后将删除以下内容：\n%s",
+	"clean.locked":          "文件被占用，暂不移动: %s",
+	"clean.move_failed":     "移动 %s 失败",
+
+	"procs.list_failed":      "无法枚举进程（忽略）：%v",
+	"procs.running":          "以下程序正在运行，需要关闭后才能继续：%s\n\n请关闭这些程序后点击“重试”，或选择“强制关闭”。选择“忽略”时被占用的文件将在重启后替换。",
+	"procs.retry":            "重试",
+	"procs.force":            "强制关闭",
+	"procs.ignore":           "忽略",
+	"procs.all_closed":       "相关程序已全部关闭。",
+	"procs.not_responding":   "程序 [%d] 未响应，强制结束。",
+	"procs.terminate_failed": "结束进程 [%d] 失败: %v",
+
+	"shortcut.desktop_creating":   " - 正在创建桌面快捷方式...",
+	"shortcut.desktop_failed":     "   × 桌面快捷方式失败: %v",
+	"shortcut.desktop_done":       "   √ 桌面快捷方式: %s",
+	"shortcut.startmenu_creating": " - 正在创建开始菜单快捷方式...",
+	"shortcut.startmenu_failed":   "   × 开始菜单快捷方式失败: %v",
+	"shortcut.startmenu_done":     "   √ 开始菜单快捷方式: %s",
+
+	"wizard.license_required": "必须接受许可协议才能继续安装。",
+	"wizard.dir_invalid":      "请输入有效的安装目录（绝对路径）。",
+	"wizard.dir_protected":    "这是系统或用户目录，请为应用程序选择其下的子目录。",
+
+	"console.press_enter":      "按回车退出...",
+	"console.choose":           "请选择 [%d]: ",
+	"console.welcome":          "欢迎安装 %s %s",
+	"console.accept_license":   "是否接受以上许可协议？(Y/N): ",
+	"console.accept_eula_hint": "静默安装需要使用 /ACCEPTEULA 开关接受许可协议。",
+	"console.install_dir":      "安装目录 [%s]: ",
+	"console.install_failed":   "安装失败: %v",
+	"console.install_done":     "安装完成，祝您使用愉快！",
+	"console.launch":           "是否立即运行程序？(Y/N): ",
+	"console.autostart":        "是否自动启动 %s？(Y/N): ",
+	"console.components":       "输入要切换的组件序号（逗号分隔），直接回车继续: ",
+
+	"gui.title":            "%s %s 安装",
+	"gui.back":             "< 上一步",
+	"gui.next":             "下一步 >",
+	"gui.install":          "安装",
+	"gui.finish":           "完成",
+	"gui.browse":           "浏览...",
+	"gui.browse_title":     "选择安装目录",
+	"gui.welcome_title":    "欢迎",
+	"gui.welcome_text":     "欢迎使用 %s %s 安装向导。\n\n本向导将引导您完成安装。建议在继续之前关闭其他应用程序。\n\n点击“下一步”继续。",
+	"gui.license_title":    "许可协议",
+	"gui.license_intro":    "请阅读以下许可协议：",
+	"gui.license_accept":   "我接受许可协议中的条款",
+	"gui.dir_title":        "选择安装位置",
+	"gui.dir_text":         "安装程序将把 %s 安装到以下目录。\n如需安装到其他目录，请点击“浏览”。",
+	"gui.components_title": "选择组件",
+	"gui.progress_title":   "正在安装",
+	"gui.finish_title":     "安装完成",
+	"gui.finish_text":      "%s 已成功安装到：\n%s",
+	"gui.failed_title":     "安装失败",
+	"gui.failed_text":      "安装未能完成：\n%v",
+	"gui.launch":           "运行 %s",
+	"gui.autostart":        "自动启动 %s",
+	"gui.confirm_exit":     "确定要退出安装吗？",
+
+	"uninstall.start":                 "正在卸载...",
+	"uninstall.locate_failed":         "无法定位卸载程序: %v",
+	"uninstall.no_record":             "%s 中没有安装记录（%v），未删除任何内容。",
+	"uninstall.wrong_product":         "%s 中安装的是 %s 而不是 %s，未删除任何内容。",
+	"uninstall.no_meta":               "卸载程序无法确认所属产品（%v），未删除任何内容。",
+	"uninstall.record_mismatch":       "%s 中的安装记录是为 %s 写入的，未删除任何内容。",
+	"uninstall.confirm":               "确定要完全移除 %s 及其所有组件吗？",
+	"uninstall.button":                "卸载",
+	"uninstall.cancelled":             "卸载已取消。",
+	"uninstall.aborted":               "卸载已中止：%v",
+	"uninstall.still_running":         "部分程序仍在运行，相关文件将在重启后删除。",
+	"uninstall.post_hooks_failed":     "准备 post-uninstall 钩子失败（忽略）：%v",
+	"uninstall.self_delete_failed":    "自删除计划失败（手动删除目录）：%v",
+	"uninstall.self_delete_scheduled": "已计划删除卸载程序与安装目录...",
+	"uninstall.wait_parent_failed":    "等待卸载程序退出失败: %v",
+	"uninstall.done":                  "%s 已从您的计算机中移除。",
+
+	"log.see": "详细信息请查看日志：%s",
+}
@@ -0,0 +1,23 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// osLanguages 按 gettext 的优先级读取 LANGUAGE、LC_ALL、LC_MESSAGES 与 LANG
+func osLanguages() []string {
+	var out []string
+	if v := os.Getenv("LANGUAGE"); v != "" {
+		out = append(out, strings.Split(v, ":")...)
+	}
+	for _, k := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(k); v != "" {
+			out = append(out, v)
+			break
+		}
+	}
+	return out
+}
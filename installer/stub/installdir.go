@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// 安装目录模板中可用的已知目录，以 {名称} 引用
+const (
+	folderProgramFiles    = "ProgramFiles"    // 与主程序架构匹配的 Program Files；Linux 为 /opt
+	folderProgramFiles64  = "ProgramFiles64"  // 64 位 Program Files（32 位系统上同 ProgramFiles）
+	folderProgramFilesX86 = "ProgramFilesX86" // 32 位 Program Files
+	folderLocalAppData    = "LocalAppData"    // Linux 为 XDG_DATA_HOME
+	folderAppData         = "AppData"         // 漫游 AppData；Linux 为 XDG_CONFIG_HOME
+	folderUserHome        = "UserHome"
+	folderXDGDataHome     = "XDG_DATA_HOME" // Windows 为 LocalAppData
+	folderDesktop         = "Desktop"
+	folderPrograms        = "Programs" // 开始菜单的“程序”目录（仅 Windows）
+)
+
+// 仅用于清理策略的已知目录（不能在安装目录模板中使用）
+const (
+	folderDocuments   = "Documents"
+	folderDownloads   = "Downloads"
+	folderWindows     = "Windows"     // 仅 Windows
+	folderSystem      = "System"      // System32，仅 Windows
+	folderProgramData = "ProgramData" // 仅 Windows
+	folderPublic      = "Public"      // C:\Users\Public，仅 Windows
+	folderStartup     = "Startup"     // 当前用户的“启动”文件夹，仅 Windows
+)
+
+// knownFolderResolver 抽象已知目录的查询，安装目录模板与快捷方式只依赖该接口，
+// 便于替换为假实现进行测试
+type knownFolderResolver interface {
+	// Path 返回已知目录的绝对路径；不支持或查询失败时返回错误
+	Path(id string) (string, error)
+}
+
+// knownFolders 当前使用的实现，默认为操作系统实现
+var knownFolders knownFolderResolver = osKnownFolders{}
+
+var errUnknownFolder = errors.New("unknown folder")
+
+var templateToken = regexp.MustCompile(`\{([A-Za-z0-9_]+)\}`)
+
+// resolveInstallDir 决定向导中的默认安装目录：上次安装的目录（仍有本产品的安装记录时）优先，
+// 其次为 meta 中的 InstallDir 模板，为空或无法解析时使用平台默认模板
+func resolveInstallDir(m InstallMeta) string {
+	if dir := lastInstallDir(m.ProductName); dir != "" {
+		if rec, err := loadInstallRecord(dir); err == nil && rec.ProductName == m.ProductName {
+			logT("installdir.previous", dir)
+			return dir
+		}
+	}
+	if m.InstallDir != "" {
+		dir, err := expandInstallDir(m.InstallDir, m)
+		if err == nil {
+			return dir
+		}
+		warnT("installdir.template_failed", m.InstallDir, err)
+	}
+	dir, err := expandInstallDir(defaultInstallDirTemplate(), m)
+	if err != nil {
+		cwd, _ := os.Getwd()
+		return filepath.Join(cwd, pathSegment(m.ProductName))
+	}
+	return dir
+}
+
+// expandInstallDir 替换模板中的 {ProductName}、{Version}、{Publisher} 与已知目录，
+// 结果为相对路径时相对于当前目录
+func expandInstallDir(tmpl string, m InstallMeta) (string, error) {
+	var firstErr error
+	out := templateToken.ReplaceAllStringFunc(tmpl, func(tok string) string {
+		name := tok[1 : len(tok)-1]
+		switch name {
+		case "ProductName":
+			return pathSegment(m.ProductName)
+		case "Version":
+			return pathSegment(m.Version)
+		case "Publisher":
+			return pathSegment(m.Publisher)
+		}
+		p, err := knownFolders.Path(name)
+		if err != nil && firstErr == nil {
+			firstErr = errors.New(name + ": " + err.Error())
+		}
+		return p
+	})
+	if firstErr != nil {
+		return "", firstErr
+	}
+	out = filepath.Clean(filepath.FromSlash(out))
+	if !filepath.IsAbs(out) {
+		return filepath.Abs(out)
+	}
+	return out, nil
+}
+
+// pathSegment 去掉不能出现在单个路径段中的字符
+func pathSegment(s string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`<>:"/\|?*`, r) || r < ' ' {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(s))
+}
@@ -0,0 +1,90 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type osKnownFolders struct{}
+
+func (osKnownFolders) Path(id string) (string, error) {
+	switch id {
+	case folderProgramFiles, folderProgramFiles64, folderProgramFilesX86:
+		return "/opt", nil
+	case folderLocalAppData, folderXDGDataHome:
+		return xdgDir("XDG_DATA_HOME", ".local/share")
+	case folderAppData:
+		return xdgDir("XDG_CONFIG_HOME", ".config")
+	case folderUserHome:
+		return os.UserHomeDir()
+	case folderDesktop:
+		return xdgDir("XDG_DESKTOP_DIR", "Desktop")
+	case folderDocuments:
+		return xdgDir("XDG_DOCUMENTS_DIR", "Documents")
+	case folderDownloads:
+		return xdgDir("XDG_DOWNLOAD_DIR", "Downloads")
+	}
+	return "", errUnknownFolder
+}
+
+// xdgDir 按 XDG Base Directory 规范读取环境变量（须为绝对路径），否则使用 ~/<def>
+func xdgDir(env, def string) (string, error) {
+	if p := os.Getenv(env); filepath.IsAbs(p) {
+		return p, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, def), nil
+}
+
+// defaultInstallDirTemplate root 安装到 /opt，普通用户安装到 XDG_DATA_HOME
+func defaultInstallDirTemplate() string {
+	if os.Geteuid() == 0 {
+		return "/opt/{ProductName}"
+	}
+	return "{XDG_DATA_HOME}/{ProductName}"
+}
+
+// installDirStateFile 记录上次安装目录的文件：$XDG_STATE_HOME/<ProductName>/install-dir
+func installDirStateFile(productName string) (string, error) {
+	state, err := xdgDir("XDG_STATE_HOME", ".local/state")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(state, pathSegment(productName), "install-dir"), nil
+}
+
+func lastInstallDir(productName string) string {
+	p, err := installDirStateFile(productName)
+	if err != nil {
+		return ""
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func rememberInstallDir(productName, dir string) error {
+	p, err := installDirStateFile(productName)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(p, []byte(dir+"\n"), 0o644)
+}
+
+func forgetInstallDir(productName string) {
+	if p, err := installDirStateFile(productName); err == nil {
+		_ = os.Remove(p)
+		_ = os.Remove(filepath.Dir(p))
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// fakeKnownFolders 以映射表代替系统查询
+type fakeKnownFolders map[string]string
+
+func (f fakeKnownFolders) Path(id string) (string, error) {
+	if p, ok := f[id]; ok {
+		return p, nil
+	}
+	return "", errUnknownFolder
+}
+
+func setFakeKnownFolders(t *testing.T, f fakeKnownFolders) {
+	t.Helper()
+	old := knownFolders
+	knownFolders = f
+	t.Cleanup(func() { knownFolders = old })
+}
+
+func TestExpandInstallDir(t *testing.T) {
+	root := t.TempDir()
+	setFakeKnownFolders(t, fakeKnownFolders{
+		folderProgramFiles: filepath.Join(root, "Program Files"),
+		folderLocalAppData: filepath.Join(root, "Local"),
+	})
+	m := InstallMeta{ProductName: "My: App", Version: "1.2/3", Publisher: "ACME"}
+	cwd, _ := os.Getwd()
+
+	tests := []struct {
+		tmpl    string
+		want    string
+		wantErr bool
+	}{
+		{"{ProgramFiles}/{Publisher}/{ProductName}", filepath.Join(root, "Program Files", "ACME", "My_ App"), false},
+		{"{LocalAppData}/{ProductName}-{Version}", filepath.Join(root, "Local", "My_ App-1.2_3"), false},
+		{"apps/{ProductName}", filepath.Join(cwd, "apps", "My_ App"), false},
+		{"{Desktop}/{ProductName}", "", true},
+		{"{NoSuchFolder}/x", "", true},
+	}
+	for _, tt := range tests {
+		got, err := expandInstallDir(tt.tmpl, m)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("expandInstallDir(%q) error = %v, wantErr %v", tt.tmpl, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("expandInstallDir(%q) = %q, want %q", tt.tmpl, got, tt.want)
+		}
+	}
+}
+
+func TestResolveInstallDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the last install directory is kept in the registry on Windows")
+	}
+	setupTestInstall(t)
+	root := t.TempDir()
+	setFakeKnownFolders(t, fakeKnownFolders{folderProgramFiles: root})
+	m := InstallMeta{ProductName: "app", InstallDir: "{ProgramFiles}/{ProductName}"}
+	fromTemplate := filepath.Join(root, "app")
+
+	// 没有上次的目录：使用模板
+	if got := resolveInstallDir(m); got != fromTemplate {
+		t.Errorf("no previous dir: got %q, want %q", got, fromTemplate)
+	}
+
+	prev := filepath.Join(t.TempDir(), "elsewhere")
+	if err := os.MkdirAll(prev, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := rememberInstallDir(m.ProductName, prev); err != nil {
+		t.Fatal(err)
+	}
+	// 记录的目录中没有安装记录
+	if got := resolveInstallDir(m); got != fromTemplate {
+		t.Errorf("previous dir without record: got %q, want %q", got, fromTemplate)
+	}
+	// 安装记录属于其他产品
+	if err := saveInstallRecord(&installRecord{ProductName: "other", InstallDir: prev}); err != nil {
+		t.Fatal(err)
+	}
+	if got := resolveInstallDir(m); got != fromTemplate {
+		t.Errorf("previous dir of another product: got %q, want %q", got, fromTemplate)
+	}
+	// 本产品的安装记录
+	if err := saveInstallRecord(&installRecord{ProductName: "app", InstallDir: prev}); err != nil {
+		t.Fatal(err)
+	}
+	if got := resolveInstallDir(m); got != prev {
+		t.Errorf("previous install: got %q, want %q", got, prev)
+	}
+	// 模板无法解析时回退到平台默认模板
+	forgetInstallDir(m.ProductName)
+	m.InstallDir = "{Desktop}/{ProductName}"
+	if got := resolveInstallDir(m); got == "" || got == fromTemplate || filepath.Base(got) != "app" {
+		t.Errorf("fallback: got %q", got)
+	}
+}
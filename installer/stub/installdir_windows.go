@@ -0,0 +1,82 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+type osKnownFolders struct{}
+
+func (osKnownFolders) Path(id string) (string, error) {
+	switch id {
+	case folderProgramFiles:
+		// 32 位主程序安装到 Program Files (x86)，与系统的文件系统重定向保持一致
+		if meta.Arch == "386" || meta.Arch == "arm" {
+			return windows.KnownFolderPath(windows.FOLDERID_ProgramFilesX86, 0)
+		}
+		return programFiles64()
+	case folderProgramFiles64:
+		return programFiles64()
+	case folderProgramFilesX86:
+		return windows.KnownFolderPath(windows.FOLDERID_ProgramFilesX86, 0)
+	case folderLocalAppData, folderXDGDataHome:
+		return windows.KnownFolderPath(windows.FOLDERID_LocalAppData, 0)
+	case folderAppData:
+		return windows.KnownFolderPath(windows.FOLDERID_RoamingAppData, 0)
+	case folderUserHome:
+		return windows.KnownFolderPath(windows.FOLDERID_Profile, 0)
+	case folderDesktop:
+		return windows.KnownFolderPath(windows.FOLDERID_Desktop, 0)
+	case folderPrograms:
+		return windows.KnownFolderPath(windows.FOLDERID_Programs, 0)
+	case folderDocuments:
+		return windows.KnownFolderPath(windows.FOLDERID_Documents, 0)
+	case folderDownloads:
+		return windows.KnownFolderPath(windows.FOLDERID_Downloads, 0)
+	case folderWindows:
+		return windows.KnownFolderPath(windows.FOLDERID_Windows, 0)
+	case folderSystem:
+		return windows.KnownFolderPath(windows.FOLDERID_System, 0)
+	case folderProgramData:
+		return windows.KnownFolderPath(windows.FOLDERID_ProgramData, 0)
+	case folderPublic:
+		return windows.KnownFolderPath(windows.FOLDERID_Public, 0)
+	case folderStartup:
+		return windows.KnownFolderPath(windows.FOLDERID_Startup, 0)
+	}
+	return "", errUnknownFolder
+}
+
+// programFiles64 32 位 stub 查询 FOLDERID_ProgramFiles 得到的是 (x86) 目录，
+// 且不支持 FOLDERID_ProgramFilesX64，因此优先使用 64 位系统为所有进程设置的 ProgramW6432
+func programFiles64() (string, error) {
+	if p := os.Getenv("ProgramW6432"); p != "" {
+		return p, nil
+	}
+	return windows.KnownFolderPath(windows.FOLDERID_ProgramFiles, 0)
+}
+
+func defaultInstallDirTemplate() string { return `{ProgramFiles}\{ProductName}` }
+
+// lastInstallDir 读取 writeRegistry 写入的 HKCU\Software\<ProductName>\InstallDir
+func lastInstallDir(productName string) string {
+	k, err := registry.OpenKey(registry.CURRENT_USER, `Software\`+productName, registry.QUERY_VALUE)
+	if err != nil {
+		return ""
+	}
+	defer k.Close()
+	v, _, err := k.GetStringValue("InstallDir")
+	if err != nil {
+		return ""
+	}
+	return v
+}
+
+// rememberInstallDir / forgetInstallDir：安装目录已由 writeRegistry 写入，随卸载删除注册表键
+func rememberInstallDir(productName, dir string) error { return nil }
+
+func forgetInstallDir(productName string) {}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+)
+
+// launchSpec 与 meta.json 中 runAfterInstall 对应
+type launchSpec struct {
+	EntryPoint string   `json:"entryPoint"` // 为空时为主入口
+	Args       []string `json:"args"`
+	WorkingDir string   `json:"workingDir"` // 相对安装目录；为空时为安装目录
+	Unchecked  bool     `json:"unchecked"`
+	Disabled   bool     `json:"disabled"`
+}
+
+// launchCommand 一次启动请求，由 processRunner.Launch 执行
+type launchCommand struct {
+	Path   string
+	Args   []string
+	Dir    string
+	AsUser bool // 当前进程已提权时以登录的非管理员用户身份启动
+}
+
+// launchChecked 完成页“运行程序”的默认值：/LAUNCH 强制启动（静默安装也会启动），/LAUNCH=0 不启动；
+// 否则静默安装不启动，交互安装按 runAfterInstall.unchecked
+func launchChecked(m InstallMeta) bool {
+	if v, ok := switchValue("LAUNCH"); ok {
+		return v != "0"
+	}
+	return !silentMode() && !m.RunAfterInstall.Unchecked
+}
+
+// buildLaunch 根据 runAfterInstall 生成启动请求
+func buildLaunch(m InstallMeta, installDir string) (launchCommand, error) {
+	r := m.RunAfterInstall
+	rel := mainEntry(m)
+	if r.EntryPoint != "" {
+		rel = entryPoints(m)[r.EntryPoint]
+	}
+	if rel == "" {
+		return launchCommand{}, errors.New(T("launch.no_entry", r.EntryPoint))
+	}
+	hc := hookContext{InstallDir: installDir, ProductName: m.ProductName, Version: m.Version}
+	cmd := launchCommand{
+		Path:   filepath.Join(installDir, filepath.FromSlash(rel)),
+		Dir:    installDir,
+		AsUser: true,
+	}
+	for _, a := range r.Args {
+		cmd.Args = append(cmd.Args, hc.expand(a))
+	}
+	if r.WorkingDir != "" {
+		cmd.Dir = filepath.FromSlash(hc.expand(r.WorkingDir))
+		if !filepath.IsAbs(cmd.Dir) {
+			cmd.Dir = filepath.Join(installDir, cmd.Dir)
+		}
+	}
+	return cmd, nil
+}
+
+// launchApp 安装完成后启动程序，不等待其退出
+func launchApp(m InstallMeta, installDir string) error {
+	cmd, err := buildLaunch(m, installDir)
+	if err != nil {
+		return err
+	}
+	logT("launch.start", cmd.Path)
+	pid, err := procRunner.Launch(cmd)
+	if err != nil {
+		return err
+	}
+	logT("launch.started", pid)
+	return nil
+}
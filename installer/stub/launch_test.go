@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLaunchChecked(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		unchecked bool
+		want      bool
+	}{
+		{"interactive", nil, false, true},
+		{"interactive unchecked", nil, true, false},
+		{"silent", []string{"/S"}, false, false},
+		{"silent /LAUNCH", []string{"/S", "/LAUNCH"}, false, true},
+		{"/LAUNCH overrides unchecked", []string{"/LAUNCH"}, true, true},
+		{"/LAUNCH=0", []string{"/LAUNCH=0"}, false, false},
+		{"/LAUNCH=1", []string{"/S", "/LAUNCH=1"}, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTestSwitches(t, tt.args...)
+			m := InstallMeta{RunAfterInstall: launchSpec{Unchecked: tt.unchecked}}
+			if got := launchChecked(m); got != tt.want {
+				t.Errorf("launchChecked = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLaunchApp(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "app")
+	eps := []entryPointSpec{
+		{Name: "main", Windows: "app.exe", Linux: "app", Main: true},
+		{Name: "tray", Windows: `bin\tray.exe`, Linux: "bin/tray"},
+	}
+	main := entryPoints(InstallMeta{EntryPoints: eps})["main"]
+	tray := entryPoints(InstallMeta{EntryPoints: eps})["tray"]
+
+	tests := []struct {
+		name    string
+		spec    launchSpec
+		want    launchCommand
+		wantErr bool
+	}{
+		{"main entry", launchSpec{},
+			launchCommand{Path: filepath.Join(dir, filepath.FromSlash(main)), Dir: dir, AsUser: true}, false},
+		{"entry point with args and working dir", launchSpec{
+			EntryPoint: "tray",
+			Args:       []string{"--data", "{InstallDir}/data", "--product={ProductName} {Version}"},
+			WorkingDir: "bin",
+		}, launchCommand{
+			Path:   filepath.Join(dir, filepath.FromSlash(tray)),
+			Args:   []string{"--data", dir + "/data", "--product=p 1.0"},
+			Dir:    filepath.Join(dir, "bin"),
+			AsUser: true,
+		}, false},
+		{"absolute working dir", launchSpec{WorkingDir: "{InstallDir}/work"},
+			launchCommand{Path: filepath.Join(dir, filepath.FromSlash(main)), Dir: filepath.Join(dir, "work"), AsUser: true}, false},
+		{"unknown entry point", launchSpec{EntryPoint: "nope"}, launchCommand{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeRunner(t)
+			m := InstallMeta{ProductName: "p", Version: "1.0", EntryPoints: eps, RunAfterInstall: tt.spec}
+			err := launchApp(m, dir)
+			if tt.wantErr {
+				if err == nil || len(f.launched) != 0 {
+					t.Fatalf("err = %v, launched = %v; want an error and nothing launched", err, f.launched)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(f.launched) != 1 || !reflect.DeepEqual(f.launched[0], tt.want) {
+				t.Errorf("launched = %+v, want %+v", f.launched, tt.want)
+			}
+		})
+	}
+
+	f := newFakeRunner(t)
+	f.err = errors.New("no shell")
+	if err := launchApp(InstallMeta{EntryPoints: eps}, dir); err == nil {
+		t.Error("launch failure not reported")
+	}
+}
+
+// TestRunAfterInstall 完整的静默安装：/LAUNCH 时安装后以非管理员身份启动主程序，否则不启动
+func TestRunAfterInstall(t *testing.T) {
+	for _, launch := range []bool{true, false} {
+		args := []string{"/S"}
+		if launch {
+			args = append(args, "/LAUNCH")
+		}
+		setTestSwitches(t, args...)
+		dir := setupTestInstall(t)
+		f := newFakeRunner(t)
+		meta = InstallMeta{ProductName: "launch-test", ExeName: "app", Version: "1.0.0",
+			RunAfterInstall: launchSpec{Args: []string{"--first-run"}}}
+		w := newWizard(wizardState{
+			ProductName:   meta.ProductName,
+			InstallDir:    dir,
+			LaunchOffered: true,
+			LaunchChecked: launchChecked(meta),
+		})
+		files := []*inMemoryFile{{Name: "app", Mode: 0o755, Data: []byte("app")}}
+		if code := runInstallWizard(w, files); code != exitSuccess {
+			t.Fatalf("launch=%v: exit code %d", launch, code)
+		}
+		var want []launchCommand
+		if launch {
+			want = []launchCommand{{Path: filepath.Join(dir, "app"), Args: []string{"--first-run"}, Dir: dir, AsUser: true}}
+		}
+		if !reflect.DeepEqual(f.launched, want) {
+			t.Errorf("launch=%v: launched = %+v, want %+v", launch, f.launched, want)
+		}
+	}
+}
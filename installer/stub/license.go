@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"path"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"exe_installer/installer/internal/rtf"
+)
+
+// licenseText 将打包进来的许可协议（.txt / .md / .rtf）转换为可直接显示的纯文本
+func licenseText(name string, data []byte) string {
+	if strings.EqualFold(path.Ext(name), ".rtf") {
+		return rtf.Parse(data).Text
+	}
+	return decodeText(data)
+}
+
+// decodeText 处理 UTF-8 / UTF-16 BOM；Markdown 原样显示
+func decodeText(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		data = data[3:]
+	case len(data) >= 2 && (data[0] == 0xFF && data[1] == 0xFE || data[0] == 0xFE && data[1] == 0xFF):
+		var order binary.ByteOrder = binary.LittleEndian
+		if data[0] == 0xFE {
+			order = binary.BigEndian
+		}
+		u := make([]uint16, 0, len(data)/2)
+		for i := 2; i+1 < len(data); i += 2 {
+			u = append(u, order.Uint16(data[i:]))
+		}
+		return string(utf16.Decode(u))
+	}
+	if !utf8.Valid(data) {
+		return rtf.DecodeCP1252(data)
+	}
+	return string(data)
+}
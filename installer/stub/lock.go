@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+// 第二个实例的处理方式，对应 meta 中的 secondInstance
+const (
+	secondInstanceWait  = "wait"  // 等待第一个实例结束
+	secondInstanceFocus = "focus" // 将第一个实例的窗口置于前台后退出
+	secondInstanceExit  = "exit"  // 直接退出
+)
+
+const defaultInstanceWait = 10 * time.Minute
+
+var errInstanceBusy = errors.New("another instance is running")
+
+// instanceLocker 抽象跨进程的产品锁：Windows 为命名互斥体，其他平台为 flock 锁文件，
+// 便于替换为假实现进行测试
+type instanceLocker interface {
+	// Lock 在 timeout 内获取名为 name 的锁（0 表示不等待），被其他进程持有时返回 errInstanceBusy
+	Lock(name string, timeout time.Duration) (release func(), err error)
+	// Focus 将产品的安装向导窗口置于前台，找不到时返回 false
+	Focus(product string) bool
+}
+
+// instanceLocks 当前使用的实现，默认为操作系统实现
+var instanceLocks instanceLocker = osInstanceLocker{}
+
+type osInstanceLocker struct{}
+
+// lockInstance 获取产品锁，保证同一产品同时只有一个安装、卸载或维护程序在修改安装目录。
+// 锁被占用时按 policy 处理；policy 为空时交互运行为 focus，静默运行为 wait。
+// 返回的 code 非零时调用方应以该退出码结束；无法创建锁时只记录警告，不阻止安装。
+func lockInstance(product, policy string, timeout time.Duration) (release func(), code int) {
+	noop := func() {}
+	if policy == "" {
+		policy = secondInstanceFocus
+		if silentMode() {
+			policy = secondInstanceWait
+		}
+	}
+	if timeout <= 0 {
+		timeout = defaultInstanceWait
+	}
+	name := "exe_installer-" + sanitizeLogName(product)
+	release, err := instanceLocks.Lock(name, 0)
+	if err == nil {
+		return release, exitSuccess
+	}
+	if !errors.Is(err, errInstanceBusy) {
+		warnT("instance.lock_failed", err)
+		return noop, exitSuccess
+	}
+
+	switch policy {
+	case secondInstanceWait:
+		logT("instance.waiting", product, int(timeout/time.Second))
+		release, err = instanceLocks.Lock(name, timeout)
+		if err == nil {
+			return release, exitSuccess
+		}
+		if !errors.Is(err, errInstanceBusy) {
+			warnT("instance.lock_failed", err)
+			return noop, exitSuccess
+		}
+	case secondInstanceFocus:
+		if instanceLocks.Focus(product) {
+			logT("instance.focused", product)
+			return noop, exitAlreadyRunning
+		}
+	}
+	reportError(T("instance.running", product))
+	return noop, exitAlreadyRunning
+}
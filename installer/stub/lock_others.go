@@ -0,0 +1,45 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// Lock 对临时目录中的 <name>.lock 加 flock 排他锁；进程退出时内核自动释放。
+// 锁文件由其他用户创建时以只读方式打开，flock 同样有效。
+func (osInstanceLocker) Lock(name string, timeout time.Duration) (func(), error) {
+	p := filepath.Join(os.TempDir(), name+".lock")
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_RDWR, 0o666)
+	if err != nil {
+		if f, err = os.Open(p); err != nil {
+			return nil, err
+		}
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			return func() {
+				_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+				f.Close()
+			}, nil
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			f.Close()
+			return nil, err
+		}
+		if !time.Now().Before(deadline) {
+			f.Close()
+			return nil, errInstanceBusy
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+// Focus 控制台前端没有可置于前台的窗口
+func (osInstanceLocker) Focus(string) bool { return false }
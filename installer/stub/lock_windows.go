@@ -0,0 +1,111 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	procGetClassNameW = user32.NewProc("GetClassNameW")
+	procIsIconic      = user32.NewProc("IsIconic")
+)
+
+const swRestore = 9
+
+// Lock 等待命名互斥体。优先使用 Global\ 命名空间，使不同会话中的实例互斥；
+// 无权在其中创建对象时退回 Local\。互斥体归获取它的线程所有，持有期间锁定当前线程。
+func (osInstanceLocker) Lock(name string, timeout time.Duration) (func(), error) {
+	h, err := createMutex(`Global\` + name)
+	if errors.Is(err, windows.ERROR_ACCESS_DENIED) {
+		h, err = createMutex(`Local\` + name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	runtime.LockOSThread()
+	ev, err := windows.WaitForSingleObject(h, uint32(timeout/time.Millisecond))
+	if err == nil && (ev == windows.WAIT_OBJECT_0 || ev == windows.WAIT_ABANDONED) {
+		return func() {
+			_ = windows.ReleaseMutex(h)
+			_ = windows.CloseHandle(h)
+			runtime.UnlockOSThread()
+		}, nil
+	}
+	runtime.UnlockOSThread()
+	_ = windows.CloseHandle(h)
+	if err != nil {
+		return nil, err
+	}
+	return nil, errInstanceBusy
+}
+
+// createMutex 创建或打开命名互斥体；已存在不视为错误
+func createMutex(name string) (windows.Handle, error) {
+	p, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return 0, err
+	}
+	h, err := windows.CreateMutex(nil, false, p)
+	if h != 0 && errors.Is(err, windows.ERROR_ALREADY_EXISTS) {
+		err = nil
+	}
+	return h, err
+}
+
+// focusCallback 查找 focusProduct 的安装向导窗口（不属于当前进程），结果写入 focusFound。
+// 与 closeWindowsCallback 相同，回调只创建一次，参数通过 focusMu 保护的变量传入。
+var (
+	focusMu       sync.Mutex
+	focusProduct  string
+	focusFound    windows.HWND
+	focusCallback = syscall.NewCallback(func(hwnd windows.HWND, _ uintptr) uintptr {
+		var pid uint32
+		if _, err := windows.GetWindowThreadProcessId(hwnd, &pid); err != nil || pid == uint32(os.Getpid()) {
+			return 1
+		}
+		if windowClass(hwnd) == wizardClass && strings.Contains(windowText(hwnd), focusProduct) {
+			focusFound = hwnd
+			return 0 // 停止枚举
+		}
+		return 1
+	})
+)
+
+// Focus 查找其他进程中标题含产品名的安装向导窗口，恢复并置于前台
+func (osInstanceLocker) Focus(product string) bool {
+	focusMu.Lock()
+	focusProduct, focusFound = product, 0
+	_ = windows.EnumWindows(focusCallback, nil)
+	found := focusFound
+	focusMu.Unlock()
+	if found == 0 {
+		return false
+	}
+	if r, _, _ := procIsIconic.Call(uintptr(found)); r != 0 {
+		procShowWindow.Call(uintptr(found), swRestore)
+	}
+	procSetForegroundWindow.Call(uintptr(found))
+	return true
+}
+
+func windowClass(hwnd windows.HWND) string {
+	buf := make([]uint16, 256)
+	n, _, _ := procGetClassNameW.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	return windows.UTF16ToString(buf[:n])
+}
+
+func windowText(hwnd windows.HWND) string {
+	buf := make([]uint16, 512)
+	n, _, _ := procGetWindowTextW.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	return windows.UTF16ToString(buf[:n])
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 每次运行（安装、升级、卸载）都写一份日志：人工阅读的文本日志与供支持工具解析的 JSON Lines。
+// 运行期间写在 %TEMP%（或 /LOG= 指定的路径），安装成功后复制到安装目录的 logs/ 中；
+// 失败时在错误信息中给出日志路径。
+
+// 日志级别
+const (
+	levelDebug = "debug" // 仅写入日志文件
+	levelInfo  = "info"
+	levelWarn  = "warn"
+	levelError = "error"
+)
+
+// logEntry JSON Lines 中的一行
+type logEntry struct {
+	Time       time.Time `json:"time"`
+	Level      string    `json:"level"`
+	Step       string    `json:"step,omitempty"`
+	Msg        string    `json:"msg"`
+	Op         string    `json:"op,omitempty"` // 文件操作：mkdir / write / replace / move / remove
+	Path       string    `json:"path,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"durationMs,omitempty"`
+}
+
+// runLogger 线程安全：GUI 下安装在后台 goroutine 中执行
+type runLogger struct {
+	mu      sync.Mutex
+	text    *os.File
+	jsonl   *os.File
+	path    string
+	pending []logEntry // 打开文件之前的记录
+	step    string
+	stepAt  time.Time
+	started time.Time
+}
+
+var runLog = &runLogger{started: time.Now()}
+
+// Open 创建日志文件 <prefix>-<时间>.log 与同名 .jsonl，并写入此前缓存的记录。
+// /LOG=<文件> 指定文本日志路径（JSON Lines 为其后加 .jsonl），已存在时追加。
+func (l *runLogger) Open(prefix string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.text != nil {
+		return nil
+	}
+	p, ok := switchValue("LOG")
+	if !ok || p == "" {
+		p = filepath.Join(os.TempDir(), fmt.Sprintf("%s-%s.log",
+			sanitizeLogName(prefix), l.started.Format("20060102-150405")))
+	}
+	text, err := os.OpenFile(p, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	jsonl, err := os.OpenFile(p+".jsonl", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		text.Close()
+		return err
+	}
+	l.text, l.jsonl, l.path = text, jsonl, p
+	for _, e := range l.pending {
+		l.writeLocked(e)
+	}
+	l.pending = nil
+	return nil
+}
+
+// Path 返回文本日志路径；尚未打开时为空
+func (l *runLogger) Path() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.path
+}
+
+func (l *runLogger) write(e logEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.Step == "" {
+		e.Step = l.step
+	}
+	if l.text == nil {
+		l.pending = append(l.pending, e)
+		return
+	}
+	l.writeLocked(e)
+}
+
+func (l *runLogger) writeLocked(e logEntry) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %-5s", e.Time.Format("2006-01-02 15:04:05.000"), strings.ToUpper(e.Level))
+	if e.Step != "" {
+		fmt.Fprintf(&b, " [%s]", e.Step)
+	}
+	b.WriteString(" " + e.Msg)
+	if e.Op != "" {
+		fmt.Fprintf(&b, " op=%s", e.Op)
+	}
+	if e.Path != "" {
+		fmt.Fprintf(&b, " path=%q", e.Path)
+	}
+	if e.Error != "" {
+		fmt.Fprintf(&b, " error=%q", e.Error)
+	}
+	if e.DurationMs > 0 {
+		fmt.Fprintf(&b, " (%dms)", e.DurationMs)
+	}
+	fmt.Fprintln(l.text, b.String())
+	if data, err := json.Marshal(e); err == nil {
+		l.jsonl.Write(append(data, '\n'))
+	}
+}
+
+// Step 结束上一步骤（记录用时）并开始新步骤
+func (l *runLogger) Step(name string) {
+	l.endStep()
+	l.mu.Lock()
+	l.step, l.stepAt = name, time.Now()
+	l.mu.Unlock()
+	l.write(logEntry{Level: levelDebug, Msg: "step started"})
+}
+
+func (l *runLogger) endStep() {
+	l.mu.Lock()
+	step, at := l.step, l.stepAt
+	l.step = ""
+	l.mu.Unlock()
+	if step != "" {
+		l.write(logEntry{Level: levelDebug, Step: step, Msg: "step finished", DurationMs: time.Since(at).Milliseconds()})
+	}
+}
+
+// Close 记录运行结果与总用时并关闭文件
+func (l *runLogger) Close(err error) {
+	l.endStep()
+	e := logEntry{Level: levelInfo, Msg: "finished", DurationMs: time.Since(l.started).Milliseconds()}
+	if err != nil {
+		e.Level, e.Msg, e.Error = levelError, "failed", err.Error()
+	}
+	l.write(e)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.text != nil {
+		l.text.Close()
+		l.jsonl.Close()
+		l.text, l.jsonl = nil, nil
+	}
+}
+
+// CopyTo 将日志复制到 dir（安装目录下的 logs/），返回复制后的文本日志路径
+func (l *runLogger) CopyTo(dir string) (string, error) {
+	l.mu.Lock()
+	src := l.path
+	l.mu.Unlock()
+	if src == "" {
+		return "", nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	dst := filepath.Join(dir, filepath.Base(src))
+	for _, suffix := range []string{"", ".jsonl"} {
+		if err := copyFile(src+suffix, dst+suffix); err != nil {
+			return "", err
+		}
+	}
+	return dst, nil
+}
+
+func sanitizeLogName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`<>:"/\|?* `, r) {
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+// logAt 输出到界面并写入日志文件
+func logAt(level, msg string) {
+	ui.Log(msg)
+	runLog.write(logEntry{Level: level, Msg: msg})
+}
+
+// warnT 输出一条已翻译的警告（例如被忽略的失败）
+func warnT(key string, args ...any) { logAt(levelWarn, T(key, args...)) }
+
+// logFileOp 记录一次文件操作；仅写入日志文件
+func logFileOp(op, path string, err error) {
+	e := logEntry{Level: levelDebug, Msg: "file", Op: op, Path: path}
+	if err != nil {
+		e.Level, e.Error = levelWarn, err.Error()
+	}
+	runLog.write(e)
+}
+
+// reportError 显示错误并附上日志路径
+func reportError(msg string) {
+	runLog.write(logEntry{Level: levelError, Msg: msg})
+	if p := runLog.Path(); p != "" {
+		msg += "\n\n" + T("log.see", p)
+	}
+	ui.Error(msg)
+}
@@ -13,23 +13,56 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 )
 
 const (
-	magicTrailer = "SFXMAGIC"
-	trailerSize  = 8 + 8
+	magicTrailer   = "SFXMAGIC"
+	uninstallMagic = "SFXUNINS" // 卸载程序：不含归档，仅用 trailer 标记运行模式
+	trailerSize    = 8 + 8
 )
 
 // InstallMeta 与打包时的 meta.json 对应
 type InstallMeta struct {
-	ProductName             string `json:"productName"`
-	ExeName                 string `json:"exeName"`
-	InstallDir              string `json:"installDir"`
-	CreateDesktopShortcut   bool   `json:"createDesktopShortcut"`
-	CreateStartMenuShortcut bool   `json:"createStartMenuShortcut"`
-	Version                 string `json:"version"`
-	GeneratedAt             string `json:"generatedAt"`
-	ShortcutName            string `json:"shortcutName"`
+	ProductName                  string              `json:"productName"`
+	ExeName                      string              `json:"exeName"`
+	InstallDir                   string              `json:"installDir"`
+	CreateDesktopShortcut        bool                `json:"createDesktopShortcut"`
+	CreateStartMenuShortcut      bool                `json:"createStartMenuShortcut"`
+	Version                      string              `json:"version"`
+	GeneratedAt                  string              `json:"generatedAt"`
+	ShortcutName                 string              `json:"shortcutName"`
+	Publisher                    string              `json:"publisher"`
+	URLInfoAbout                 string              `json:"urlInfoAbout"`
+	HelpLink                     string              `json:"helpLink"`
+	URLUpdateInfo                string              `json:"urlUpdateInfo"`
+	Contact                      string              `json:"contact"`
+	Comments                     string              `json:"comments"`
+	Language                     uint32              `json:"language"`
+	DisplayIcon                  string              `json:"displayIcon"`
+	VersionMajor                 uint32              `json:"versionMajor"`
+	VersionMinor                 uint32              `json:"versionMinor"`
+	InstallSize                  int64               `json:"installSize"` // 解压后总字节数
+	Hooks                        []hookSpec          `json:"hooks"`
+	CloseAppsTimeoutSeconds      int                 `json:"closeAppsTimeoutSeconds"`
+	LicenseFile                  string              `json:"licenseFile"` // 归档根目录中的许可协议文件名
+	Components                   []componentSpec     `json:"components"`
+	Arch                         string              `json:"arch"`         // 主程序的 CPU 架构（GOARCH 命名），为空不检查
+	MinOSVersion                 string              `json:"minOSVersion"` // Windows 为 "10.0.17763" 形式，Linux 为内核版本
+	Prerequisites                []prereqSpec        `json:"prerequisites"`
+	Arches                       []string            `json:"arches"` // 通用 setup 中各主程序变体的架构
+	ArchFallback                 map[string][]string `json:"archFallback"`
+	EntryPoints                  []entryPointSpec    `json:"entryPoints"` // 为空时 ExeName 为唯一的主程序
+	RunAfterInstall              launchSpec          `json:"runAfterInstall"`
+	VersionPolicy                versionPolicy       `json:"versionPolicy"`
+	SecondInstance               string              `json:"secondInstance"` // 另一个实例正在运行时：wait、focus 或 exit
+	SecondInstanceTimeoutSeconds int                 `json:"secondInstanceTimeoutSeconds"`
+	Environment                  []envSpec           `json:"environment"`     // 要设置或加入列表（如 PATH）的环境变量
+	Services                     []serviceSpec       `json:"services"`        // 安装后注册并启动，卸载时删除
+	Autostart                    []autostartSpec     `json:"autostart"`       // 登录时启动或按计划运行
+	AutostartChoice              string              `json:"autostartChoice"` // 向导中“自动启动”复选框：checked、unchecked 或 always
+	Portable                     bool                `json:"portable"`        // 不安装：解压到临时目录运行主程序，退出后删除
+	CachePayload                 bool                `json:"cachePayload"`    // 在安装目录保存归档副本，供修复与修改使用
 }
 
 // 默认值（若 meta.json 缺失）
@@ -49,103 +82,354 @@ type inMemoryFile struct {
 }
 
 func main() {
-	if isUninstallMode() {
-		runUninstall()
+	selectLanguage()
+	runLog.write(logEntry{Level: levelInfo, Msg: "started: " + strings.Join(os.Args, " ")})
+
+	// 卸载程序的临时副本：负责删除安装目录与原卸载程序
+	if dir, ok := switchValue("FINISHUNINSTALL"); ok {
+		_ = runLog.Open("uninstall") // 父进程通过 /LOG= 传入日志路径，追加到同一份日志
+		self, _ := os.Executable()
+		runFinishUninstall(dir, self)
+		runLog.Close(nil)
 		return
 	}
 
-	fmt.Println("正在安装，请稍候...")
+	ui = chooseUI()
+	if mode := maintenanceMode(); mode != "" {
+		os.Exit(processExitCode(runMaintenance(mode)))
+	}
+	if isUninstallMode() {
+		os.Exit(processExitCode(runUninstall()))
+	}
+	if dir, ok := switchValue("EXTRACT"); ok {
+		os.Exit(processExitCode(runExtract(dir)))
+	}
+	os.Exit(processExitCode(runInstaller()))
+}
 
-	archive, err := extractSelf()
+// runInstaller 解包内置归档并驱动安装向导，返回进程退出码
+func runInstaller() int {
+	files, err := loadPayload()
 	if err != nil {
-		fmt.Printf("无法提取内置归档: %v\n", err)
-		_ = pressAnyKey()
-		return
+		reportError(err.Error())
+		return exitFatal
+	}
+	if meta.Portable {
+		runPortable(files)
+	}
+	_ = runLog.Open(meta.ProductName + "-setup")
+	logT("install.product", meta.ProductName, meta.Version)
+	release, code := lockInstance(meta.ProductName, meta.SecondInstance, time.Duration(meta.SecondInstanceTimeoutSeconds)*time.Second)
+	defer release()
+	if code != exitSuccess {
+		runLog.Close(nil)
+		return code
+	}
+
+	if files, err = selectArchFiles(files); err != nil {
+		reportError(err.Error())
+		runLog.Close(err)
+		return exitCodeOf(err)
+	}
+	if err := checkPlatform(meta); err != nil {
+		reportError(err.Error())
+		runLog.Close(err)
+		return exitCodeOf(err)
+	}
+
+	var license string
+	if meta.LicenseFile != "" {
+		f := findFile(files, meta.LicenseFile)
+		if f == nil {
+			reportError(T("install.license_missing", meta.LicenseFile))
+			runLog.Close(nil)
+			return exitFatal
+		}
+		license = licenseText(f.Name, f.Data)
 	}
 
-	fmt.Println("正在解压归档...")
-	files, err := untarGzToMemory(archive)
+	installDir := resolveInstallDir(meta)
+	if hasSwitch("DRYRUN") {
+		return runCleanDryRun(installDir)
+	}
+	prev, _ := loadInstallRecord(installDir) // 升级时沿用上次的组件选择
+	installed := ""
+	if prev != nil && prev.ProductName == meta.ProductName {
+		installed = prev.Version
+		logT("version.installed", prev.Version, meta.Version)
+	}
+	same, err := checkInstalledVersion(meta, prev)
 	if err != nil {
-		fmt.Printf("解包归档失败: %v\n", err)
-		_ = pressAnyKey()
-		return
+		reportError(err.Error())
+		runLog.Close(err)
+		return exitCodeOf(err)
+	}
+	if same {
+		switch sameVersionAction(meta) {
+		case sameVersionRepair:
+			return runRepair(prev)
+		case sameVersionBlock:
+			err := withExitCode(exitProductVersion, errors.New(T("version.same_installed", meta.ProductName, meta.Version)))
+			reportError(err.Error())
+			runLog.Close(err)
+			return exitCodeOf(err)
+		case "":
+			logT("install.cancelled")
+			runLog.Close(nil)
+			return exitCancelled
+		}
 	}
-	fmt.Printf("解压完成，共 %d 个条目。\n", len(files))
 
-	// 解析 meta.json
-	if m := findFile(files, "meta.json"); m != nil {
-		_ = json.Unmarshal(m.Data, &meta) // 宽松处理
+	w := newWizard(wizardState{
+		ProductName:      meta.ProductName,
+		Version:          meta.Version,
+		LicenseText:      license,
+		LicenseAccepted:  silentMode() && hasSwitch("ACCEPTEULA"),
+		InstallDir:       installDir,
+		Components:       initialComponents(meta.Components, prev),
+		LaunchOffered:    !meta.RunAfterInstall.Disabled,
+		LaunchChecked:    launchChecked(meta),
+		InstalledVersion: installed,
+		AutostartOffered: autostartOffered(meta),
+		Autostart:        autostartDefault(meta, prev),
+	})
+	return runInstallWizard(w, files)
+}
+
+// runInstallWizard 驱动向导执行安装，返回进程退出码；安装与修改模式共用
+func runInstallWizard(w *wizard, files []*inMemoryFile) int {
+	var installErr error
+	ui.Run(w, func(progress progressFunc) (string, error) {
+		exe, err := install(files, w.State.InstallDir, w.SelectedComponents(), w.State.Autostart, progress)
+		installErr = err
+		if p := runLog.Path(); err != nil && p != "" {
+			err = fmt.Errorf("%w\n\n%s", err, T("log.see", p))
+		}
+		return exe, err
+	})
+	if w.Cancelled() {
+		logT("install.cancelled")
+		runLog.Close(nil)
+		return exitCancelled
+	}
+	if w.State.LaunchApp && w.State.Err == nil {
+		if err := launchApp(meta, w.State.InstallDir); err != nil {
+			reportError(T("install.launch_failed", err))
+		}
+	}
+	runLog.Close(installErr)
+	if installErr != nil {
+		return exitCodeOf(installErr)
 	}
-	fmt.Printf("产品: %s  版本: %s\n", meta.ProductName, meta.Version)
+	_, _ = runLog.CopyTo(filepath.Join(w.State.InstallDir, "logs"))
+	if len(pendingReboot) > 0 || rebootRequired {
+		return exitRebootRequired
+	}
+	return exitSuccess
+}
 
-	installDir, err := decideInstallDir(meta.ProductName, meta.InstallDir)
+// install 执行实际安装步骤并报告进度，返回主程序路径；失败时已尽量恢复安装前的文件
+func install(files []*inMemoryFile, installDir string, components []string, autostart bool, progress progressFunc) (string, error) {
+	runLog.Step("checks")
+	progress(0, T("progress.preparing"))
+	prereqFiles, files := splitPrereqFiles(files)
+	files = componentFiles(files, meta.Components, components)
+	if err := checkEntryPoints(meta, files, components); err != nil {
+		return "", err
+	}
+	// 向导中可能改选了另一个已安装旧版本的目录
+	if rec, err := loadInstallRecord(installDir); err == nil {
+		if _, err := checkInstalledVersion(meta, rec); err != nil {
+			return "", err
+		}
+	}
+	if err := checkTarget(installDir, filesSize(files)); err != nil {
+		return "", err
+	}
+	// 在运行前置组件与钩子之前按清理策略检查安装目录
+	plan, err := planClean(installDir)
+	if err == nil {
+		err = confirmClean(plan)
+	}
 	if err != nil {
-		fmt.Printf("创建安装目录失败: %v\n", err)
-		_ = pressAnyKey()
-		return
+		return "", err
 	}
-	fmt.Printf("目标安装目录: %s\n", installDir)
 
-	// 在写入之前清理旧内容（保留目录本身），避免残留旧版本文件
-	fmt.Println("清理旧版本文件（若存在）...")
-	if err := cleanInstallDir(installDir); err != nil {
-		fmt.Printf("清理已有目录失败: %v\n", err)
-		_ = pressAnyKey()
-		return
+	if len(meta.Prerequisites) > 0 {
+		runLog.Step("prerequisites")
+		progress(2, T("progress.prerequisites"))
+		if err := installPrerequisites(meta.Prerequisites, prereqFiles); err != nil {
+			return "", err
+		}
 	}
-	fmt.Println("目录清理完成，开始写入文件...")
 
-	if err := writeFilesWithLog(files, installDir); err != nil {
-		fmt.Printf("写文件失败: %v\n", err)
-		_ = pressAnyKey()
-		return
+	runLog.Step("prepare")
+	if err := os.MkdirAll(installDir, 0o755); err != nil {
+		return "", fmt.Errorf("%s: %w", T("install.mkdir_failed"), err)
 	}
-	fmt.Println("文件写入完成。")
+	logT("install.target_dir", installDir)
+	prev, _ := loadInstallRecord(installDir) // 清理旧文件前读取，用于删除上次遗留的快捷方式与注册表值
 
-	fmt.Printf("已安装到: %s\n", installDir)
+	hc := hookContext{InstallDir: installDir, ProductName: meta.ProductName, Version: meta.Version}
+	runLog.Step("pre-install")
+	progress(5, T("progress.pre_install"))
+	if err := runPreInstallHooks(files, hc); err != nil {
+		return "", fmt.Errorf("%s: %w", T("install.aborted"), err)
+	}
 
-	// 确定实际 exe 路径
-	exePath := filepath.Join(installDir, meta.ExeName)
-	if _, err := os.Stat(exePath); err != nil {
-		fmt.Printf("未找到指定主程序 %s，尝试自动查找...\n", meta.ExeName)
-		if detected := detectAnyExe(installDir); detected != "" {
-			fmt.Printf("自动发现可执行文件: %s\n", detected)
-			exePath = detected
-		} else {
-			fmt.Println("未发现任何 .exe，跳过快捷方式创建。")
-			_ = pressAnyKey()
-			return
+	// 安装目录中的程序仍在运行时，写入会因文件被占用而中途失败；服务先经服务管理器停止，
+	// 避免被当作普通进程强制结束
+	runLog.Step("stop-services")
+	stopped := stopServices(serviceNames(meta, prev))
+	runLog.Step("close-apps")
+	progress(10, T("progress.check_running"))
+	if left := closeRunningInstances(installDir, time.Duration(meta.CloseAppsTimeoutSeconds)*time.Second); len(left) > 0 {
+		warnT("install.still_running")
+	}
+
+	// 在写入之前将旧内容移入备份目录（保留目录本身），避免残留旧版本文件；失败时可恢复
+	runLog.Step("clean")
+	progress(15, T("progress.clean"))
+	backup, err := cleanInstallDir(plan)
+	if err != nil {
+		startServices(stopped)
+		return "", fmt.Errorf("%s: %w", T("install.clean_failed"), err)
+	}
+	logT("install.clean_done")
+
+	runLog.Step("copy")
+	progress(20, T("progress.copy"))
+	if err := writeFilesWithLog(files, installDir, func(done, total int) {
+		progress(20+60*done/total, "")
+	}); err != nil {
+		restoreBackup(backup)
+		startServices(stopped)
+		return "", fmt.Errorf("%s: %w", T("install.write_failed"), err)
+	}
+	logT("install.write_done")
+
+	runLog.Step("post-install")
+	progress(82, T("progress.post_install"))
+	if err := runHooks(meta.Hooks, hookPostInstall, hc); err != nil {
+		// 此时旧版本的文件已被替换，而新版本的安装记录与卸载程序尚未写入；即使策略为 abort
+		// 也恢复安装前的文件，否则升级时会留下既无法运行旧版本、也无法卸载的半成品
+		restoreBackup(backup)
+		startServices(stopped)
+		return "", fmt.Errorf("%s: %w", T("install.aborted"), err)
+	}
+	if err := backup.commit(); err != nil {
+		warnT("install.backup_cleanup_failed", err)
+	}
+
+	logT("install.installed_to", installDir)
+
+	exePath := filepath.Join(installDir, filepath.FromSlash(mainEntry(meta)))
+
+	rec := newInstallRecord(meta, installDir, exePath, files)
+	rec.Components = components
+	rec.OfferedComponents = componentNames(meta.Components)
+	for _, h := range meta.Hooks {
+		if h.Stage == hookPreUninstall || h.Stage == hookPostUninstall {
+			rec.Hooks = append(rec.Hooks, h)
 		}
 	}
 
 	if runtime.GOOS == "windows" && (meta.CreateDesktopShortcut || meta.CreateStartMenuShortcut) {
-		fmt.Println("开始创建快捷方式...")
-		if err := createShortcuts(exePath, installDir, meta); err != nil {
-			fmt.Printf("创建快捷方式失败（忽略）：%v\n", err)
+		runLog.Step("shortcuts")
+		progress(88, T("progress.shortcuts"))
+		links, err := createShortcuts(exePath, installDir, meta)
+		rec.Shortcuts = links
+		if err != nil {
+			warnT("install.shortcuts_failed", err)
 		} else {
-			fmt.Println("快捷方式创建完成。")
+			logT("install.shortcuts_done")
 		}
 	}
+	if err := applyComponents(selectedSpecs(meta.Components, components), hc, rec); err != nil {
+		warnT("install.components_failed", err)
+	}
+	if prev != nil {
+		removeStaleEntries(prev, rec)
+	}
+	if err := applyEnvironment(meta, hc, rec, prev); err != nil {
+		warnT("install.env_failed", err)
+	}
+	runLog.Step("services")
+	if err := registerServices(meta, hc, rec, prev, stopped); err != nil {
+		warnT("install.services_failed", err)
+	}
+	if err := applyAutostart(meta, hc, rec, prev, autostart); err != nil {
+		warnT("install.autostart_failed", err)
+	}
 
-	// 生成卸载程序并写入注册表（仅 Windows 生效）
-	if runtime.GOOS == "windows" {
-		if err := createUninstaller(installDir); err != nil {
-			fmt.Printf("创建卸载程序失败（忽略）：%v\n", err)
+	// 生成卸载程序与安装记录；注册表仅 Windows 生效
+	runLog.Step("register")
+	progress(94, T("progress.register"))
+	if err := createUninstaller(installDir); err != nil {
+		warnT("install.uninstaller_failed", err)
+	}
+	if meta.CachePayload {
+		if err := cachePayload(installDir); err != nil {
+			warnT("install.cache_failed", err)
 		}
+	}
+	if rec.SetupPath == "" && prev != nil {
+		rec.SetupPath = prev.SetupPath
+	}
+	if err := saveInstallRecord(rec); err != nil {
+		warnT("install.record_failed", err)
+	}
+	if runtime.GOOS == "windows" {
 		if err := writeRegistry(meta, installDir, exePath); err != nil {
-			fmt.Printf("写入注册表失败（忽略）：%v\n", err)
+			warnT("install.registry_failed", err)
 		} else {
-			fmt.Println("已写入注册表信息。")
+			logT("install.registry_done")
 		}
 	}
+	if err := rememberInstallDir(meta.ProductName, installDir); err != nil {
+		warnT("installdir.remember_failed", err)
+	}
 
-	fmt.Println("安装完成，祝您使用愉快！")
-	_ = pressAnyKey()
+	if len(pendingReboot) > 0 {
+		warnT("install.pending_reboot", len(pendingReboot))
+	}
+	if rebootRequired {
+		warnT("install.reboot_required")
+	}
+	progress(100, T("progress.done"))
+	return exePath, nil
+}
+
+// runPreInstallHooks 执行 pre-install 钩子。此时新文件尚未写入，
+// 钩子引用的文件从归档（或旧版本安装目录）复制到临时目录执行。
+func runPreInstallHooks(files []*inMemoryFile, hc hookContext) error {
+	dir, cleanup, err := stageHookFiles(meta.Hooks, hookPreInstall, files, hc.InstallDir)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	hc.BaseDir = dir
+	return runHooks(meta.Hooks, hookPreInstall, hc)
+}
+
+func restoreBackup(b *installBackup) {
+	logT("install.restoring")
+	if err := b.rollback(); err != nil {
+		if b.backup == "" {
+			// 旧文件已全部恢复，只有部分新文件未能删除
+			warnT("install.rollback_incomplete", err)
+			return
+		}
+		warnT("install.restore_failed", err, b.backup)
+	}
 }
 
 func pressAnyKey() error {
-	fmt.Print("按回车退出...")
-	_, err := fmt.Scanln()
+	if silentMode() {
+		return nil
+	}
+	fmt.Print(T("console.press_enter"))
+	_, err := stdin.ReadString('\n')
 	return err
 }
 
@@ -193,6 +477,15 @@ func untarGzToMemory(gzData []byte) ([]*inMemoryFile, error) {
 	return out, nil
 }
 
+// filesSize 返回全部文件解压后的总字节数
+func filesSize(files []*inMemoryFile) int64 {
+	var n int64
+	for _, f := range files {
+		n += int64(len(f.Data))
+	}
+	return n
+}
+
 func findFile(files []*inMemoryFile, name string) *inMemoryFile {
 	for _, f := range files {
 		if f.Name == name {
@@ -202,42 +495,34 @@ func findFile(files []*inMemoryFile, name string) *inMemoryFile {
 	return nil
 }
 
-func writeFiles(files []*inMemoryFile, base string) error {
-	for _, f := range files {
-		if strings.HasSuffix(f.Name, "/") {
-			dir := filepath.Join(base, strings.TrimSuffix(f.Name, "/"))
-			if err := os.MkdirAll(dir, 0o755); err != nil {
-				return err
-			}
-			continue
-		}
-		dest := filepath.Join(base, f.Name)
-		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
-			return err
-		}
-		mode := os.FileMode(f.Mode)
-		if mode == 0 {
-			mode = 0o644
-		}
-		// Windows 下执行位不会实际影响 exe，可保留
-		if err := os.WriteFile(dest, f.Data, mode); err != nil {
+// pendingReboot 因被占用而登记为重启后替换的文件
+var pendingReboot []string
+
+// writeFilesWithLog 写入全部文件并逐条记录；onProgress 按已写入条目数回调。
+// 写入前先校验全部路径，含 ..、绝对路径或盘符的条目使整个写入失败，不会写出目标目录之外
+func writeFilesWithLog(files []*inMemoryFile, base string, onProgress func(done, total int)) error {
+	dests := make([]string, len(files))
+	for i, f := range files {
+		dest, err := safeJoin(base, strings.TrimSuffix(f.Name, "/"))
+		if err != nil {
 			return err
 		}
+		dests[i] = dest
 	}
-	return nil
-}
-
-func writeFilesWithLog(files []*inMemoryFile, base string) error {
 	for i, f := range files {
+		if onProgress != nil {
+			onProgress(i, len(files))
+		}
+		dest := dests[i]
 		if strings.HasSuffix(f.Name, "/") {
-			dir := filepath.Join(base, strings.TrimSuffix(f.Name, "/"))
+			dir := dest
 			if err := os.MkdirAll(dir, 0o755); err != nil {
 				return err
 			}
-			fmt.Printf("[%d/%d] 创建目录: %s\n", i+1, len(files), dir)
+			logT("install.mkdir_entry", i+1, len(files), dir)
+			logFileOp("mkdir", dir, nil)
 			continue
 		}
-		dest := filepath.Join(base, f.Name)
 		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
 			return err
 		}
@@ -246,26 +531,36 @@ func writeFilesWithLog(files []*inMemoryFile, base string) error {
 			mode = 0o644
 		}
 		if err := os.WriteFile(dest, f.Data, mode); err != nil {
-			return err
+			if !isFileLocked(err) {
+				return err
+			}
+			reboot, err := replaceLockedFile(dest, f.Data, mode)
+			if err != nil {
+				return err
+			}
+			if reboot {
+				pendingReboot = append(pendingReboot, dest)
+				warnT("install.file_locked", i+1, len(files), dest)
+				logFileOp("replace", dest, nil)
+				continue
+			}
 		}
-		fmt.Printf("[%d/%d] 写入文件: %s (%d bytes)\n", i+1, len(files), dest, len(f.Data))
+		logT("install.file_written", i+1, len(files), dest, len(f.Data))
+		logFileOp("write", dest, nil)
 	}
 	return nil
 }
 
 // ========== 自解压基础 ==========
 
-func extractSelf() ([]byte, error) {
-	self, err := os.Executable()
-	if err != nil {
-		return nil, err
-	}
-	f, err := os.Open(self)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
+// selfTrailer 描述当前可执行文件末尾的 [8 字节长度][8 字节 magic] 结构
+type selfTrailer struct {
+	Magic      string
+	ArchiveLen int64
+	StubSize   int64 // 不含归档与 trailer 的 stub 本体大小
+}
 
+func readSelfTrailer(f *os.File) (*selfTrailer, error) {
 	info, err := f.Stat()
 	if err != nil {
 		return nil, err
@@ -280,113 +575,180 @@ func extractSelf() ([]byte, error) {
 	if _, err := io.ReadFull(f, trailer); err != nil {
 		return nil, err
 	}
-	if string(trailer[8:]) != magicTrailer {
+	magic := string(trailer[8:])
+	if magic != magicTrailer && magic != uninstallMagic {
 		return nil, fmt.Errorf("magic mismatch")
 	}
 	archiveLen := binary.LittleEndian.Uint64(trailer[:8])
-	if archiveLen == 0 || archiveLen > uint64(info.Size()) {
+	if archiveLen > uint64(info.Size()) {
 		return nil, fmt.Errorf("invalid archive len")
 	}
 	start := info.Size() - int64(trailerSize) - int64(archiveLen)
 	if start < 0 {
 		return nil, fmt.Errorf("invalid start")
 	}
-	if _, err := f.Seek(start, io.SeekStart); err != nil {
+	return &selfTrailer{Magic: magic, ArchiveLen: int64(archiveLen), StubSize: start}, nil
+}
+
+// payloadArchive 当前载荷的原始归档（tar.gz），安装时可缓存到安装目录；
+// payloadSource 载荷所在的 setup 文件，来自缓存时为空
+var (
+	payloadArchive []byte
+	payloadSource  string
+)
+
+// loadPayload 读取自身携带的归档并解析 meta.json；归档中可能带有更匹配系统语言的翻译
+func loadPayload() ([]*inMemoryFile, error) {
+	archive, err := extractSelf()
+	if err != nil {
+		return nil, errors.New(T("install.extract_failed", err))
+	}
+	files, err := untarGzToMemory(archive)
+	if err != nil {
+		return nil, errors.New(T("install.unpack_failed", err))
+	}
+	if m := findFile(files, "meta.json"); m != nil {
+		_ = json.Unmarshal(m.Data, &meta) // 宽松处理
+	}
+	payloadArchive = archive
+	payloadSource, _ = os.Executable()
+	loadTranslations(files)
+	selectLanguage()
+	return files, nil
+}
+
+func extractSelf() ([]byte, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	return readSetupArchive(self)
+}
+
+// readSetupArchive 读取 setup 文件携带的归档
+func readSetupArchive(p string) ([]byte, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	t, err := readSelfTrailer(f)
+	if err != nil {
+		return nil, err
+	}
+	if t.Magic != magicTrailer || t.ArchiveLen == 0 {
+		return nil, fmt.Errorf("no payload")
+	}
+	if _, err := f.Seek(t.StubSize, io.SeekStart); err != nil {
 		return nil, err
 	}
-	buf := make([]byte, archiveLen)
+	buf := make([]byte, t.ArchiveLen)
 	if _, err := io.ReadFull(f, buf); err != nil {
 		return nil, err
 	}
 	return buf, nil
 }
 
-func decideInstallDir(productName, forced string) (string, error) {
-	if forced != "" {
-		return forced, os.MkdirAll(forced, 0o755)
+// ========== 目录清理（安全） ==========
+
+// cleanInstallDir 校验目标目录后，将其中的旧内容移入同级的临时备份目录。
+// 调用方在安装成功后 commit 删除备份，失败时 rollback 恢复。
+func cleanInstallDir(plan *cleanPlan) (*installBackup, error) {
+	dir := plan.Dir
+	b := &installBackup{dir: dir}
+	// 钩子可能改变了目录内容，以当前内容为准
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) || err == nil && len(entries) == 0 {
+		return b, nil
 	}
-	if runtime.GOOS == "windows" {
-		if pf := os.Getenv("ProgramFiles"); pf != "" {
-			path := filepath.Join(pf, productName)
-			return path, os.MkdirAll(path, 0o755)
-		}
+	if err != nil {
+		return nil, err
 	}
-	cwd, _ := os.Getwd()
-	path := filepath.Join(cwd, productName)
-	return path, os.MkdirAll(path, 0o755)
-}
-
-// detectAnyExe: 若指定 exeName 不存在，兜底寻找一个 .exe
-func detectAnyExe(root string) string {
-	entries, err := os.ReadDir(root)
+	// 备份目录与安装目录同级，保证 Rename 在同一卷内完成（运行中的 exe 也可被重命名）
+	b.backup, err = os.MkdirTemp(filepath.Dir(dir), "."+filepath.Base(dir)+".backup-")
 	if err != nil {
-		return ""
+		return nil, err
 	}
+	self, _ := os.Executable()
+	b.kept = map[string]bool{}
 	for _, e := range entries {
-		if e.IsDir() {
-			continue
-		}
 		name := e.Name()
-		if strings.HasSuffix(strings.ToLower(name), ".exe") {
-			return filepath.Join(root, name)
+		// 修改模式下运行中的卸载程序（维护程序）留在原处
+		if strings.EqualFold(filepath.Join(dir, name), self) {
+			b.kept[name] = true
+			continue
 		}
-	}
-	// 进一步递归一层（可选）
-	for _, e := range entries {
-		if e.IsDir() {
-			sub := filepath.Join(root, e.Name())
-			files, _ := os.ReadDir(sub)
-			for _, se := range files {
-				if !se.IsDir() && strings.HasSuffix(strings.ToLower(se.Name()), ".exe") {
-					return filepath.Join(sub, se.Name())
-				}
+		err := os.Rename(filepath.Join(dir, name), filepath.Join(b.backup, name))
+		logFileOp("move", filepath.Join(dir, name), err)
+		if err != nil {
+			if isFileLocked(err) {
+				// 被占用的文件保留在原处，写入时回退为重启后替换
+				warnT("clean.locked", name)
+				b.kept[name] = true
+				continue
 			}
+			_ = b.rollback()
+			return nil, fmt.Errorf("%s: %w", T("clean.move_failed", name), err)
 		}
 	}
-	return ""
+	return b, nil
 }
 
-// ========== 目录清理（安全） ==========
+// installBackup 保存安装前被移走的旧文件
+type installBackup struct {
+	dir    string          // 安装目录
+	backup string          // 备份目录；没有旧文件时为空
+	kept   map[string]bool // 留在安装目录中的条目（运行中的维护程序、被占用的文件），回滚时不删除
+}
 
-func cleanInstallDir(dir string) error {
-	// 若不存在则直接创建由调用者继续
-	info, err := os.Stat(dir)
-	if os.IsNotExist(err) {
+// commit 安装成功，删除备份
+func (b *installBackup) commit() error {
+	if b == nil || b.backup == "" {
 		return nil
 	}
-	if err != nil {
-		return err
-	}
-	if !info.IsDir() {
-		return fmt.Errorf("目标路径存在但不是目录: %s", dir)
-	}
-	// 安全保护：禁止删除过于顶层或敏感目录
-	lower := strings.ToLower(filepath.Clean(dir))
-	if lower == "c:/" || lower == "c:\\" || len(lower) <= 3 { // 例如 c:\ 或 d:\
-		return fmt.Errorf("拒绝清理系统根目录: %s", dir)
+	err := os.RemoveAll(b.backup)
+	b.backup = ""
+	return err
+}
+
+// rollback 删除已写入的新文件，并将备份中的旧文件移回安装目录。
+// 某一项删除或恢复失败时继续处理其余各项，未能恢复的旧文件留在备份目录中。
+func (b *installBackup) rollback() error {
+	if b == nil {
+		return nil
 	}
-	if pf := os.Getenv("ProgramFiles"); pf != "" {
-		lp := strings.ToLower(filepath.Clean(pf))
-		if lower == lp { // 不能直接是 Program Files 根
-			return fmt.Errorf("拒绝清理 ProgramFiles 根目录: %s", dir)
+	var errs []string
+	entries, _ := os.ReadDir(b.dir)
+	for _, e := range entries {
+		if b.kept[e.Name()] {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(b.dir, e.Name())); err != nil {
+			errs = append(errs, err.Error())
 		}
 	}
-	// 额外保护：必须包含产品名（防止 meta 空 productName）
-	if meta.ProductName == "" || !strings.Contains(lower, strings.ToLower(meta.ProductName)) {
-		// 仅警告，不中断——但为了安全这里直接拒绝
-		return fmt.Errorf("目录不包含产品名，取消清理: %s", dir)
-	}
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		return err
-	}
-	for _, e := range entries {
-		name := e.Name()
-		// 不删除正在运行的卸载程序 / 安装程序（理论上不在此目录）
-		full := filepath.Join(dir, name)
-		if err := os.RemoveAll(full); err != nil {
-			return fmt.Errorf("删除 %s 失败: %w", name, err)
+	if b.backup != "" {
+		olds, err := os.ReadDir(b.backup)
+		if err != nil {
+			errs = append(errs, err.Error())
 		}
+		restored := err == nil
+		for _, e := range olds {
+			if err := os.Rename(filepath.Join(b.backup, e.Name()), filepath.Join(b.dir, e.Name())); err != nil {
+				errs = append(errs, err.Error())
+				restored = false
+			}
+		}
+		if restored {
+			if err := os.Remove(b.backup); err != nil {
+				errs = append(errs, err.Error())
+			}
+			b.backup = ""
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
 	}
 	return nil
 }
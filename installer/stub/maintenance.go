@@ -0,0 +1,326 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// 维护模式，对应 /REPAIR 与 /MODIFY 开关
+const (
+	modeRepair = "REPAIR"
+	modeModify = "MODIFY"
+)
+
+// payloadCacheName 安装目录中缓存的归档（CachePayload）
+const payloadCacheName = "payload.cache"
+
+// maintenanceMode 返回命令行指定的维护模式，未指定时为空
+func maintenanceMode() string {
+	for _, m := range []string{modeRepair, modeModify} {
+		if hasSwitch(m) {
+			return m
+		}
+	}
+	return ""
+}
+
+// runMaintenance 修复或修改已安装的产品，返回进程退出码。
+// 通常由安装目录中的卸载程序运行（“应用和功能”中的“修改”）；也可以 setup /REPAIR 运行，
+// 此时直接使用 setup 自身的载荷。/REPAIR=<目录> 或 /MODIFY=<目录> 可指定安装目录。
+func runMaintenance(mode string) int {
+	installDir, err := maintenanceDir(mode)
+	if err != nil {
+		reportError(err.Error())
+		return exitFatal
+	}
+	rec, err := loadInstallRecord(installDir)
+	if err != nil {
+		reportError(T("maintenance.not_installed", installDir))
+		return exitFatal
+	}
+	loadTranslationDir(installDir)
+	selectLanguage(rec.Language)
+	_ = runLog.Open(rec.ProductName + "-" + strings.ToLower(mode))
+	logT("install.product", rec.ProductName, rec.Version)
+	release, code := lockInstance(rec.ProductName, "", 0)
+	defer release()
+	if code != exitSuccess {
+		runLog.Close(nil)
+		return code
+	}
+
+	// 没有可选组件时修改没有意义，直接修复；交互运行 /MODIFY 时让用户选择
+	if mode == modeModify && len(rec.OfferedComponents) == 0 {
+		mode = modeRepair
+	}
+	if mode == modeModify && !silentMode() {
+		choices := []string{T("maintenance.modify"), T("maintenance.repair"), T("common.cancel")}
+		switch ui.Choose(T("maintenance.choose", rec.ProductName), choices, 0) {
+		case 1:
+			mode = modeRepair
+		case 2:
+			logT("install.cancelled")
+			runLog.Close(nil)
+			return exitCancelled
+		}
+	}
+	if mode == modeModify {
+		return runModify(rec)
+	}
+	return runRepair(rec)
+}
+
+// maintenanceDir 确定要维护的安装目录：开关值、卸载程序所在目录或上次安装的目录
+func maintenanceDir(mode string) (string, error) {
+	if v, _ := switchValue(mode); v != "" {
+		return filepath.Abs(v)
+	}
+	if isUninstallMode() {
+		exe, err := os.Executable()
+		if err != nil {
+			return "", errors.New(T("uninstall.locate_failed", err))
+		}
+		return filepath.Dir(exe), nil
+	}
+	if _, err := loadPayload(); err != nil {
+		return "", err
+	}
+	if dir := lastInstallDir(meta.ProductName); dir != "" {
+		return dir, nil
+	}
+	return "", errors.New(T("maintenance.not_installed", meta.ProductName))
+}
+
+// runModify 重新显示组件页并以新的选择重新安装；取消的组件由安装流程删除
+func runModify(rec *installRecord) int {
+	files, err := loadMaintenancePayload(rec)
+	if err != nil {
+		reportError(err.Error())
+		runLog.Close(err)
+		return exitCodeOf(err)
+	}
+	w := newWizard(wizardState{
+		ProductName:     meta.ProductName,
+		Version:         meta.Version,
+		LicenseAccepted: true, // 安装时已接受
+		InstallDir:      rec.InstallDir,
+		Components:      initialComponents(meta.Components, rec),
+		Autostart:       autostartDefault(meta, rec),
+		Maintenance:     true,
+	})
+	return runInstallWizard(w, files)
+}
+
+// runRepair 按安装记录中的 SHA-256 校验已安装的文件，从载荷恢复缺失或损坏的文件，
+// 并重新写入注册表项与缺失的快捷方式。文件完好时不需要载荷。
+func runRepair(rec *installRecord) int {
+	dir := rec.InstallDir
+	runLog.Step("verify")
+	if len(rec.Digests) == 0 {
+		warnT("repair.no_digests")
+	}
+	broken := brokenFiles(rec)
+	logT("repair.checked", len(rec.Files), len(broken))
+
+	if len(broken) > 0 {
+		if err := restoreFiles(rec, broken); err != nil {
+			reportError(T("repair.failed", err))
+			runLog.Close(err)
+			return exitCodeOf(err)
+		}
+		if left := brokenFiles(rec); len(left) > 0 {
+			err := errors.New(T("repair.still_broken", strings.Join(left, ", ")))
+			reportError(err.Error())
+			runLog.Close(err)
+			return exitFatal
+		}
+	}
+
+	// 注册表与快捷方式使用已安装（或刚恢复）的 meta.json
+	if data, err := os.ReadFile(filepath.Join(dir, "meta.json")); err == nil {
+		_ = json.Unmarshal(data, &meta)
+	}
+	if runtime.GOOS == "windows" {
+		runLog.Step("register")
+		repairShortcuts(rec)
+		if err := writeRegistry(meta, dir, rec.ExePath); err != nil {
+			warnT("install.registry_failed", err)
+		}
+	}
+	runLog.Close(nil)
+	if len(broken) == 0 {
+		ui.Info(T("repair.intact", rec.ProductName))
+	} else {
+		ui.Info(T("repair.done", len(broken), rec.ProductName))
+	}
+	if len(pendingReboot) > 0 {
+		return exitRebootRequired
+	}
+	return exitSuccess
+}
+
+// brokenFiles 返回缺失或与记录的 SHA-256 不一致的文件；旧版本的记录没有摘要，只检查是否存在
+func brokenFiles(rec *installRecord) []string {
+	var out []string
+	for _, name := range rec.Files {
+		p := filepath.Join(rec.InstallDir, filepath.FromSlash(name))
+		sum, err := fileSHA256(p)
+		want, hasDigest := rec.Digests[name]
+		switch {
+		case err != nil:
+			logAt(levelWarn, T("repair.missing", name))
+		case hasDigest && sum != want:
+			logAt(levelWarn, T("repair.corrupted", name))
+		default:
+			continue
+		}
+		out = append(out, name)
+	}
+	return out
+}
+
+func fileSHA256(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// restoreFiles 从载荷中取出 names 并写回安装目录
+func restoreFiles(rec *installRecord, names []string) error {
+	files, err := loadMaintenancePayload(rec)
+	if err != nil {
+		return err
+	}
+	var restore []*inMemoryFile
+	for _, name := range names {
+		f := findFile(files, name)
+		if f == nil {
+			return errors.New(T("repair.not_in_payload", name))
+		}
+		restore = append(restore, f)
+	}
+	runLog.Step("stop-services")
+	stopped := stopServices(rec.Services)
+	defer startServices(stopped)
+	runLog.Step("close-apps")
+	if left := closeRunningInstances(rec.InstallDir, time.Duration(meta.CloseAppsTimeoutSeconds)*time.Second); len(left) > 0 {
+		warnT("install.still_running")
+	}
+	runLog.Step("copy")
+	return writeFilesWithLog(restore, rec.InstallDir, nil)
+}
+
+// repairShortcuts 主程序的快捷方式缺失时重新创建
+func repairShortcuts(rec *installRecord) {
+	for _, link := range rec.Shortcuts {
+		if _, err := os.Stat(link); err == nil {
+			continue
+		}
+		links, err := createShortcuts(rec.ExePath, rec.InstallDir, meta)
+		if err != nil {
+			warnT("install.shortcuts_failed", err)
+			return
+		}
+		rec.Shortcuts = links
+		if err := saveInstallRecord(rec); err != nil {
+			warnT("install.record_failed", err)
+		}
+		return
+	}
+}
+
+// loadMaintenancePayload 找到与已安装版本相同的载荷并设置 meta：依次尝试 setup 自身、
+// /SOURCE= 指定的 setup、安装目录中缓存的归档与安装时运行的 setup
+func loadMaintenancePayload(rec *installRecord) ([]*inMemoryFile, error) {
+	type source struct {
+		path string
+		raw  bool // 缓存的 tar.gz，而不是 setup
+	}
+	var sources []source
+	if !isUninstallMode() {
+		if self, err := os.Executable(); err == nil {
+			sources = append(sources, source{path: self})
+		}
+	}
+	if v, ok := switchValue("SOURCE"); ok && v != "" {
+		sources = append(sources, source{path: v})
+	}
+	sources = append(sources, source{path: filepath.Join(rec.InstallDir, payloadCacheName), raw: true})
+	if rec.SetupPath != "" {
+		sources = append(sources, source{path: rec.SetupPath})
+	}
+
+	tried := map[string]bool{}
+	for _, s := range sources {
+		if tried[s.path] {
+			continue
+		}
+		tried[s.path] = true
+		var archive []byte
+		var err error
+		if s.raw {
+			archive, err = os.ReadFile(s.path)
+		} else {
+			archive, err = readSetupArchive(s.path)
+		}
+		if err != nil {
+			continue
+		}
+		files, err := untarGzToMemory(archive)
+		if err != nil {
+			warnT("maintenance.source_invalid", s.path, err)
+			continue
+		}
+		m := meta
+		if f := findFile(files, "meta.json"); f != nil {
+			_ = json.Unmarshal(f.Data, &m)
+		}
+		if m.ProductName != rec.ProductName || m.Version != rec.Version {
+			warnT("maintenance.source_mismatch", s.path, m.ProductName, m.Version)
+			continue
+		}
+		logT("maintenance.source", s.path)
+		meta = m
+		payloadArchive = archive
+		payloadSource = ""
+		if !s.raw {
+			payloadSource = s.path
+		}
+		loadTranslations(files)
+		if len(meta.Arches) > 0 {
+			if rec.Arch == "" {
+				return selectArchFiles(files)
+			}
+			meta.Arch = rec.Arch
+			files = applyPayloadArch(files, rec.Arch)
+		}
+		return files, nil
+	}
+	return nil, errors.New(T("maintenance.no_source", rec.ProductName, rec.Version))
+}
+
+// cachePayload 将当前归档保存到安装目录，修复与修改时无需再次提供 setup
+func cachePayload(installDir string) error {
+	if payloadArchive == nil {
+		return nil
+	}
+	p := filepath.Join(installDir, payloadCacheName)
+	err := os.WriteFile(p, payloadArchive, 0o644)
+	logFileOp("write", p, err)
+	return err
+}
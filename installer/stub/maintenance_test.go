@@ -0,0 +1,207 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// testArchive 生成含 meta.json 与 files 的 tar.gz 载荷
+func testArchive(t *testing.T, m InstallMeta, files map[string]string) []byte {
+	t.Helper()
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	add := func(name string, data []byte) {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	add("meta.json", data)
+	for name, content := range files {
+		add(name, []byte(content))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// writeTestSetup 以 stub、归档与尾部标记写出一个 setup
+func writeTestSetup(t *testing.T, p string, archive []byte) {
+	t.Helper()
+	trailer := make([]byte, trailerSize)
+	binary.LittleEndian.PutUint64(trailer[:8], uint64(len(archive)))
+	copy(trailer[8:], magicTrailer)
+	data := append(append([]byte("stub"), archive...), trailer...)
+	if err := os.WriteFile(p, data, 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// setupMaintenanceTest 准备安装目录与 1.0.0 版本的安装记录，并在测试结束时恢复载荷状态
+func setupMaintenanceTest(t *testing.T) *installRecord {
+	t.Helper()
+	dir := setupTestInstall(t)
+	oldArchive, oldSource := payloadArchive, payloadSource
+	t.Cleanup(func() { payloadArchive, payloadSource = oldArchive, oldSource })
+	setTestSwitches(t)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return &installRecord{ProductName: "maint-test", Version: "1.0.0", InstallDir: dir}
+}
+
+func TestBrokenFiles(t *testing.T) {
+	rec := setupMaintenanceTest(t)
+	writeTestFile(t, filepath.Join(rec.InstallDir, "app"), "app")
+	writeTestFile(t, filepath.Join(rec.InstallDir, "lib", "core.so"), "tampered")
+	writeTestFile(t, filepath.Join(rec.InstallDir, "legacy.txt"), "anything")
+	appSum, _ := fileSHA256(filepath.Join(rec.InstallDir, "app"))
+	rec.Files = []string{"app", "lib/core.so", "data/missing.db", "legacy.txt", "legacy-missing.txt"}
+	rec.Digests = map[string]string{
+		"app":             appSum,
+		"lib/core.so":     "0000",
+		"data/missing.db": "0000",
+	}
+	want := []string{"lib/core.so", "data/missing.db", "legacy-missing.txt"}
+	if got := brokenFiles(rec); !reflect.DeepEqual(got, want) {
+		t.Errorf("brokenFiles = %v, want %v", got, want)
+	}
+}
+
+func TestRestoreFiles(t *testing.T) {
+	rec := setupMaintenanceTest(t)
+	m := InstallMeta{ProductName: rec.ProductName, Version: rec.Version}
+	archive := testArchive(t, m, map[string]string{"app": "app", "lib/core.so": "core"})
+	writeTestFile(t, filepath.Join(rec.InstallDir, payloadCacheName), string(archive))
+	writeTestFile(t, filepath.Join(rec.InstallDir, "app"), "app")
+	writeTestFile(t, filepath.Join(rec.InstallDir, "lib", "core.so"), "tampered")
+
+	if err := restoreFiles(rec, []string{"lib/core.so"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestFile(t, filepath.Join(rec.InstallDir, "lib", "core.so")); got != "core" {
+		t.Errorf("lib/core.so = %q, want the payload copy", got)
+	}
+	// 载荷中没有的文件无法恢复，不写入任何文件
+	writeTestFile(t, filepath.Join(rec.InstallDir, "lib", "core.so"), "tampered")
+	if err := restoreFiles(rec, []string{"lib/core.so", "plugins/extra.so"}); err == nil {
+		t.Error("restoreFiles with a file missing from the payload: want error")
+	}
+	if got := readTestFile(t, filepath.Join(rec.InstallDir, "lib", "core.so")); got != "tampered" {
+		t.Errorf("lib/core.so = %q, want it untouched", got)
+	}
+}
+
+func TestLoadMaintenancePayload(t *testing.T) {
+	type source int
+	const (
+		fromSource source = iota
+		fromCache
+		fromSetup
+		none
+	)
+	tests := []struct {
+		name   string
+		source InstallMeta // /SOURCE= 指定的 setup，ProductName 为空时不指定
+		cache  InstallMeta // 缓存的归档，ProductName 为空时写入损坏的内容
+		setup  InstallMeta // 安装时运行的 setup，ProductName 为空时不存在
+		want   source
+	}{
+		{"source matches", InstallMeta{ProductName: "maint-test", Version: "1.0.0"},
+			InstallMeta{ProductName: "maint-test", Version: "1.0.0"}, InstallMeta{}, fromSource},
+		{"source version mismatch", InstallMeta{ProductName: "maint-test", Version: "2.0.0"},
+			InstallMeta{ProductName: "maint-test", Version: "1.0.0"}, InstallMeta{}, fromCache},
+		{"source product mismatch", InstallMeta{ProductName: "other", Version: "1.0.0"},
+			InstallMeta{ProductName: "maint-test", Version: "1.0.0"}, InstallMeta{}, fromCache},
+		{"cache corrupted", InstallMeta{},
+			InstallMeta{}, InstallMeta{ProductName: "maint-test", Version: "1.0.0"}, fromSetup},
+		{"cache version mismatch", InstallMeta{},
+			InstallMeta{ProductName: "maint-test", Version: "0.9.0"}, InstallMeta{ProductName: "maint-test", Version: "1.0.0"}, fromSetup},
+		{"nothing matches", InstallMeta{ProductName: "maint-test", Version: "2.0.0"},
+			InstallMeta{ProductName: "maint-test", Version: "2.0.0"}, InstallMeta{ProductName: "maint-test", Version: "2.0.0"}, none},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := setupMaintenanceTest(t)
+			dir := t.TempDir()
+			sourcePath := filepath.Join(dir, "source-setup")
+			cachePath := filepath.Join(rec.InstallDir, payloadCacheName)
+			rec.SetupPath = filepath.Join(dir, "setup")
+			if tt.source.ProductName != "" {
+				writeTestSetup(t, sourcePath, testArchive(t, tt.source, map[string]string{"app": "source"}))
+				setTestSwitches(t, "/SOURCE="+sourcePath)
+			}
+			if tt.cache.ProductName != "" {
+				writeTestFile(t, cachePath, string(testArchive(t, tt.cache, map[string]string{"app": "cache"})))
+			} else {
+				writeTestFile(t, cachePath, "not a tar.gz")
+			}
+			if tt.setup.ProductName != "" {
+				writeTestSetup(t, rec.SetupPath, testArchive(t, tt.setup, map[string]string{"app": "setup"}))
+			}
+
+			files, err := loadMaintenancePayload(rec)
+			if tt.want == none {
+				if err == nil {
+					t.Fatal("loadMaintenancePayload: want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantApp, wantSource := "cache", "" // 缓存的归档不是 setup，payloadSource 为空
+			switch tt.want {
+			case fromSource:
+				wantApp, wantSource = "source", sourcePath
+			case fromSetup:
+				wantApp, wantSource = "setup", rec.SetupPath
+			}
+			if f := findFile(files, "app"); f == nil || string(f.Data) != wantApp {
+				t.Errorf("app = %v, want %q", f, wantApp)
+			}
+			if payloadSource != wantSource {
+				t.Errorf("payloadSource = %q, want %q", payloadSource, wantSource)
+			}
+			if meta.ProductName != rec.ProductName || meta.Version != rec.Version {
+				t.Errorf("meta = %s %s, want the installed product", meta.ProductName, meta.Version)
+			}
+		})
+	}
+}
+
+func TestLoadMaintenancePayloadSkipsNonSetupSource(t *testing.T) {
+	rec := setupMaintenanceTest(t)
+	m := InstallMeta{ProductName: rec.ProductName, Version: rec.Version}
+	// /SOURCE= 指向裸归档而不是 setup 时不使用
+	raw := filepath.Join(t.TempDir(), "payload.tar.gz")
+	writeTestFile(t, raw, string(testArchive(t, m, map[string]string{"app": "raw"})))
+	setTestSwitches(t, "/SOURCE="+raw)
+	writeTestFile(t, filepath.Join(rec.InstallDir, payloadCacheName), string(testArchive(t, m, map[string]string{"app": "cache"})))
+
+	files, err := loadMaintenancePayload(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := findFile(files, "app"); f == nil || string(f.Data) != "cache" {
+		t.Errorf("app = %v, want the cached copy", f)
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"exe_installer/installer/internal/version"
+)
+
+// prereqDir 归档中打包的前置组件安装程序所在目录；这些文件不会写入安装目录
+const prereqDir = "prereqs"
+
+const defaultPrereqTimeout = 30 * time.Minute
+
+// prereqSpec 与 meta.json 中 prerequisites 的元素对应
+type prereqSpec struct {
+	Name           string     `json:"name"`
+	Command        string     `json:"command"` // Bundled 时为归档内路径，否则为绝对路径或相对 setup 所在目录的路径
+	Bundled        bool       `json:"bundled"`
+	Args           []string   `json:"args"`
+	Detect         detectRule `json:"detect"`
+	SuccessCodes   []int      `json:"successCodes"`
+	RebootCodes    []int      `json:"rebootCodes"`
+	TimeoutSeconds int        `json:"timeoutSeconds"`
+	Optional       bool       `json:"optional"` // 安装失败时继续
+}
+
+// detectRule 判断前置组件是否已安装；设置的条件须全部满足。
+// MinVersion 与注册表值比较；未设置注册表时与文件版本比较（仅 Windows）。
+type detectRule struct {
+	File          string `json:"file"`        // 可使用 %ProgramFiles% 之类的环境变量
+	RegistryKey   string `json:"registryKey"` // 例如 `HKLM\SOFTWARE\Microsoft\VisualStudio\14.0\VC\Runtimes\x64`
+	RegistryValue string `json:"registryValue"`
+	MinVersion    string `json:"minVersion"`
+}
+
+// rebootRequired 前置组件安装程序要求重启
+var rebootRequired bool
+
+// installed 检测前置组件是否已安装；既没有 File 也没有 RegistryKey 的规则视为未安装
+func (r detectRule) installed() bool {
+	if r.File != "" {
+		p := expandEnv(r.File)
+		if !fileExists(p) {
+			return false
+		}
+		if r.MinVersion != "" && r.RegistryKey == "" {
+			if v := fileVersion(p); v == "" || version.CompareNumbers(v, r.MinVersion) < 0 {
+				return false
+			}
+		}
+	}
+	if r.RegistryKey != "" {
+		v, ok := registryString(r.RegistryKey, r.RegistryValue)
+		if !ok {
+			return false
+		}
+		if r.MinVersion != "" {
+			return version.CompareNumbers(v, r.MinVersion) >= 0
+		}
+		// 例如 VC++ 运行库的 Installed=1
+		return r.RegistryValue == "" || v != "0"
+	}
+	return r.File != ""
+}
+
+var envPattern = regexp.MustCompile(`%([^%]+)%`)
+
+// expandEnv 展开 %VAR% 形式的环境变量；未定义的变量与 $ 保持原样（Windows 路径与参数中 $ 是普通字符）
+func expandEnv(s string) string {
+	return envPattern.ReplaceAllStringFunc(s, func(m string) string {
+		if v, ok := os.LookupEnv(m[1 : len(m)-1]); ok {
+			return v
+		}
+		return m
+	})
+}
+
+// installPrerequisites 依次检测并安装缺少的前置组件
+func installPrerequisites(specs []prereqSpec, files []*inMemoryFile) error {
+	var tmpDir string
+	defer func() {
+		if tmpDir != "" {
+			_ = os.RemoveAll(tmpDir)
+		}
+	}()
+	for _, p := range specs {
+		if p.Detect.installed() {
+			logT("prereq.present", p.Name)
+			continue
+		}
+		logT("prereq.installing", p.Name)
+		command := p.Command
+		if p.Bundled {
+			f := findFile(files, p.Command)
+			if f == nil {
+				if err := prereqFailure(p, errors.New(T("prereq.missing_installer", p.Command))); err != nil {
+					return err
+				}
+				continue
+			}
+			if tmpDir == "" {
+				var err error
+				if tmpDir, err = os.MkdirTemp("", "prereq-"); err != nil {
+					return err
+				}
+			}
+			command = filepath.Join(tmpDir, path.Base(p.Command))
+			if err := os.WriteFile(command, f.Data, 0o755); err != nil {
+				return err
+			}
+		} else if !filepath.IsAbs(command) {
+			// 与 setup 同目录的安装程序
+			self, err := os.Executable()
+			if err != nil {
+				return err
+			}
+			command = filepath.Join(filepath.Dir(self), command)
+		}
+		if !fileExists(command) {
+			if err := prereqFailure(p, errors.New(T("prereq.missing_installer", command))); err != nil {
+				return err
+			}
+			continue
+		}
+
+		reboot, err := runPrerequisite(p, command)
+		if err != nil {
+			if err := prereqFailure(p, err); err != nil {
+				return err
+			}
+			continue
+		}
+		logT("prereq.done", p.Name)
+		if reboot {
+			rebootRequired = true
+			warnT("prereq.reboot", p.Name)
+		}
+	}
+	return nil
+}
+
+// prereqFailure 可选组件失败时仅记录，否则返回中止安装的错误
+func prereqFailure(p prereqSpec, err error) error {
+	if p.Optional {
+		warnT("prereq.failed_ignored", p.Name, err)
+		return nil
+	}
+	return errors.New(T("prereq.failed", p.Name, err))
+}
+
+// runPrerequisite 运行安装程序并按退出码判断结果
+func runPrerequisite(p prereqSpec, command string) (reboot bool, err error) {
+	timeout := defaultPrereqTimeout
+	if p.TimeoutSeconds > 0 {
+		timeout = time.Duration(p.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	cmd := exec.CommandContext(ctx, command, p.Args...)
+	cmd.WaitDelay = outputWaitDelay
+	cmd.Dir = filepath.Dir(command)
+	out, err := cmd.CombinedOutput()
+	for _, line := range strings.Split(strings.TrimRight(string(out), "\r\n"), "\n") {
+		if line != "" {
+			logf("  | %s", strings.TrimRight(line, "\r"))
+		}
+	}
+	runLog.write(logEntry{Level: levelInfo, Msg: "prerequisite " + p.Name + " exited", DurationMs: time.Since(start).Milliseconds()})
+	if ctx.Err() == context.DeadlineExceeded {
+		return false, errors.New(T("hook.timeout", timeout))
+	}
+	code := 0
+	var ee *exec.ExitError
+	if errors.As(err, &ee) {
+		code = ee.ExitCode()
+	} else if err != nil {
+		return false, err
+	}
+
+	success, rebootCodes := p.SuccessCodes, p.RebootCodes
+	if len(success) == 0 {
+		success = []int{0}
+	}
+	if len(rebootCodes) == 0 {
+		rebootCodes = defaultRebootCodes
+	}
+	switch {
+	case slices.Contains(success, code):
+		return false, nil
+	case slices.Contains(rebootCodes, code):
+		return true, nil
+	}
+	return false, errors.New(T("prereq.exit_code", code))
+}
+
+// splitPrereqFiles 将前置组件安装程序从待安装文件中分离出来
+func splitPrereqFiles(files []*inMemoryFile) (prereqs, rest []*inMemoryFile) {
+	for _, f := range files {
+		if strings.HasPrefix(f.Name, prereqDir+"/") {
+			prereqs = append(prereqs, f)
+		} else {
+			rest = append(rest, f)
+		}
+	}
+	return prereqs, rest
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package main
+
+// defaultRebootCodes 没有约定表示“需要重启”的退出码（3 之类的值通常表示失败），
+// 只认前置组件 RebootCodes 中明确声明的退出码
+var defaultRebootCodes []int
+
+// fileVersion 非 Windows 平台没有文件版本资源
+func fileVersion(path string) string { return "" }
+
+// registryString 非 Windows 平台没有注册表，按未安装处理
+func registryString(key, name string) (string, bool) { return "", false }
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("PREREQ_TEST_DIR", "/opt/x")
+	tests := []struct{ in, want string }{
+		{"%PREREQ_TEST_DIR%/bin", "/opt/x/bin"},
+		{"%PREREQ_UNDEFINED%/bin", "%PREREQ_UNDEFINED%/bin"},
+		{`C:\$Recycle.Bin\a$b`, `C:\$Recycle.Bin\a$b`},
+		{"$PREREQ_TEST_DIR", "$PREREQ_TEST_DIR"},
+	}
+	for _, tt := range tests {
+		if got := expandEnv(tt.in); got != tt.want {
+			t.Errorf("expandEnv(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDetectRuleInstalled(t *testing.T) {
+	dir := t.TempDir()
+	present := filepath.Join(dir, "present")
+	writeTestFile(t, present, "x")
+	tests := []struct {
+		name string
+		rule detectRule
+		want bool
+	}{
+		{"file present", detectRule{File: present}, true},
+		{"file missing", detectRule{File: filepath.Join(dir, "missing")}, false},
+		{"min version only", detectRule{MinVersion: "1.0"}, false},
+		{"empty", detectRule{}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.rule.installed(); got != tt.want {
+			t.Errorf("%s: installed() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRunPrerequisiteExitCodes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses /bin/sh")
+	}
+	setupTestInstall(t)
+	tests := []struct {
+		name    string
+		code    string
+		success []int
+		reboot  []int
+		want    bool
+		wantErr bool
+	}{
+		{"success", "0", nil, nil, false, false},
+		{"failure", "1", nil, nil, false, true},
+		// Linux 上没有默认的重启退出码，3 是失败
+		{"3 without reboot codes", "3", nil, nil, false, true},
+		{"declared reboot code", "3", nil, []int{3}, true, false},
+		{"declared success code", "2", []int{0, 2}, nil, false, false},
+		{"success codes replace 0", "0", []int{2}, nil, false, true},
+	}
+	for _, tt := range tests {
+		p := prereqSpec{Name: "runtime", Args: []string{"-c", "exit $((" + tt.code + "))"}, SuccessCodes: tt.success, RebootCodes: tt.reboot}
+		reboot, err := runPrerequisite(p, "/bin/sh")
+		if reboot != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("%s: runPrerequisite = %v, %v; want reboot %v, wantErr %v", tt.name, reboot, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+// defaultRebootCodes ERROR_SUCCESS_REBOOT_REQUIRED 与 ERROR_SUCCESS_REBOOT_INITIATED
+var defaultRebootCodes = []int{3010, 1641}
+
+// fileVersion 读取文件版本资源，返回 "a.b.c.d"；没有版本信息时为空
+func fileVersion(path string) string {
+	size, err := windows.GetFileVersionInfoSize(path, nil)
+	if err != nil || size == 0 {
+		return ""
+	}
+	buf := make([]byte, size)
+	if err := windows.GetFileVersionInfo(path, 0, size, unsafe.Pointer(&buf[0])); err != nil {
+		return ""
+	}
+	var info *windows.VS_FIXEDFILEINFO
+	var n uint32
+	if err := windows.VerQueryValue(unsafe.Pointer(&buf[0]), `\`, unsafe.Pointer(&info), &n); err != nil || info == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d.%d.%d.%d", info.FileVersionMS>>16, info.FileVersionMS&0xFFFF,
+		info.FileVersionLS>>16, info.FileVersionLS&0xFFFF)
+}
+
+// registryString 读取 "HKLM\..." 形式的键中的值（总是访问 64 位视图，32 位视图请写明 WOW6432Node）；
+// 数值类型转换为十进制字符串。name 为空时只判断键是否存在。
+func registryString(key, name string) (string, bool) {
+	root, sub, ok := splitRegistryRoot(key)
+	if !ok {
+		return "", false
+	}
+	k, err := registry.OpenKey(root, sub, registry.QUERY_VALUE|registry.WOW64_64KEY)
+	if err != nil {
+		return "", false
+	}
+	defer k.Close()
+	if name == "" {
+		return "", true
+	}
+	if s, _, err := k.GetStringValue(name); err == nil {
+		return s, true
+	}
+	if n, _, err := k.GetIntegerValue(name); err == nil {
+		return strconv.FormatUint(n, 10), true
+	}
+	return "", false
+}
+
+func splitRegistryRoot(key string) (registry.Key, string, bool) {
+	root, sub, _ := strings.Cut(key, `\`)
+	switch strings.ToUpper(root) {
+	case "HKLM", "HKEY_LOCAL_MACHINE":
+		return registry.LOCAL_MACHINE, sub, true
+	case "HKCU", "HKEY_CURRENT_USER":
+		return registry.CURRENT_USER, sub, true
+	case "HKCR", "HKEY_CLASSES_ROOT":
+		return registry.CLASSES_ROOT, sub, true
+	case "HKU", "HKEY_USERS":
+		return registry.USERS, sub, true
+	}
+	return 0, "", false
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+// processRunner 抽象子进程的启动与等待，自删除等流程只依赖该接口，
+// 便于在 Linux 上替换为假实现进行测试。
+type processRunner interface {
+	// Start 以分离方式启动进程，返回其 PID；不等待其结束
+	Start(path string, args []string) (int, error)
+	// Launch 在 c.Dir 中启动用户程序并返回其 PID，不等待其结束；
+	// c.AsUser 且当前进程已提权时以原始的非管理员用户身份运行
+	Launch(c launchCommand) (int, error)
+	// WaitExit 等待指定 PID 的进程退出；进程不存在视为已退出
+	WaitExit(pid int, timeout time.Duration) error
+}
+
+var errWaitTimeout = errors.New("wait for process timed out")
+
+// procRunner 当前使用的实现，默认为操作系统实现
+var procRunner processRunner = osProcessRunner{}
+
+type osProcessRunner struct{}
@@ -0,0 +1,113 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+func (osProcessRunner) Start(path string, args []string) (int, error) {
+	cmd := exec.Command(path, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	pid := cmd.Process.Pid
+	_ = cmd.Process.Release()
+	return pid, nil
+}
+
+// Launch 以 root 运行（sudo / pkexec）时切换回调用者的 uid、gid 与附加组，
+// 并将 HOME、USER、LOGNAME 改为该用户的，使程序的设置写入其用户目录
+func (osProcessRunner) Launch(c launchCommand) (int, error) {
+	cmd := exec.Command(c.Path, c.Args...)
+	cmd.Dir = c.Dir
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if c.AsUser && os.Geteuid() == 0 {
+		if u := invokingUser(); u != nil {
+			cred, err := userCredential(u)
+			if err != nil {
+				return 0, err
+			}
+			cmd.SysProcAttr.Credential = cred
+			cmd.Env = userEnv(os.Environ(), u)
+			logT("launch.as_user", u.Username)
+		}
+	}
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	pid := cmd.Process.Pid
+	_ = cmd.Process.Release()
+	return pid, nil
+}
+
+// invokingUser 返回通过 sudo 或 pkexec 提权前的用户；直接以 root 登录时返回 nil
+func invokingUser() *user.User {
+	for _, k := range []string{"SUDO_UID", "PKEXEC_UID"} {
+		if id := os.Getenv(k); id != "" && id != "0" {
+			if u, err := user.LookupId(id); err == nil {
+				return u
+			}
+		}
+	}
+	return nil
+}
+
+func userCredential(u *user.User) (*syscall.Credential, error) {
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	cred := &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}
+	if ids, err := u.GroupIds(); err == nil {
+		for _, id := range ids {
+			if g, err := strconv.ParseUint(id, 10, 32); err == nil {
+				cred.Groups = append(cred.Groups, uint32(g))
+			}
+		}
+	}
+	return cred, nil
+}
+
+// userEnv 替换环境中与用户身份相关的变量，去掉 sudo 留下的变量
+func userEnv(env []string, u *user.User) []string {
+	out := make([]string, 0, len(env)+3)
+	for _, kv := range env {
+		k, _, _ := strings.Cut(kv, "=")
+		switch {
+		case k == "HOME", k == "USER", k == "LOGNAME", strings.HasPrefix(k, "SUDO_"), k == "PKEXEC_UID":
+			continue
+		}
+		out = append(out, kv)
+	}
+	return append(out, "HOME="+u.HomeDir, "USER="+u.Username, "LOGNAME="+u.Username)
+}
+
+// WaitExit 通过 kill(pid, 0) 轮询进程是否仍存在
+func (osProcessRunner) WaitExit(pid int, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		if err := syscall.Kill(pid, 0); errors.Is(err, syscall.ESRCH) {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errWaitTimeout
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// deleteOnReboot 非 Windows 平台可直接删除运行中的文件
+func deleteOnReboot(path string) error { return os.RemoveAll(path) }
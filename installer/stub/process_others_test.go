@@ -0,0 +1,42 @@
+//go:build !windows
+
+package main
+
+import (
+	"os/user"
+	"reflect"
+	"testing"
+)
+
+func TestUserEnv(t *testing.T) {
+	u := &user.User{Username: "alice", HomeDir: "/home/alice"}
+	env := []string{"PATH=/usr/bin", "HOME=/root", "USER=root", "LOGNAME=root", "SUDO_USER=alice", "SUDO_UID=1000", "PKEXEC_UID=1000", "LANG=C"}
+	want := []string{"PATH=/usr/bin", "LANG=C", "HOME=/home/alice", "USER=alice", "LOGNAME=alice"}
+	if got := userEnv(env, u); !reflect.DeepEqual(got, want) {
+		t.Errorf("userEnv = %v, want %v", got, want)
+	}
+}
+
+func TestInvokingUser(t *testing.T) {
+	t.Setenv("SUDO_UID", "")
+	t.Setenv("PKEXEC_UID", "")
+	if u := invokingUser(); u != nil {
+		t.Errorf("no sudo: got %v", u.Username)
+	}
+	t.Setenv("SUDO_UID", "0")
+	if u := invokingUser(); u != nil {
+		t.Errorf("sudo from root: got %v, want nil", u.Username)
+	}
+	// 以 root 运行测试时改用 nobody
+	other, err := user.Current()
+	if err == nil && other.Uid == "0" {
+		other, err = user.LookupId("65534")
+	}
+	if err != nil {
+		t.Skip("needs a non-root user to look up")
+	}
+	t.Setenv("PKEXEC_UID", other.Uid)
+	if u := invokingUser(); u == nil || u.Uid != other.Uid {
+		t.Errorf("pkexec: got %v, want %s", u, other.Username)
+	}
+}
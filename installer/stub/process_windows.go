@@ -0,0 +1,141 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+func (osProcessRunner) Start(path string, args []string) (int, error) {
+	cmd := exec.Command(path, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		HideWindow:    true,
+		CreationFlags: windows.CREATE_NEW_PROCESS_GROUP | windows.DETACHED_PROCESS,
+	}
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	pid := cmd.Process.Pid
+	_ = cmd.Process.Release()
+	return pid, nil
+}
+
+var procCreateProcessWithTokenW = windows.NewLazySystemDLL("advapi32.dll").NewProc("CreateProcessWithTokenW")
+
+// Launch 未提权时直接启动；已提权时借用桌面外壳（explorer.exe）的令牌启动，
+// 程序以登录用户的非管理员身份运行。程序使用自己的控制台，且不隐藏窗口
+func (osProcessRunner) Launch(c launchCommand) (int, error) {
+	if c.AsUser && windows.GetCurrentProcessToken().IsElevated() {
+		return launchAsShellUser(c)
+	}
+	cmd := exec.Command(c.Path, c.Args...)
+	cmd.Dir = c.Dir
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: windows.CREATE_NEW_CONSOLE | windows.CREATE_NEW_PROCESS_GROUP,
+	}
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	pid := cmd.Process.Pid
+	_ = cmd.Process.Release()
+	return pid, nil
+}
+
+// launchAsShellUser 复制外壳进程的令牌并以 CreateProcessWithTokenW 启动（管理员具有所需的 SeImpersonatePrivilege）。
+// 没有外壳（例如通过远程会话或服务运行）时返回错误，而不是以管理员身份启动
+func launchAsShellUser(c launchCommand) (int, error) {
+	hwnd := windows.GetShellWindow()
+	if hwnd == 0 {
+		return 0, errors.New(T("launch.no_shell"))
+	}
+	var shellPID uint32
+	if _, err := windows.GetWindowThreadProcessId(hwnd, &shellPID); err != nil {
+		return 0, err
+	}
+	hp, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, shellPID)
+	if err != nil {
+		return 0, err
+	}
+	defer windows.CloseHandle(hp)
+	var shellToken windows.Token
+	if err := windows.OpenProcessToken(hp, windows.TOKEN_DUPLICATE, &shellToken); err != nil {
+		return 0, err
+	}
+	defer shellToken.Close()
+	var token windows.Token
+	access := uint32(windows.TOKEN_QUERY | windows.TOKEN_DUPLICATE | windows.TOKEN_ASSIGN_PRIMARY |
+		windows.TOKEN_ADJUST_DEFAULT | windows.TOKEN_ADJUST_SESSIONID)
+	if err := windows.DuplicateTokenEx(shellToken, access, nil, windows.SecurityImpersonation, windows.TokenPrimary, &token); err != nil {
+		return 0, err
+	}
+	defer token.Close()
+
+	// 使用该用户自己的环境变量（USERPROFILE、APPDATA 等）
+	var env *uint16
+	if err := windows.CreateEnvironmentBlock(&env, token, false); err == nil {
+		defer windows.DestroyEnvironmentBlock(env)
+	}
+	app, err := windows.UTF16PtrFromString(c.Path)
+	if err != nil {
+		return 0, err
+	}
+	cmdLine, err := windows.UTF16PtrFromString(windows.ComposeCommandLine(append([]string{c.Path}, c.Args...)))
+	if err != nil {
+		return 0, err
+	}
+	var dir *uint16
+	if c.Dir != "" {
+		if dir, err = windows.UTF16PtrFromString(c.Dir); err != nil {
+			return 0, err
+		}
+	}
+	si := windows.StartupInfo{Cb: uint32(unsafe.Sizeof(windows.StartupInfo{}))}
+	var pi windows.ProcessInformation
+	flags := windows.CREATE_NEW_CONSOLE | windows.CREATE_NEW_PROCESS_GROUP | windows.CREATE_UNICODE_ENVIRONMENT
+	r, _, err := procCreateProcessWithTokenW.Call(uintptr(token), 0,
+		uintptr(unsafe.Pointer(app)), uintptr(unsafe.Pointer(cmdLine)), uintptr(flags),
+		uintptr(unsafe.Pointer(env)), uintptr(unsafe.Pointer(dir)),
+		uintptr(unsafe.Pointer(&si)), uintptr(unsafe.Pointer(&pi)))
+	if r == 0 {
+		return 0, err
+	}
+	windows.CloseHandle(pi.Thread)
+	windows.CloseHandle(pi.Process)
+	logT("launch.as_shell_user")
+	return int(pi.ProcessId), nil
+}
+
+// WaitExit 打开进程句柄并 WaitForSingleObject；OpenProcess 失败说明进程已不存在
+func (osProcessRunner) WaitExit(pid int, timeout time.Duration) error {
+	h, err := windows.OpenProcess(windows.SYNCHRONIZE, false, uint32(pid))
+	if err != nil {
+		if errors.Is(err, windows.ERROR_INVALID_PARAMETER) {
+			return nil
+		}
+		return err
+	}
+	defer windows.CloseHandle(h)
+	ev, err := windows.WaitForSingleObject(h, uint32(timeout/time.Millisecond))
+	if err != nil {
+		return err
+	}
+	if ev == uint32(windows.WAIT_TIMEOUT) {
+		return errWaitTimeout
+	}
+	return nil
+}
+
+// deleteOnReboot 运行中的 exe 无法删除，登记为重启后删除（需要管理员权限）
+func deleteOnReboot(path string) error {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return err
+	}
+	return windows.MoveFileEx(p, nil, windows.MOVEFILE_DELAY_UNTIL_REBOOT)
+}
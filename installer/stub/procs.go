@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// processInfo 描述一个正在运行的进程
+type processInfo struct {
+	PID     int
+	ExePath string // 映像文件绝对路径；无权限读取时为空
+}
+
+// processLister 枚举并关闭进程的抽象。Windows 使用 Toolhelp 快照，
+// 其余平台读取 /proc，便于在 Linux 上测试“检测安装目录中运行的程序”流程。
+type processLister interface {
+	List() ([]processInfo, error)
+	// RequestClose 请求进程正常退出（Windows 向其窗口发送 WM_CLOSE，Linux 发送 SIGTERM）
+	RequestClose(pid int) error
+	// Terminate 强制结束进程
+	Terminate(pid int) error
+}
+
+var procLister processLister = osProcessLister{}
+
+type osProcessLister struct{}
+
+const defaultCloseAppsTimeout = 15 * time.Second
+
+// processesInDir 返回映像位于 dir 下的进程（排除当前进程）
+func processesInDir(dir string) ([]processInfo, error) {
+	all, err := procLister.List()
+	if err != nil {
+		return nil, err
+	}
+	prefix := filepath.Clean(dir) + string(filepath.Separator)
+	self := os.Getpid()
+	var out []processInfo
+	for _, p := range all {
+		if p.PID == self || p.ExePath == "" {
+			continue
+		}
+		if pathHasPrefix(filepath.Clean(p.ExePath), prefix) {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func pathHasPrefix(p, prefix string) bool {
+	if runtime.GOOS == "windows" {
+		return strings.HasPrefix(strings.ToLower(p), strings.ToLower(prefix))
+	}
+	return strings.HasPrefix(p, prefix)
+}
+
+// closeRunningInstances 检测安装目录中正在运行的程序并关闭它们。
+// 静默模式下先请求退出，超时后强制结束；交互模式下提示用户关闭、强制关闭或忽略。
+// 返回仍在运行的进程，调用方对被占用文件回退到重启后替换。
+func closeRunningInstances(dir string, timeout time.Duration) []processInfo {
+	procs, err := processesInDir(dir)
+	if err != nil {
+		warnT("procs.list_failed", err)
+		return nil
+	}
+	if len(procs) == 0 {
+		return nil
+	}
+	if timeout <= 0 {
+		timeout = defaultCloseAppsTimeout
+	}
+	for {
+		var list strings.Builder
+		for _, p := range procs {
+			fmt.Fprintf(&list, "\n  [%d] %s", p.PID, p.ExePath)
+		}
+		// 静默模式直接强制关闭
+		choice := ui.Choose(T("procs.running", list.String()),
+			[]string{T("procs.retry"), T("procs.force"), T("procs.ignore")}, 1)
+		switch choice {
+		case 1:
+			procs = stopProcesses(procs, timeout)
+		case 2:
+			return procs
+		default:
+			if procs, err = processesInDir(dir); err != nil {
+				return nil
+			}
+		}
+		if len(procs) == 0 {
+			logT("procs.all_closed")
+			return nil
+		}
+		if silentMode() {
+			return procs
+		}
+	}
+}
+
+// stopProcesses 先请求退出并等待 timeout，仍未退出则强制结束；返回无法结束的进程
+func stopProcesses(procs []processInfo, timeout time.Duration) []processInfo {
+	for _, p := range procs {
+		_ = procLister.RequestClose(p.PID)
+	}
+	deadline := time.Now().Add(timeout)
+	var left []processInfo
+	for _, p := range procs {
+		wait := time.Until(deadline)
+		if wait < 0 {
+			wait = 0
+		}
+		if procRunner.WaitExit(p.PID, wait) == nil {
+			continue
+		}
+		warnT("procs.not_responding", p.PID)
+		if err := procLister.Terminate(p.PID); err != nil {
+			warnT("procs.terminate_failed", p.PID, err)
+			left = append(left, p)
+			continue
+		}
+		if procRunner.WaitExit(p.PID, 5*time.Second) != nil {
+			left = append(left, p)
+		}
+	}
+	return left
+}
@@ -0,0 +1,43 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+// List 读取 /proc/<pid>/exe；无权限的进程 ExePath 为空
+func (osProcessLister) List() ([]processInfo, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	var out []processInfo
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil || !e.IsDir() {
+			continue
+		}
+		exe, _ := os.Readlink(filepath.Join("/proc", e.Name(), "exe"))
+		out = append(out, processInfo{PID: pid, ExePath: exe})
+	}
+	return out, nil
+}
+
+func (osProcessLister) RequestClose(pid int) error { return syscall.Kill(pid, syscall.SIGTERM) }
+func (osProcessLister) Terminate(pid int) error    { return syscall.Kill(pid, syscall.SIGKILL) }
+
+// isFileLocked 正在运行的可执行文件无法以写方式打开（ETXTBSY）
+func isFileLocked(err error) bool { return errors.Is(err, syscall.ETXTBSY) }
+
+// replaceLockedFile 非 Windows 平台可删除被占用的文件后重新创建，无需重启
+func replaceLockedFile(dest string, data []byte, mode os.FileMode) (bool, error) {
+	if err := os.Remove(dest); err != nil {
+		return false, err
+	}
+	return false, os.WriteFile(dest, data, mode)
+}
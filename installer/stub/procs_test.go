@@ -0,0 +1,179 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// fakeProcesses 模拟一组进程：同时实现 processLister 与 processRunner 的等待，
+// 请求退出或强制结束后进程是否消失由 ignoresClose 与 unkillable 决定；
+// closedByUser 中的进程在第一次枚举之后退出，模拟用户在提示期间自行关闭程序
+type fakeProcesses struct {
+	procs        []processInfo
+	ignoresClose map[int]bool
+	unkillable   map[int]bool
+	closedByUser map[int]bool
+	listed       int
+	calls        []string
+}
+
+func newFakeProcesses(t *testing.T, procs ...processInfo) *fakeProcesses {
+	f := &fakeProcesses{procs: procs, ignoresClose: map[int]bool{}, unkillable: map[int]bool{}, closedByUser: map[int]bool{}}
+	oldLister, oldRunner := procLister, procRunner
+	procLister, procRunner = f, f
+	t.Cleanup(func() { procLister, procRunner = oldLister, oldRunner })
+	return f
+}
+
+func (f *fakeProcesses) List() ([]processInfo, error) {
+	if f.listed++; f.listed > 1 {
+		for pid := range f.closedByUser {
+			f.exit(pid)
+		}
+	}
+	return append([]processInfo(nil), f.procs...), nil
+}
+
+func (f *fakeProcesses) exit(pid int) {
+	for i, p := range f.procs {
+		if p.PID == pid {
+			f.procs = append(f.procs[:i], f.procs[i+1:]...)
+			return
+		}
+	}
+}
+
+func (f *fakeProcesses) RequestClose(pid int) error {
+	f.calls = append(f.calls, "close "+strconv.Itoa(pid))
+	if !f.ignoresClose[pid] {
+		f.exit(pid)
+	}
+	return nil
+}
+
+func (f *fakeProcesses) Terminate(pid int) error {
+	f.calls = append(f.calls, "kill "+strconv.Itoa(pid))
+	if f.unkillable[pid] {
+		return errors.New("access denied")
+	}
+	f.exit(pid)
+	return nil
+}
+
+func (f *fakeProcesses) WaitExit(pid int, timeout time.Duration) error {
+	for _, p := range f.procs {
+		if p.PID == pid {
+			return errWaitTimeout
+		}
+	}
+	return nil
+}
+
+func (f *fakeProcesses) Start(string, []string) (int, error) { return 0, errors.New("not supported") }
+func (f *fakeProcesses) Launch(launchCommand) (int, error)   { return 0, errors.New("not supported") }
+
+func pids(procs []processInfo) []int {
+	var out []int
+	for _, p := range procs {
+		out = append(out, p.PID)
+	}
+	return out
+}
+
+func TestProcessesInDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "app")
+	newFakeProcesses(t,
+		processInfo{PID: 10, ExePath: filepath.Join(dir, "app")},
+		processInfo{PID: 11, ExePath: filepath.Join(dir, "bin", "helper")},
+		processInfo{PID: 12, ExePath: dir + "-other/app"},
+		processInfo{PID: 13, ExePath: ""},
+	)
+	got, err := processesInDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{10, 11}; !reflect.DeepEqual(pids(got), want) {
+		t.Errorf("processesInDir = %v, want %v", pids(got), want)
+	}
+}
+
+func TestStopProcesses(t *testing.T) {
+	f := newFakeProcesses(t,
+		processInfo{PID: 1, ExePath: "/a"},
+		processInfo{PID: 2, ExePath: "/b"},
+		processInfo{PID: 3, ExePath: "/c"},
+	)
+	f.ignoresClose[2] = true
+	f.ignoresClose[3] = true
+	f.unkillable[3] = true
+
+	left := stopProcesses(append([]processInfo(nil), f.procs...), time.Millisecond)
+	if want := []int{3}; !reflect.DeepEqual(pids(left), want) {
+		t.Errorf("left = %v, want %v", pids(left), want)
+	}
+	want := []string{"close 1", "close 2", "close 3", "kill 2", "kill 3"}
+	if !reflect.DeepEqual(f.calls, want) {
+		t.Errorf("calls = %v, want %v", f.calls, want)
+	}
+}
+
+func TestCloseRunningInstances(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "app")
+	exe := filepath.Join(dir, "app")
+	oldUI := ui
+	t.Cleanup(func() { ui = oldUI })
+
+	t.Run("silent force", func(t *testing.T) {
+		setTestSwitches(t, "/S")
+		ui = &consoleUI{silent: true}
+		f := newFakeProcesses(t, processInfo{PID: 20, ExePath: exe}, processInfo{PID: 21, ExePath: "/usr/bin/other"})
+		f.ignoresClose[20] = true
+		if left := closeRunningInstances(dir, time.Millisecond); len(left) != 0 {
+			t.Errorf("left = %v, want none", pids(left))
+		}
+		if want := []string{"close 20", "kill 20"}; !reflect.DeepEqual(f.calls, want) {
+			t.Errorf("calls = %v, want %v", f.calls, want)
+		}
+	})
+
+	t.Run("silent unkillable", func(t *testing.T) {
+		setTestSwitches(t, "/S")
+		ui = &consoleUI{silent: true}
+		f := newFakeProcesses(t, processInfo{PID: 20, ExePath: exe})
+		f.ignoresClose[20], f.unkillable[20] = true, true
+		if left := closeRunningInstances(dir, time.Millisecond); !reflect.DeepEqual(pids(left), []int{20}) {
+			t.Errorf("left = %v, want [20]", pids(left))
+		}
+	})
+
+	t.Run("interactive ignore", func(t *testing.T) {
+		setTestSwitches(t)
+		setTestStdin(t, "3\n")
+		ui = &consoleUI{}
+		f := newFakeProcesses(t, processInfo{PID: 20, ExePath: exe})
+		if left := closeRunningInstances(dir, time.Millisecond); !reflect.DeepEqual(pids(left), []int{20}) {
+			t.Errorf("left = %v, want [20]", pids(left))
+		}
+		if len(f.calls) != 0 {
+			t.Errorf("calls = %v, want none", f.calls)
+		}
+	})
+
+	t.Run("interactive retry after the user closed it", func(t *testing.T) {
+		setTestSwitches(t)
+		setTestStdin(t, "1\n")
+		ui = &consoleUI{}
+		f := newFakeProcesses(t, processInfo{PID: 20, ExePath: exe})
+		f.closedByUser[20] = true
+		if left := closeRunningInstances(dir, time.Millisecond); len(left) != 0 {
+			t.Errorf("left = %v, want none", pids(left))
+		}
+		if len(f.calls) != 0 {
+			t.Errorf("calls = %v, want none", f.calls)
+		}
+	})
+}
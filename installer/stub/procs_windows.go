@@ -0,0 +1,106 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var procPostMessageW = windows.NewLazySystemDLL("user32.dll").NewProc("PostMessageW")
+
+const wmClose = 0x0010
+
+// List 使用 Toolhelp 快照枚举进程，并通过 QueryFullProcessImageName 获取映像路径
+func (osProcessLister) List() ([]processInfo, error) {
+	snap, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer windows.CloseHandle(snap)
+
+	var out []processInfo
+	var pe windows.ProcessEntry32
+	pe.Size = uint32(unsafe.Sizeof(pe))
+	for err = windows.Process32First(snap, &pe); err == nil; err = windows.Process32Next(snap, &pe) {
+		out = append(out, processInfo{PID: int(pe.ProcessID), ExePath: processImagePath(pe.ProcessID)})
+	}
+	if !errors.Is(err, windows.ERROR_NO_MORE_FILES) {
+		return nil, err
+	}
+	return out, nil
+}
+
+func processImagePath(pid uint32) string {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, pid)
+	if err != nil {
+		return ""
+	}
+	defer windows.CloseHandle(h)
+	buf := make([]uint16, windows.MAX_LONG_PATH)
+	size := uint32(len(buf))
+	if err := windows.QueryFullProcessImageName(h, 0, &buf[0], &size); err != nil {
+		return ""
+	}
+	return windows.UTF16ToString(buf[:size])
+}
+
+// closeWindowsCallback 向 closeWindowsPID 的窗口发送 WM_CLOSE。syscall.NewCallback 创建的回调
+// 不会释放且总数有限，因此只创建一次，目标 PID 通过 closeWindowsMu 保护的变量传入。
+var (
+	closeWindowsMu       sync.Mutex
+	closeWindowsPID      uint32
+	closeWindowsCallback = syscall.NewCallback(func(hwnd windows.HWND, _ uintptr) uintptr {
+		var owner uint32
+		if _, err := windows.GetWindowThreadProcessId(hwnd, &owner); err == nil && owner == closeWindowsPID {
+			procPostMessageW.Call(uintptr(hwnd), wmClose, 0, 0)
+		}
+		return 1 // 继续枚举
+	})
+)
+
+// RequestClose 向进程的所有顶层窗口发送 WM_CLOSE
+func (osProcessLister) RequestClose(pid int) error {
+	closeWindowsMu.Lock()
+	defer closeWindowsMu.Unlock()
+	closeWindowsPID = uint32(pid)
+	return windows.EnumWindows(closeWindowsCallback, nil)
+}
+
+func (osProcessLister) Terminate(pid int) error {
+	h, err := windows.OpenProcess(windows.PROCESS_TERMINATE, false, uint32(pid))
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(h)
+	return windows.TerminateProcess(h, 1)
+}
+
+func isFileLocked(err error) bool {
+	return errors.Is(err, windows.ERROR_SHARING_VIOLATION) || errors.Is(err, windows.ERROR_LOCK_VIOLATION)
+}
+
+// replaceLockedFile 将新内容写入 <dest>.new，并登记在下次重启时替换 dest
+func replaceLockedFile(dest string, data []byte, mode os.FileMode) (bool, error) {
+	pending := dest + ".new"
+	if err := os.WriteFile(pending, data, mode); err != nil {
+		return false, err
+	}
+	from, err := windows.UTF16PtrFromString(pending)
+	if err != nil {
+		return false, err
+	}
+	to, err := windows.UTF16PtrFromString(dest)
+	if err != nil {
+		return false, err
+	}
+	if err := windows.MoveFileEx(from, to, windows.MOVEFILE_DELAY_UNTIL_REBOOT|windows.MOVEFILE_REPLACE_EXISTING); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// recordFileName 安装记录文件名，与卸载程序一同位于安装目录
+const recordFileName = "install-record.json"
+
+// installRecord 安装时写入的记录；卸载程序据此定位产品名、快捷方式与已安装文件，
+// 而不是从目录名推断。
+type installRecord struct {
+	ProductName  string     `json:"productName"`
+	Version      string     `json:"version"`
+	InstallDir   string     `json:"installDir"`
+	ExePath      string     `json:"exePath"`
+	ShortcutName string     `json:"shortcutName"`
+	Shortcuts    []string   `json:"shortcuts,omitempty"` // 已创建的快捷方式绝对路径
+	Files        []string   `json:"files,omitempty"`     // 相对安装目录的文件路径
+	InstalledAt  string     `json:"installedAt"`
+	Hooks        []hookSpec `json:"hooks,omitempty"`    // pre/post-uninstall 钩子
+	Language     string     `json:"language,omitempty"` // 安装时使用的界面语言
+
+	EntryPoints map[string]string `json:"entryPoints,omitempty"` // 入口名称 -> 相对安装目录的文件
+	Digests     map[string]string `json:"digests,omitempty"`     // 相对安装目录的文件 -> SHA-256，修复时校验
+	Arch        string            `json:"arch,omitempty"`        // 通用 setup 安装的主程序架构
+	SetupPath   string            `json:"setupPath,omitempty"`   // 安装时运行的 setup，修复与修改时作为载荷来源之一
+
+	Components        []string         `json:"components,omitempty"`        // 已选择的组件
+	OfferedComponents []string         `json:"offeredComponents,omitempty"` // 安装时提供的全部组件，用于区分升级后新增的组件
+	Registry          []registryValue  `json:"registry,omitempty"`          // 组件写入的注册表值
+	Environment       []envChange      `json:"environment,omitempty"`       // 已修改的环境变量，卸载时精确撤销
+	Services          []string         `json:"services,omitempty"`          // 已注册的服务，按注册顺序
+	Autostart         []autostartEntry `json:"autostart,omitempty"`         // 已创建的自动启动项
+	AutostartDeclined bool             `json:"autostartDeclined,omitempty"` // 用户未选择自动启动，升级时沿用
+}
+
+func (r *installRecord) hasComponent(name string) bool {
+	for _, n := range r.Components {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func newInstallRecord(m InstallMeta, installDir, exePath string, files []*inMemoryFile) *installRecord {
+	rec := &installRecord{
+		ProductName:  m.ProductName,
+		Version:      m.Version,
+		InstallDir:   installDir,
+		ExePath:      exePath,
+		ShortcutName: m.ShortcutName,
+		InstalledAt:  time.Now().Format(time.RFC3339),
+		Language:     lang,
+		EntryPoints:  entryPoints(m),
+		Digests:      map[string]string{},
+		SetupPath:    payloadSource,
+	}
+	if len(m.Arches) > 0 {
+		rec.Arch = m.Arch
+	}
+	if rec.ShortcutName == "" {
+		rec.ShortcutName = m.ProductName
+	}
+	for _, f := range files {
+		if strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		rec.Files = append(rec.Files, filepath.ToSlash(f.Name))
+		sum := sha256.Sum256(f.Data)
+		rec.Digests[filepath.ToSlash(f.Name)] = hex.EncodeToString(sum[:])
+	}
+	return rec
+}
+
+func saveInstallRecord(rec *installRecord) error {
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(rec.InstallDir, recordFileName), data, 0o644)
+}
+
+func loadInstallRecord(installDir string) (*installRecord, error) {
+	data, err := os.ReadFile(filepath.Join(installDir, recordFileName))
+	if err != nil {
+		return nil, err
+	}
+	var rec installRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, err
+	}
+	// 以实际所在目录为准（目录可能被整体移动过）
+	rec.InstallDir = installDir
+	return &rec, nil
+}
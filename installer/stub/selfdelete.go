@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// scheduleSelfDelete 卸载程序无法删除正在运行的自身：将自身复制到临时目录，
+// 以 /FINISHUNINSTALL=<安装目录> /PARENTPID=<pid> 启动该副本，由副本等待当前进程退出后收尾。
+func scheduleSelfDelete(exePath, installDir string) error {
+	tmpDir, err := os.MkdirTemp("", "uninst-")
+	if err != nil {
+		return err
+	}
+	tmpExe := filepath.Join(tmpDir, filepath.Base(exePath))
+	if err := copyFile(exePath, tmpExe); err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return fmt.Errorf("copy uninstaller: %w", err)
+	}
+	args := []string{
+		"/FINISHUNINSTALL=" + installDir,
+		"/PARENTPID=" + strconv.Itoa(os.Getpid()),
+	}
+	if p := runLog.Path(); p != "" {
+		args = append(args, "/LOG="+p)
+	}
+	if _, err := procRunner.Start(tmpExe, args); err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return fmt.Errorf("start %s: %w", tmpExe, err)
+	}
+	return nil
+}
+
+// runFinishUninstall 运行于临时副本 self 中：等待父进程退出，删除其留下的卸载程序与（已清空的）安装目录，
+// 最后安排删除临时副本自身。只做非递归删除，避免误传参数时删除无关内容。
+func runFinishUninstall(installDir, self string) {
+	if v, ok := switchValue("PARENTPID"); ok {
+		if pid, err := strconv.Atoi(v); err == nil && pid > 0 {
+			if err := procRunner.WaitExit(pid, 30*time.Second); err != nil {
+				warnT("uninstall.wait_parent_failed", err)
+			}
+		}
+	}
+
+	leftover := filepath.Join(installDir, uninstallerName())
+	for attempt := 0; attempt < 10; attempt++ {
+		_ = os.Remove(leftover)
+		err := os.Remove(installDir)
+		if err == nil || os.IsNotExist(err) {
+			logFileOp("remove", installDir, nil)
+			break
+		}
+		logFileOp("remove", installDir, err)
+		// 文件句柄可能尚未完全释放，稍后重试
+		time.Sleep(time.Duration(attempt+1) * 200 * time.Millisecond)
+	}
+
+	if self != "" {
+		_ = deleteOnReboot(self)
+		_ = deleteOnReboot(filepath.Dir(self))
+	}
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o755)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
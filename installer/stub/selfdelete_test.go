@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// fakeProcessRunner 记录启动与等待的进程，不真正运行任何程序
+type fakeProcessRunner struct {
+	started  [][]string // 每项为 path 后接参数
+	launched []launchCommand
+	waited   []int
+	err      error
+}
+
+func newFakeRunner(t *testing.T) *fakeProcessRunner {
+	f := &fakeProcessRunner{}
+	old := procRunner
+	procRunner = f
+	t.Cleanup(func() { procRunner = old })
+	return f
+}
+
+func (f *fakeProcessRunner) Start(path string, args []string) (int, error) {
+	f.started = append(f.started, append([]string{path}, args...))
+	return 4242, f.err
+}
+
+func (f *fakeProcessRunner) Launch(c launchCommand) (int, error) {
+	f.launched = append(f.launched, c)
+	return 4243, f.err
+}
+
+func (f *fakeProcessRunner) WaitExit(pid int, timeout time.Duration) error {
+	f.waited = append(f.waited, pid)
+	return f.err
+}
+
+// setTestSwitches 以 args 作为命令行开关
+func setTestSwitches(t *testing.T, args ...string) {
+	t.Helper()
+	old := switches
+	switches = parseSwitches(args)
+	t.Cleanup(func() { switches = old })
+}
+
+func TestScheduleSelfDelete(t *testing.T) {
+	setTestSwitches(t)
+	f := newFakeRunner(t)
+	installDir := t.TempDir()
+	exe := filepath.Join(installDir, uninstallerName())
+	writeTestFile(t, exe, "uninstaller")
+
+	if err := scheduleSelfDelete(exe, installDir); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.started) != 1 {
+		t.Fatalf("started = %v, want one process", f.started)
+	}
+	copyPath := f.started[0][0]
+	t.Cleanup(func() { os.RemoveAll(filepath.Dir(copyPath)) })
+	if filepath.Dir(copyPath) == installDir || filepath.Base(copyPath) != uninstallerName() {
+		t.Errorf("copy path = %q, want a temp copy named %s", copyPath, uninstallerName())
+	}
+	if got := readTestFile(t, copyPath); got != "uninstaller" {
+		t.Errorf("copy content = %q", got)
+	}
+	wantArgs := []string{"/FINISHUNINSTALL=" + installDir, "/PARENTPID=" + strconv.Itoa(os.Getpid())}
+	if got := f.started[0][1:3]; !reflect.DeepEqual(got, wantArgs) {
+		t.Errorf("args = %v, want %v", got, wantArgs)
+	}
+}
+
+func TestScheduleSelfDeleteStartFails(t *testing.T) {
+	f := newFakeRunner(t)
+	f.err = errors.New("boom")
+	installDir := t.TempDir()
+	exe := filepath.Join(installDir, uninstallerName())
+	writeTestFile(t, exe, "uninstaller")
+
+	if err := scheduleSelfDelete(exe, installDir); err == nil {
+		t.Fatal("want error")
+	}
+	if _, err := os.Stat(filepath.Dir(f.started[0][0])); !os.IsNotExist(err) {
+		t.Errorf("temp copy not cleaned up: %v", err)
+	}
+}
+
+func TestRunFinishUninstall(t *testing.T) {
+	setTestSwitches(t, "/FINISHUNINSTALL=x", "/PARENTPID=1234")
+	f := newFakeRunner(t)
+	installDir := filepath.Join(t.TempDir(), "app")
+	writeTestFile(t, filepath.Join(installDir, uninstallerName()), "uninstaller")
+	self := filepath.Join(t.TempDir(), "uninst-1", uninstallerName())
+	writeTestFile(t, self, "copy")
+
+	runFinishUninstall(installDir, self)
+	if !reflect.DeepEqual(f.waited, []int{1234}) {
+		t.Errorf("waited = %v, want [1234]", f.waited)
+	}
+	if _, err := os.Stat(installDir); !os.IsNotExist(err) {
+		t.Errorf("install dir not removed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Dir(self)); !os.IsNotExist(err) {
+		t.Errorf("temp copy not removed: %v", err)
+	}
+}
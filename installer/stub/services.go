@@ -0,0 +1,188 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+// 服务的启动类型，对应 meta 中 services 的 startType
+const (
+	serviceAuto     = "auto" // 默认：随系统（Linux 为用户会话）启动
+	serviceDelayed  = "delayed"
+	serviceManual   = "manual"
+	serviceDisabled = "disabled"
+)
+
+// 服务失败后的恢复操作
+const (
+	recoveryNone    = "none"
+	recoveryRestart = "restart"
+	recoveryReboot  = "reboot"
+)
+
+// serviceStopTimeout 替换文件前等待服务停止的最长时间
+const serviceStopTimeout = 30 * time.Second
+
+// serviceSpec 与 meta.json 中 services 的元素对应
+type serviceSpec struct {
+	Name               string         `json:"name"`
+	DisplayName        string         `json:"displayName"`
+	Description        string         `json:"description"`
+	EntryPoint         string         `json:"entryPoint"` // 为空时为主入口
+	Args               []string       `json:"args"`
+	StartType          string         `json:"startType"`
+	Account            string         `json:"account"` // Windows 运行账户，为空时为 LocalSystem
+	Dependencies       []string       `json:"dependencies"`
+	Recovery           []recoverySpec `json:"recovery"` // 依次对应第一次、第二次与之后的失败
+	ResetPeriodSeconds int            `json:"resetPeriodSeconds"`
+	NoStart            bool           `json:"noStart"` // 安装后不启动
+}
+
+type recoverySpec struct {
+	Action       string `json:"action"`
+	DelaySeconds int    `json:"delaySeconds"`
+}
+
+// serviceConfig 已解析出可执行文件绝对路径、替换了参数占位符的服务配置
+type serviceConfig struct {
+	serviceSpec
+	Path string
+	Dir  string // 工作目录，即安装目录
+}
+
+// serviceManager 抽象服务的注册与启停：Windows 为服务控制管理器，其他平台为 systemd --user 单元。
+// 安装流程只依赖该接口，便于在 Linux 上替换为假实现验证调用顺序。
+type serviceManager interface {
+	// Install 创建服务，已存在时更新其配置
+	Install(c serviceConfig) error
+	// Start 启动服务，已在运行视为成功
+	Start(name string) error
+	// Stop 停止服务并等待其停止，返回停止前是否在运行；服务不存在视为未运行
+	Stop(name string, timeout time.Duration) (bool, error)
+	// Remove 停止并删除服务，不存在视为成功
+	Remove(name string) error
+}
+
+// services 当前使用的实现，默认为操作系统实现
+var services serviceManager = osServiceManager{}
+
+type osServiceManager struct{}
+
+// serviceNames meta 中声明的与上次安装记录中的服务名，去重
+func serviceNames(m InstallMeta, prev *installRecord) []string {
+	var names []string
+	for _, s := range m.Services {
+		names = append(names, s.Name)
+	}
+	if prev != nil {
+		for _, n := range prev.Services {
+			if !slices.Contains(names, n) {
+				names = append(names, n)
+			}
+		}
+	}
+	return names
+}
+
+// stopServices 替换文件前停止服务，返回原先在运行的服务，失败时据此恢复
+func stopServices(names []string) []string {
+	var running []string
+	for _, n := range names {
+		was, err := services.Stop(n, serviceStopTimeout)
+		if err != nil {
+			warnT("service.stop_failed", n, err)
+			continue
+		}
+		if was {
+			logT("service.stopped", n)
+			running = append(running, n)
+		}
+	}
+	return running
+}
+
+// startServices 启动服务，失败时只记录警告
+func startServices(names []string) {
+	for _, n := range names {
+		if err := services.Start(n); err != nil {
+			warnT("service.start_failed", n, err)
+			continue
+		}
+		logT("service.started", n)
+	}
+}
+
+// buildServiceConfig 解析服务的入口与参数
+func buildServiceConfig(m InstallMeta, s serviceSpec, hc hookContext) (serviceConfig, error) {
+	path, err := entryPointPath(m, s.EntryPoint, hc.InstallDir)
+	if err != nil {
+		return serviceConfig{}, err
+	}
+	c := serviceConfig{serviceSpec: s, Path: path, Dir: hc.InstallDir}
+	c.Args = nil
+	for _, a := range s.Args {
+		c.Args = append(c.Args, hc.expand(a))
+	}
+	if c.DisplayName == "" {
+		c.DisplayName = s.Name
+	}
+	return c, nil
+}
+
+// registerServices 在新文件写入后创建或更新服务并写入安装记录，
+// 删除上次安装有而本次不再声明的服务，然后启动服务（NoStart 的服务仅在替换文件前正在运行时重新启动）
+func registerServices(m InstallMeta, hc hookContext, rec, prev *installRecord, stopped []string) error {
+	var errs []string
+	var registered []string
+	for _, s := range m.Services {
+		c, err := buildServiceConfig(m, s, hc)
+		if err != nil {
+			errs = append(errs, s.Name+": "+err.Error())
+			continue
+		}
+		// 注册失败也写入记录：可能已部分创建（如单元文件已写入），卸载时 Remove 会忽略不存在的服务
+		rec.Services = append(rec.Services, s.Name)
+		if err := services.Install(c); err != nil {
+			errs = append(errs, s.Name+": "+err.Error())
+			continue
+		}
+		logT("service.registered", s.Name)
+		registered = append(registered, s.Name)
+	}
+	if prev != nil {
+		for _, n := range prev.Services {
+			if !slices.ContainsFunc(m.Services, func(s serviceSpec) bool { return s.Name == n }) {
+				removeService(n)
+			}
+		}
+	}
+	var start []string
+	for _, s := range m.Services {
+		wanted := !s.NoStart || slices.Contains(stopped, s.Name)
+		if wanted && s.StartType != serviceDisabled && slices.Contains(registered, s.Name) {
+			start = append(start, s.Name)
+		}
+	}
+	startServices(start)
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// removeServices 卸载时按注册的逆序停止并删除服务
+func removeServices(rec *installRecord) {
+	for i := len(rec.Services) - 1; i >= 0; i-- {
+		removeService(rec.Services[i])
+	}
+}
+
+func removeService(name string) {
+	if err := services.Remove(name); err != nil {
+		warnT("service.remove_failed", name, err)
+		return
+	}
+	logT("service.removed", name)
+}
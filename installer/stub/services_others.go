@@ -0,0 +1,142 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 非 Windows 平台以 systemd --user 单元实现服务：单元文件写入 ~/.config/systemd/user/<名称>.service，
+// 随用户会话启动（需要 loginctl enable-linger 才能在未登录时运行）。Account 被忽略；
+// delayed 与 auto 相同；恢复操作只支持第一次失败的 restart。
+
+func unitName(name string) string { return name + ".service" }
+
+func unitPath(name string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "systemd", "user", unitName(name)), nil
+}
+
+// systemctl 运行 systemctl --user，错误中附带其输出
+func systemctl(args ...string) error {
+	out, err := exec.Command("systemctl", append([]string{"--user"}, args...)...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("systemctl %s: %s", strings.Join(args, " "), msg)
+		}
+		return fmt.Errorf("systemctl %s: %w", strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+func (osServiceManager) Install(c serviceConfig) error {
+	path, err := unitPath(c.Name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	err = os.WriteFile(path, []byte(unitFile(c)), 0o644)
+	logFileOp("write", path, err)
+	if err != nil {
+		return err
+	}
+	if err := systemctl("daemon-reload"); err != nil {
+		return err
+	}
+	switch c.StartType {
+	case serviceManual, serviceDisabled:
+		return systemctl("disable", unitName(c.Name))
+	default:
+		return systemctl("enable", unitName(c.Name))
+	}
+}
+
+// unitFile 生成单元文件内容
+func unitFile(c serviceConfig) string {
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	fmt.Fprintf(&b, "Description=%s\n", c.DisplayName)
+	for _, d := range c.Dependencies {
+		if !strings.Contains(d, ".") {
+			d = unitName(d)
+		}
+		fmt.Fprintf(&b, "Wants=%s\nAfter=%s\n", d, d)
+	}
+	b.WriteString("\n[Service]\n")
+	cmd := []string{unitQuote(c.Path)}
+	for _, a := range c.Args {
+		cmd = append(cmd, unitQuote(a))
+	}
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(cmd, " "))
+	fmt.Fprintf(&b, "WorkingDirectory=%s\n", strings.ReplaceAll(c.Dir, "%", "%%"))
+	if len(c.Recovery) > 0 && c.Recovery[0].Action == recoveryRestart {
+		fmt.Fprintf(&b, "Restart=on-failure\nRestartSec=%d\n", c.Recovery[0].DelaySeconds)
+	}
+	b.WriteString("\n[Install]\nWantedBy=default.target\n")
+	return b.String()
+}
+
+// unitQuote 按 systemd.service(5) 的规则引用参数，并转义 % 与 $ 以避免被展开
+func unitQuote(s string) string {
+	s = strings.NewReplacer(`%`, `%%`, `$`, `$$`).Replace(s)
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\;") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func (osServiceManager) Start(name string) error {
+	return systemctl("start", unitName(name))
+}
+
+func (osServiceManager) Stop(name string, timeout time.Duration) (bool, error) {
+	path, err := unitPath(name)
+	if err != nil {
+		return false, err
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	// is-active 仅在运行中时返回 0
+	if exec.Command("systemctl", "--user", "is-active", "--quiet", unitName(name)).Run() != nil {
+		return false, nil
+	}
+	done := make(chan error, 1)
+	go func() { done <- systemctl("stop", unitName(name)) }()
+	select {
+	case err := <-done:
+		return true, err
+	case <-time.After(timeout):
+		return true, errWaitTimeout
+	}
+}
+
+func (osServiceManager) Remove(name string) error {
+	path, err := unitPath(name)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err := systemctl("disable", "--now", unitName(name)); err != nil {
+		warnT("service.stop_failed", name, err)
+	}
+	err = os.Remove(path)
+	logFileOp("remove", path, err)
+	if err != nil {
+		return err
+	}
+	return systemctl("daemon-reload")
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// fakeServiceManager 记录调用顺序的假实现；onCall 在每次调用时执行，可用于检查当时安装目录中的文件
+type fakeServiceManager struct {
+	calls   []string
+	running map[string]bool
+	onCall  func(op, name string)
+}
+
+func newFakeServices(t *testing.T) *fakeServiceManager {
+	f := &fakeServiceManager{running: map[string]bool{}}
+	old := services
+	services = f
+	t.Cleanup(func() { services = old })
+	return f
+}
+
+func (f *fakeServiceManager) call(op, name string) {
+	f.calls = append(f.calls, op+" "+name)
+	if f.onCall != nil {
+		f.onCall(op, name)
+	}
+}
+
+func (f *fakeServiceManager) Install(c serviceConfig) error {
+	f.call("install", c.Name)
+	return nil
+}
+
+func (f *fakeServiceManager) Start(name string) error {
+	f.call("start", name)
+	f.running[name] = true
+	return nil
+}
+
+func (f *fakeServiceManager) Stop(name string, timeout time.Duration) (bool, error) {
+	f.call("stop", name)
+	was := f.running[name]
+	delete(f.running, name)
+	return was, nil
+}
+
+func (f *fakeServiceManager) Remove(name string) error {
+	f.call("remove", name)
+	delete(f.running, name)
+	return nil
+}
+
+// setupTestInstall 将安装流程涉及的用户目录与界面替换为测试用的临时目录与静默控制台，返回安装目录
+func setupTestInstall(t *testing.T) string {
+	t.Helper()
+	for _, k := range []string{"HOME", "XDG_STATE_HOME", "XDG_CONFIG_HOME", "XDG_DATA_HOME"} {
+		t.Setenv(k, t.TempDir())
+	}
+	oldUI, oldMeta := ui, meta
+	ui = &consoleUI{silent: true}
+	t.Cleanup(func() { ui, meta = oldUI, oldMeta })
+	return filepath.Join(t.TempDir(), "app")
+}
+
+// installVersion 以 m 运行完整的安装流程，主程序 app 的内容为 content
+func installVersion(t *testing.T, dir string, m InstallMeta, content string) {
+	t.Helper()
+	if err := tryInstallVersion(dir, m, content); err != nil {
+		t.Fatalf("install %s: %v", m.Version, err)
+	}
+}
+
+// tryInstallVersion 与 installVersion 相同，但返回安装错误
+func tryInstallVersion(dir string, m InstallMeta, content string) error {
+	m.ProductName, m.ExeName = "svc-test", "app"
+	meta = m
+	files := []*inMemoryFile{{Name: "app", Mode: 0o755, Data: []byte(content)}}
+	_, err := install(files, dir, nil, false, func(int, string) {})
+	return err
+}
+
+func TestServicesUpgradeOrder(t *testing.T) {
+	dir := setupTestInstall(t)
+	f := newFakeServices(t)
+	installVersion(t, dir, InstallMeta{Version: "1.0.0", Services: []serviceSpec{{Name: "agent"}}}, "v1")
+	if want := []string{"stop agent", "install agent", "start agent"}; !reflect.DeepEqual(f.calls, want) {
+		t.Fatalf("first install calls = %v, want %v", f.calls, want)
+	}
+
+	// 停止时应仍是旧文件，注册时已是新文件
+	f.calls = nil
+	seen := map[string]string{}
+	f.onCall = func(op, name string) {
+		data, _ := os.ReadFile(filepath.Join(dir, "app"))
+		seen[op] = string(data)
+	}
+	installVersion(t, dir, InstallMeta{Version: "2.0.0", Services: []serviceSpec{{Name: "agent"}}}, "v2")
+	if want := []string{"stop agent", "install agent", "start agent"}; !reflect.DeepEqual(f.calls, want) {
+		t.Fatalf("upgrade calls = %v, want %v", f.calls, want)
+	}
+	if seen["stop"] != "v1" || seen["install"] != "v2" || seen["start"] != "v2" {
+		t.Errorf("files at each step = %v, want stop=v1 install=v2 start=v2", seen)
+	}
+	rec, err := loadInstallRecord(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rec.Services, []string{"agent"}) {
+		t.Errorf("record services = %v", rec.Services)
+	}
+}
+
+func TestServicesDroppedBetweenVersions(t *testing.T) {
+	dir := setupTestInstall(t)
+	f := newFakeServices(t)
+	installVersion(t, dir, InstallMeta{Version: "1.0.0", Services: []serviceSpec{{Name: "agent"}, {Name: "helper"}}}, "v1")
+
+	f.calls = nil
+	installVersion(t, dir, InstallMeta{Version: "2.0.0", Services: []serviceSpec{{Name: "agent"}}}, "v2")
+	want := []string{"stop agent", "stop helper", "install agent", "remove helper", "start agent"}
+	if !reflect.DeepEqual(f.calls, want) {
+		t.Fatalf("calls = %v, want %v", f.calls, want)
+	}
+	rec, _ := loadInstallRecord(dir)
+	if !reflect.DeepEqual(rec.Services, []string{"agent"}) {
+		t.Errorf("record services = %v, want [agent]", rec.Services)
+	}
+}
+
+func TestServicesNoStart(t *testing.T) {
+	dir := setupTestInstall(t)
+	f := newFakeServices(t)
+	m := InstallMeta{Version: "1.0.0", Services: []serviceSpec{{Name: "agent", NoStart: true}}}
+	installVersion(t, dir, m, "v1")
+	if want := []string{"stop agent", "install agent"}; !reflect.DeepEqual(f.calls, want) {
+		t.Fatalf("NoStart install calls = %v, want %v", f.calls, want)
+	}
+
+	// 用户手动启动后升级：替换文件后重新启动
+	f.running["agent"] = true
+	f.calls = nil
+	m.Version = "2.0.0"
+	installVersion(t, dir, m, "v2")
+	if want := []string{"stop agent", "install agent", "start agent"}; !reflect.DeepEqual(f.calls, want) {
+		t.Fatalf("running NoStart upgrade calls = %v, want %v", f.calls, want)
+	}
+
+	// 未运行时升级不启动
+	delete(f.running, "agent")
+	f.calls = nil
+	m.Version = "3.0.0"
+	installVersion(t, dir, m, "v3")
+	if want := []string{"stop agent", "install agent"}; !reflect.DeepEqual(f.calls, want) {
+		t.Fatalf("stopped NoStart upgrade calls = %v, want %v", f.calls, want)
+	}
+}
+
+func TestServicesDisabledNotStarted(t *testing.T) {
+	dir := setupTestInstall(t)
+	f := newFakeServices(t)
+	installVersion(t, dir, InstallMeta{Version: "1.0.0", Services: []serviceSpec{{Name: "agent", StartType: serviceDisabled}}}, "v1")
+	if want := []string{"stop agent", "install agent"}; !reflect.DeepEqual(f.calls, want) {
+		t.Fatalf("calls = %v, want %v", f.calls, want)
+	}
+}
+
+func TestServicesRestartedAfterPostInstallHookFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses /bin/sh")
+	}
+	dir := setupTestInstall(t)
+	f := newFakeServices(t)
+	installVersion(t, dir, InstallMeta{Version: "1.0.0", Services: []serviceSpec{{Name: "agent"}}}, "v1")
+
+	f.calls = nil
+	failing := hookSpec{Stage: hookPostInstall, Command: "/bin/sh", Args: []string{"-c", "exit 1"}, OnFailure: hookFailRollback}
+	err := tryInstallVersion(dir, InstallMeta{Version: "2.0.0", Services: []serviceSpec{{Name: "agent"}}, Hooks: []hookSpec{failing}}, "v2")
+	if err == nil {
+		t.Fatal("install with a failing post-install hook succeeded")
+	}
+	if want := []string{"stop agent", "start agent"}; !reflect.DeepEqual(f.calls, want) {
+		t.Errorf("calls = %v, want %v", f.calls, want)
+	}
+	if got := readTestFile(t, filepath.Join(dir, "app")); got != "v1" {
+		t.Errorf("app = %q, want the restored v1", got)
+	}
+}
+
+func TestRemoveServicesReverseOrder(t *testing.T) {
+	setupTestInstall(t)
+	f := newFakeServices(t)
+	removeServices(&installRecord{Services: []string{"db", "agent", "helper"}})
+	if want := []string{"remove helper", "remove agent", "remove db"}; !reflect.DeepEqual(f.calls, want) {
+		t.Fatalf("calls = %v, want %v", f.calls, want)
+	}
+}
@@ -0,0 +1,171 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+var serviceStartTypes = map[string]uint32{
+	"":              mgr.StartAutomatic,
+	serviceAuto:     mgr.StartAutomatic,
+	serviceDelayed:  mgr.StartAutomatic,
+	serviceManual:   mgr.StartManual,
+	serviceDisabled: mgr.StartDisabled,
+}
+
+var recoveryTypes = map[string]int{
+	recoveryNone:    mgr.NoAction,
+	recoveryRestart: mgr.ServiceRestart,
+	recoveryReboot:  mgr.ComputerReboot,
+}
+
+// openService 服务不存在时返回 nil, nil
+func openService(m *mgr.Mgr, name string) (*mgr.Service, error) {
+	s, err := m.OpenService(name)
+	if errors.Is(err, windows.ERROR_SERVICE_DOES_NOT_EXIST) {
+		return nil, nil
+	}
+	return s, err
+}
+
+func (osServiceManager) Install(c serviceConfig) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+
+	account := c.Account
+	if account == "" {
+		account = "LocalSystem"
+	}
+	cfg := mgr.Config{
+		ServiceType:      windows.SERVICE_WIN32_OWN_PROCESS,
+		StartType:        serviceStartTypes[c.StartType],
+		ErrorControl:     mgr.ErrorNormal,
+		DisplayName:      c.DisplayName,
+		Description:      c.Description,
+		Dependencies:     c.Dependencies,
+		ServiceStartName: account,
+		DelayedAutoStart: c.StartType == serviceDelayed,
+	}
+	s, err := openService(m, c.Name)
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		if s, err = m.CreateService(c.Name, c.Path, cfg, c.Args...); err != nil {
+			return err
+		}
+	} else {
+		cfg.BinaryPathName = syscall.EscapeArg(c.Path)
+		for _, a := range c.Args {
+			cfg.BinaryPathName += " " + syscall.EscapeArg(a)
+		}
+		if err := s.UpdateConfig(cfg); err != nil {
+			s.Close()
+			return err
+		}
+	}
+	defer s.Close()
+
+	if len(c.Recovery) == 0 {
+		return s.ResetRecoveryActions()
+	}
+	var actions []mgr.RecoveryAction
+	for _, r := range c.Recovery {
+		actions = append(actions, mgr.RecoveryAction{Type: recoveryTypes[r.Action], Delay: time.Duration(r.DelaySeconds) * time.Second})
+	}
+	return s.SetRecoveryActions(actions, uint32(c.ResetPeriodSeconds))
+}
+
+func (osServiceManager) Start(name string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+	s, err := m.OpenService(name)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	err = s.Start()
+	if errors.Is(err, windows.ERROR_SERVICE_ALREADY_RUNNING) {
+		return nil
+	}
+	return err
+}
+
+func (osServiceManager) Stop(name string, timeout time.Duration) (bool, error) {
+	m, err := mgr.Connect()
+	if err != nil {
+		return false, err
+	}
+	defer m.Disconnect()
+	s, err := openService(m, name)
+	if s == nil || err != nil {
+		return false, err
+	}
+	defer s.Close()
+	return stopService(s, timeout)
+}
+
+// stopService 发送停止请求并等待服务进入停止状态
+func stopService(s *mgr.Service, timeout time.Duration) (bool, error) {
+	st, err := s.Query()
+	if err != nil {
+		return false, err
+	}
+	if st.State == svc.Stopped {
+		return false, nil
+	}
+	if st.State != svc.StopPending {
+		if _, err := s.Control(svc.Stop); err != nil && !errors.Is(err, windows.ERROR_SERVICE_NOT_ACTIVE) {
+			return true, err
+		}
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		st, err := s.Query()
+		if err != nil {
+			return true, err
+		}
+		if st.State == svc.Stopped {
+			return true, nil
+		}
+		if time.Now().After(deadline) {
+			return true, errWaitTimeout
+		}
+		time.Sleep(300 * time.Millisecond)
+	}
+}
+
+func (osServiceManager) Remove(name string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+	s, err := openService(m, name)
+	if s == nil || err != nil {
+		return err
+	}
+	defer s.Close()
+	if _, err := stopService(s, serviceStopTimeout); err != nil {
+		warnT("service.stop_failed", name, err)
+	}
+	// 仍有句柄打开时服务只被标记为删除，关闭句柄后由系统删除
+	err = s.Delete()
+	if errors.Is(err, windows.ERROR_SERVICE_MARKED_FOR_DELETE) {
+		return nil
+	}
+	return err
+}
@@ -3,6 +3,6 @@
 package main
 
 // 非 Windows 平台占位实现
-func createShortcuts(targetExe, workingDir string, meta InstallMeta) error {
-	return nil
-}
\ No newline at end of file
+func createShortcuts(targetExe, workingDir string, meta InstallMeta) ([]string, error) {
+	return nil, nil
+}
@@ -19,9 +19,10 @@ import (
 	"github.com/go-ole/go-ole/oleutil"
 )
 
-func createShortcuts(targetExe, workingDir string, meta InstallMeta) error {
+// createShortcuts 创建桌面/开始菜单快捷方式，返回实际创建成功的 .lnk 路径（写入安装记录供卸载使用）
+func createShortcuts(targetExe, workingDir string, meta InstallMeta) ([]string, error) {
 	if _, err := os.Stat(targetExe); err != nil {
-		return fmt.Errorf("target exe missing: %w", err)
+		return nil, fmt.Errorf("target exe missing: %w", err)
 	}
 
 	if workingDir == "" {
@@ -30,6 +31,7 @@ func createShortcuts(targetExe, workingDir string, meta InstallMeta) error {
 
 	iconPath := targetExe
 	var errs []string
+	var created []string
 
 	name := meta.ShortcutName
 	if name == "" {
@@ -38,14 +40,15 @@ func createShortcuts(targetExe, workingDir string, meta InstallMeta) error {
 	name = sanitizeFilename(name)
 
 	if meta.CreateDesktopShortcut {
-		fmt.Println(" - 正在创建桌面快捷方式...")
+		logT("shortcut.desktop_creating")
 		if p, err := desktopDir(); err == nil {
 			link := filepath.Join(p, name+".lnk")
 			if err2 := createShortcut(link, targetExe, workingDir, iconPath); err2 != nil {
 				errs = append(errs, "Desktop:"+err2.Error())
-				fmt.Printf("   × 桌面快捷方式失败: %v\n", err2)
+				warnT("shortcut.desktop_failed", err2)
 			} else {
-				fmt.Printf("   √ 桌面快捷方式: %s\n", link)
+				created = append(created, link)
+				logT("shortcut.desktop_done", link)
 			}
 		} else {
 			errs = append(errs, "DesktopDir:"+err.Error())
@@ -53,7 +56,7 @@ func createShortcuts(targetExe, workingDir string, meta InstallMeta) error {
 	}
 
 	if meta.CreateStartMenuShortcut {
-		fmt.Println(" - 正在创建开始菜单快捷方式...")
+		logT("shortcut.startmenu_creating")
 		if p, err := startMenuDir(name); err == nil {
 			if err = os.MkdirAll(p, 0o755); err != nil {
 				errs = append(errs, "StartMenu mkdir:"+err.Error())
@@ -61,9 +64,10 @@ func createShortcuts(targetExe, workingDir string, meta InstallMeta) error {
 				link := filepath.Join(p, name+".lnk")
 				if err2 := createShortcut(link, targetExe, workingDir, iconPath); err2 != nil {
 					errs = append(errs, "StartMenu:"+err2.Error())
-					fmt.Printf("   × 开始菜单快捷方式失败: %v\n", err2)
+					warnT("shortcut.startmenu_failed", err2)
 				} else {
-					fmt.Printf("   √ 开始菜单快捷方式: %s\n", link)
+					created = append(created, link)
+					logT("shortcut.startmenu_done", link)
 				}
 			}
 		} else {
@@ -72,28 +76,29 @@ func createShortcuts(targetExe, workingDir string, meta InstallMeta) error {
 	}
 
 	if len(errs) > 0 {
-		return fmt.Errorf(strings.Join(errs, "; "))
+		return created, errors.New(strings.Join(errs, "; "))
 	}
-	return nil
+	return created, nil
 }
 
 func desktopDir() (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", err
-	}
-	return filepath.Join(home, "Desktop"), nil
+	return knownFolders.Path(folderDesktop)
 }
 
 func startMenuDir(product string) (string, error) {
-	appData := os.Getenv("AppData")
-	if appData == "" {
-		return "", fmt.Errorf("AppData env empty")
+	programs, err := knownFolders.Path(folderPrograms)
+	if err != nil {
+		return "", err
 	}
-	return filepath.Join(appData, "Microsoft", "Windows", "Start Menu", "Programs", product), nil
+	return filepath.Join(programs, product), nil
 }
 
 func createShortcut(linkPath, targetPath, workingDir, iconPath string) error {
+	return createShortcutArgs(linkPath, targetPath, workingDir, iconPath, "")
+}
+
+// createShortcutArgs 创建带命令行参数的快捷方式；args 为已引用好的参数串
+func createShortcutArgs(linkPath, targetPath, workingDir, iconPath, args string) error {
 	if err := os.MkdirAll(filepath.Dir(linkPath), 0o755); err != nil {
 		return err
 	}
@@ -105,7 +110,7 @@ func createShortcut(linkPath, targetPath, workingDir, iconPath string) error {
 	}
 
 	// 优先使用底层 ShellLink 接口（完全 Unicode）
-	if err := createShortcutShellLinkLowLevel(linkPath, targetPath, workingDir, iconPath); err == nil {
+	if err := createShortcutShellLinkLowLevel(linkPath, targetPath, workingDir, iconPath, args); err == nil {
 		return nil
 	}
 
@@ -115,42 +120,45 @@ func createShortcut(linkPath, targetPath, workingDir, iconPath string) error {
 
 	unknown, err := oleutil.CreateObject("WScript.Shell")
 	if err != nil {
-		return fallbackVbsShortcut(linkPath, targetPath, workingDir, iconPath, fmt.Errorf("CreateObject: %w", err))
+		return fallbackVbsShortcut(linkPath, targetPath, workingDir, iconPath, args, fmt.Errorf("CreateObject: %w", err))
 	}
 	defer unknown.Release()
 	shell, err := unknown.QueryInterface(ole.IID_IDispatch)
 	if err != nil {
-		return fallbackVbsShortcut(linkPath, targetPath, workingDir, iconPath, fmt.Errorf("QI: %w", err))
+		return fallbackVbsShortcut(linkPath, targetPath, workingDir, iconPath, args, fmt.Errorf("QI: %w", err))
 	}
 	defer shell.Release()
 
 	shortcutDisp, err := oleutil.CallMethod(shell, "CreateShortcut", linkPath)
 	if err != nil {
-		return fallbackVbsShortcut(linkPath, targetPath, workingDir, iconPath, fmt.Errorf("CreateShortcut: %w", err))
+		return fallbackVbsShortcut(linkPath, targetPath, workingDir, iconPath, args, fmt.Errorf("CreateShortcut: %w", err))
 	}
 	shortcut := shortcutDisp.ToIDispatch()
 	defer shortcut.Release()
 
 	// 设置属性
 	if _, err = oleutil.PutProperty(shortcut, "TargetPath", targetPath); err != nil {
-		return fallbackVbsShortcut(linkPath, targetPath, workingDir, iconPath, fmt.Errorf("TargetPath: %w", err))
+		return fallbackVbsShortcut(linkPath, targetPath, workingDir, iconPath, args, fmt.Errorf("TargetPath: %w", err))
+	}
+	if _, err = oleutil.PutProperty(shortcut, "Arguments", args); err != nil {
+		return fallbackVbsShortcut(linkPath, targetPath, workingDir, iconPath, args, fmt.Errorf("Arguments: %w", err))
 	}
 	if _, err = oleutil.PutProperty(shortcut, "WorkingDirectory", workingDir); err != nil {
-		return fallbackVbsShortcut(linkPath, targetPath, workingDir, iconPath, fmt.Errorf("WorkingDirectory: %w", err))
+		return fallbackVbsShortcut(linkPath, targetPath, workingDir, iconPath, args, fmt.Errorf("WorkingDirectory: %w", err))
 	}
 	if _, err = oleutil.PutProperty(shortcut, "IconLocation", iconPath); err != nil {
-		return fallbackVbsShortcut(linkPath, targetPath, workingDir, iconPath, fmt.Errorf("IconLocation: %w", err))
+		return fallbackVbsShortcut(linkPath, targetPath, workingDir, iconPath, args, fmt.Errorf("IconLocation: %w", err))
 	}
 	if _, err = oleutil.PutProperty(shortcut, "WindowStyle", 1); err != nil {
-		return fallbackVbsShortcut(linkPath, targetPath, workingDir, iconPath, fmt.Errorf("WindowStyle: %w", err))
+		return fallbackVbsShortcut(linkPath, targetPath, workingDir, iconPath, args, fmt.Errorf("WindowStyle: %w", err))
 	}
 
 	if _, err = oleutil.CallMethod(shortcut, "Save"); err != nil {
-		return fallbackVbsShortcut(linkPath, targetPath, workingDir, iconPath, fmt.Errorf("Save: %w", err))
+		return fallbackVbsShortcut(linkPath, targetPath, workingDir, iconPath, args, fmt.Errorf("Save: %w", err))
 	}
 	// 验证文件是否真的创建（某些奇怪的 locale 下 Save 返回成功但文件不存在）
 	if _, statErr := os.Stat(linkPath); statErr != nil {
-		return fallbackVbsShortcut(linkPath, targetPath, workingDir, iconPath, fmt.Errorf("post-save missing: %w", statErr))
+		return fallbackVbsShortcut(linkPath, targetPath, workingDir, iconPath, args, fmt.Errorf("post-save missing: %w", statErr))
 	}
 	return nil
 }
@@ -228,7 +236,7 @@ type IPersistFileVtbl struct {
 	GetCurFile    uintptr
 }
 
-func createShortcutShellLinkLowLevel(linkPath, targetPath, workingDir, iconPath string) error {
+func createShortcutShellLinkLowLevel(linkPath, targetPath, workingDir, iconPath, args string) error {
 	// 初始化 COM (允许外部已初始化)
 	_ = ole.CoInitializeEx(0, ole.COINIT_APARTMENTTHREADED)
 	// 不使用 defer CoUninitialize() 以免与上层重复释放；由上层统一处理
@@ -256,6 +264,11 @@ func createShortcutShellLinkLowLevel(linkPath, targetPath, workingDir, iconPath
 	if err := slSetWorkingDir(shellLink, workingDir); err != nil {
 		return err
 	}
+	if args != "" {
+		if err := slSetArguments(shellLink, args); err != nil {
+			return err
+		}
+	}
 	if err := slSetShowCmd(shellLink, SW_SHOWNORMAL); err != nil {
 		return err
 	}
@@ -312,6 +325,14 @@ func slSetWorkingDir(sl *IShellLinkW, dir string) error {
 	}
 	return nil
 }
+func slSetArguments(sl *IShellLinkW, args string) error {
+	w, _ := syscall.UTF16PtrFromString(args)
+	hr, _, _ := syscall.Syscall(sl.lpVtbl.SetArguments, 2, uintptr(unsafe.Pointer(sl)), uintptr(unsafe.Pointer(w)), 0)
+	if failed(hr) {
+		return fmt.Errorf("SetArguments hr=0x%x", hr)
+	}
+	return nil
+}
 func slSetShowCmd(sl *IShellLinkW, cmd int) error {
 	hr, _, _ := syscall.Syscall(sl.lpVtbl.SetShowCmd, 2, uintptr(unsafe.Pointer(sl)), uintptr(cmd), 0)
 	if failed(hr) {
@@ -346,7 +367,7 @@ func comRelease(p unsafe.Pointer) {
 }
 
 // fallbackVbsShortcut 尝试使用临时 VBScript 创建快捷方式 (UTF-16 LE BOM) 以提升兼容性
-func fallbackVbsShortcut(linkPath, targetPath, workingDir, iconPath string, originalErr error) error {
+func fallbackVbsShortcut(linkPath, targetPath, workingDir, iconPath, args string, originalErr error) error {
 	// 若已经存在则不重复
 	if _, err := os.Stat(linkPath); err == nil {
 		return nil
@@ -357,11 +378,12 @@ Dim shell, lnk
 Set shell = CreateObject("WScript.Shell")
 Set lnk = shell.CreateShortcut(%q)
 lnk.TargetPath = %q
+lnk.Arguments = %q
 lnk.WorkingDirectory = %q
 lnk.IconLocation = %q
 lnk.WindowStyle = 1
 lnk.Save
-`, linkPath, targetPath, workingDir, iconPath)
+`, linkPath, targetPath, args, workingDir, iconPath)
 
 	tmpDir := os.TempDir()
 	name := fmt.Sprintf("shortcut_%d.vbs", time.Now().UnixNano())
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// progressFunc 安装过程报告进度（0-100）与当前步骤
+type progressFunc func(percent int, status string)
+
+// installFunc 在进度页执行实际安装，返回主程序路径（用于完成页“运行程序”）
+type installFunc func(progress progressFunc) (string, error)
+
+// installerUI 安装/卸载界面前端。页面流转与校验由 wizard 负责，前端只负责展示与收集输入。
+type installerUI interface {
+	// Run 驱动向导直至完成或取消；进入进度页时调用 install
+	Run(w *wizard, install installFunc)
+	// Log 显示一行过程信息，可在任意 goroutine 调用
+	Log(line string)
+	// Info / Error 在向导之外显示提示或错误（如卸载完成、归档损坏）
+	Info(msg string)
+	Error(msg string)
+	// Choose 让用户在若干选项中选择，返回序号；静默模式返回 def
+	Choose(msg string, choices []string, def int) int
+}
+
+// ui 当前使用的前端，由 chooseUI 在启动时决定
+var ui installerUI = &consoleUI{}
+
+// chooseUI Windows 下默认使用图形向导；/S 静默安装或 /CONSOLE 时使用控制台
+func chooseUI() installerUI {
+	if !silentMode() && !hasSwitch("CONSOLE") {
+		if g, err := newGUI(); err == nil {
+			return g
+		}
+	}
+	prepareConsole()
+	return &consoleUI{silent: silentMode()}
+}
+
+// logf 输出安装过程信息
+func logf(format string, args ...any) {
+	logAt(levelInfo, strings.TrimRight(fmt.Sprintf(format, args...), "\n"))
+}
+
+// wizardErrorText 将向导校验错误转为提示文字
+func wizardErrorText(err error) string {
+	switch {
+	case errors.Is(err, errLicenseNotAccepted):
+		return T("wizard.license_required")
+	case errors.Is(err, errInstallDirInvalid):
+		return T("wizard.dir_invalid")
+	case errors.Is(err, errInstallDirProtected):
+		return T("wizard.dir_protected")
+	}
+	return err.Error()
+}
+
+// ========== 控制台前端 ==========
+
+type consoleUI struct {
+	silent bool
+}
+
+func (c *consoleUI) Log(line string) { fmt.Println(line) }
+
+func (c *consoleUI) Info(msg string) { fmt.Println(msg) }
+
+func (c *consoleUI) Error(msg string) {
+	fmt.Println(msg)
+	_ = pressAnyKey()
+}
+
+func (c *consoleUI) Choose(msg string, choices []string, def int) int {
+	fmt.Println(msg)
+	if c.silent {
+		return def
+	}
+	for {
+		for i, ch := range choices {
+			fmt.Printf("  [%d] %s\n", i+1, ch)
+		}
+		in := promptLine(T("console.choose", def+1))
+		if in == "" {
+			return def
+		}
+		if n, err := strconv.Atoi(in); err == nil && n >= 1 && n <= len(choices) {
+			return n - 1
+		}
+	}
+}
+
+func (c *consoleUI) Run(w *wizard, install installFunc) {
+	for {
+		switch w.Page {
+		case pageWelcome:
+			fmt.Println(T("console.welcome", w.State.ProductName, w.State.Version))
+			if note := w.VersionNote(); note != "" {
+				fmt.Println(note)
+			}
+		case pageLicense:
+			if !c.silent {
+				fmt.Println(w.State.LicenseText)
+				w.State.LicenseAccepted = askYesNo(T("console.accept_license"))
+			}
+		case pageDirectory:
+			if !c.silent {
+				if d := promptLine(T("console.install_dir", w.State.InstallDir)); d != "" {
+					w.State.InstallDir = d
+				}
+				if w.State.AutostartOffered {
+					w.State.Autostart = askYesNo(T("console.autostart", w.State.ProductName))
+				}
+			}
+		case pageComponents:
+			c.chooseComponents(w)
+		case pageFinish:
+			if w.State.Err != nil {
+				fmt.Println(T("console.install_failed", w.State.Err))
+			} else {
+				fmt.Println(T("console.install_done"))
+			}
+			if w.State.CanLaunch && !c.silent {
+				w.State.LaunchApp = askYesNo(T("console.launch"))
+			}
+			_ = pressAnyKey()
+			return
+		}
+
+		act, err := w.Next()
+		if err != nil {
+			fmt.Println(wizardErrorText(err))
+			// 静默模式或输入已结束时无法更正，按取消处理，避免反复显示同一页
+			if c.silent || stdinClosed {
+				if errors.Is(err, errLicenseNotAccepted) {
+					fmt.Println(T("console.accept_eula_hint"))
+				}
+				w.Cancel()
+				return
+			}
+			continue
+		}
+		if act == actionInstall {
+			last := ""
+			exe, err := install(func(percent int, status string) {
+				w.SetProgress(percent, status)
+				if status != "" && status != last {
+					last = status
+					fmt.Printf("[%3d%%] %s\n", percent, status)
+				}
+			})
+			w.InstallDone(err, exe != "")
+		}
+	}
+}
+
+// chooseComponents 列出组件，输入序号切换选择状态
+func (c *consoleUI) chooseComponents(w *wizard) {
+	for {
+		for i, comp := range w.State.Components {
+			mark := " "
+			if comp.Selected || comp.Required {
+				mark = "x"
+			}
+			fmt.Printf("  [%s] %d. %s  %s (%s)\n", mark, i+1, comp.Name, comp.Description, formatSize(comp.Size))
+		}
+		if c.silent {
+			return
+		}
+		in := promptLine(T("console.components"))
+		if in == "" {
+			return
+		}
+		for _, f := range strings.Split(in, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(f))
+			if err != nil || n < 1 || n > len(w.State.Components) || w.State.Components[n-1].Required {
+				continue
+			}
+			w.SetComponent(n-1, !w.State.Components[n-1].Selected)
+		}
+	}
+}
+
+var stdin = bufio.NewReader(os.Stdin)
+
+// stdinClosed 标准输入已结束（EOF）或无法读取，此后的提示都只能得到空输入
+var stdinClosed bool
+
+// promptLine 输出提示并读取一行输入（去除首尾空白）
+func promptLine(prompt string) string {
+	fmt.Print(prompt)
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		stdinClosed = true
+		fmt.Println()
+	}
+	return strings.TrimSpace(line)
+}
+
+func askYesNo(prompt string) bool {
+	return strings.EqualFold(promptLine(prompt), "Y")
+}
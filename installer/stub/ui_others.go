@@ -0,0 +1,10 @@
+//go:build !windows
+
+package main
+
+import "errors"
+
+// 非 Windows 平台仅提供控制台前端
+func newGUI() (installerUI, error) { return nil, errors.New("gui not supported") }
+
+func prepareConsole() {}
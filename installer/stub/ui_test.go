@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setTestStdin 以 input 作为控制台输入
+func setTestStdin(t *testing.T, input string) {
+	t.Helper()
+	old, oldClosed := stdin, stdinClosed
+	stdin, stdinClosed = bufio.NewReader(strings.NewReader(input)), false
+	t.Cleanup(func() { stdin, stdinClosed = old, oldClosed })
+}
+
+func TestConsoleLicenseEOFCancels(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		cancelled bool
+	}{
+		{"eof", "", true},
+		{"declined then eof", "\n\nn\n", true},
+		{"accepted", "\nY\n\n\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTestStdin(t, tt.input)
+			w := newWizard(wizardState{ProductName: "p", LicenseText: "EULA", InstallDir: "/opt/p"})
+			installed := false
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				(&consoleUI{}).Run(w, func(progressFunc) (string, error) {
+					installed = true
+					return "", nil
+				})
+			}()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("console wizard did not stop at end of input")
+			}
+			if w.Cancelled() != tt.cancelled || installed == tt.cancelled {
+				t.Errorf("cancelled = %v, installed = %v, want cancelled %v", w.Cancelled(), installed, tt.cancelled)
+			}
+		})
+	}
+}
@@ -0,0 +1,779 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// Win32 窗口必须始终在同一个系统线程上创建与处理消息
+func init() { runtime.LockOSThread() }
+
+var (
+	user32   = windows.NewLazySystemDLL("user32.dll")
+	gdi32    = windows.NewLazySystemDLL("gdi32.dll")
+	comctl32 = windows.NewLazySystemDLL("comctl32.dll")
+	shell32  = windows.NewLazySystemDLL("shell32.dll")
+	kernel32 = windows.NewLazySystemDLL("kernel32.dll")
+
+	procRegisterClassExW      = user32.NewProc("RegisterClassExW")
+	procCreateWindowExW       = user32.NewProc("CreateWindowExW")
+	procDefWindowProcW        = user32.NewProc("DefWindowProcW")
+	procDestroyWindow         = user32.NewProc("DestroyWindow")
+	procShowWindow            = user32.NewProc("ShowWindow")
+	procUpdateWindow          = user32.NewProc("UpdateWindow")
+	procGetMessageW           = user32.NewProc("GetMessageW")
+	procTranslateMessage      = user32.NewProc("TranslateMessage")
+	procDispatchMessageW      = user32.NewProc("DispatchMessageW")
+	procIsDialogMessageW      = user32.NewProc("IsDialogMessageW")
+	procPostQuitMessage       = user32.NewProc("PostQuitMessage")
+	procSendMessageW          = user32.NewProc("SendMessageW")
+	procEnableWindow          = user32.NewProc("EnableWindow")
+	procSetWindowTextW        = user32.NewProc("SetWindowTextW")
+	procGetWindowTextW        = user32.NewProc("GetWindowTextW")
+	procGetWindowTextLengthW  = user32.NewProc("GetWindowTextLengthW")
+	procLoadCursorW           = user32.NewProc("LoadCursorW")
+	procGetSystemMetrics      = user32.NewProc("GetSystemMetrics")
+	procSetForegroundWindow   = user32.NewProc("SetForegroundWindow")
+	procCreateFontW           = gdi32.NewProc("CreateFontW")
+	procInitCommonControlsEx  = comctl32.NewProc("InitCommonControlsEx")
+	procSHBrowseForFolderW    = shell32.NewProc("SHBrowseForFolderW")
+	procSHGetPathFromIDListW  = shell32.NewProc("SHGetPathFromIDListW")
+	procCoTaskMemFree         = windows.NewLazySystemDLL("ole32.dll").NewProc("CoTaskMemFree")
+	procGetConsoleProcessList = kernel32.NewProc("GetConsoleProcessList")
+	procFreeConsole           = kernel32.NewProc("FreeConsole")
+	procAttachConsole         = kernel32.NewProc("AttachConsole")
+	procAllocConsole          = kernel32.NewProc("AllocConsole")
+)
+
+const (
+	wsChild           = 0x40000000
+	wsVisible         = 0x10000000
+	wsCaption         = 0x00C00000
+	wsSysMenu         = 0x00080000
+	wsMinimizeBox     = 0x00020000
+	wsPopup           = 0x80000000
+	wsTabStop         = 0x00010000
+	wsVScroll         = 0x00200000
+	wsExClientEdge    = 0x00000200
+	wsExDlgModalFrame = 0x00000001
+
+	esMultiline   = 0x0004
+	esAutoVScroll = 0x0040
+	esAutoHScroll = 0x0080
+	esReadOnly    = 0x0800
+
+	bsPushButton    = 0x0
+	bsDefPushButton = 0x1
+	bsAutoCheckBox  = 0x3
+
+	wmDestroy   = 0x0002
+	wmSetFont   = 0x0030
+	wmCommand   = 0x0111
+	wmApp       = 0x8000
+	wmAppUpdate = wmApp + 1 // 安装 goroutine 有新的进度/日志
+	wmAppDone   = wmApp + 2 // 安装结束
+	wmAppChoose = wmApp + 3 // 在 UI 线程弹出选择对话框
+
+	bmGetCheck    = 0x00F0
+	bmSetCheck    = 0x00F1
+	bstChecked    = 1
+	pbmSetRange32 = 0x0406
+	pbmSetPos     = 0x0402
+	emSetSel      = 0x00B1
+	emReplaceSel  = 0x00C2
+
+	swShow       = 5
+	idcArrow     = 32512
+	colorBtnFace = 15
+	smCxScreen   = 0
+	smCyScreen   = 1
+
+	mbOK          = 0x00000000
+	mbIconError   = 0x00000010
+	mbIconWarning = 0x00000030
+	mbIconInfo    = 0x00000040
+	mbYesNo       = 0x00000004
+	idYes         = 6
+)
+
+// 控件 ID
+const (
+	idBack = 1001 + iota
+	idNext
+	idCancel
+	idBrowse
+	idAccept
+	idLaunch
+	idAutostart
+	idChoiceBase = 2000
+	idComponent  = 3000 // 组件复选框：idComponent + 序号
+	choiceClosed = 1 << 16
+)
+
+type wndClassEx struct {
+	Size       uint32
+	Style      uint32
+	WndProc    uintptr
+	ClsExtra   int32
+	WndExtra   int32
+	Instance   windows.Handle
+	Icon       windows.Handle
+	Cursor     windows.Handle
+	Background windows.Handle
+	MenuName   *uint16
+	ClassName  *uint16
+	IconSm     windows.Handle
+}
+
+type winMsg struct {
+	Hwnd    windows.HWND
+	Message uint32
+	WParam  uintptr
+	LParam  uintptr
+	Time    uint32
+	Pt      struct{ X, Y int32 }
+}
+
+type browseInfo struct {
+	Owner       windows.HWND
+	Root        uintptr
+	DisplayName *uint16
+	Title       *uint16
+	Flags       uint32
+	Callback    uintptr
+	LParam      uintptr
+	Image       int32
+}
+
+const (
+	wizardClass = "ExeInstallerWizard"
+	choiceClass = "ExeInstallerChoice"
+	wndWidth    = 540
+	wndHeight   = 430
+)
+
+// activeGUI 窗口过程是全局回调，通过它找到界面对象
+var activeGUI *guiUI
+
+// guiUI Win32 向导前端：单窗口，切换页面时销毁并重建页面控件
+type guiUI struct {
+	instance  windows.Handle
+	font      uintptr
+	titleFont uintptr
+
+	hwnd    windows.HWND
+	hTitle  windows.HWND
+	hBack   windows.HWND
+	hNext   windows.HWND
+	hCancel windows.HWND
+	page    []windows.HWND // 当前页面的控件
+
+	// 页面控件（可能为 0）
+	hAccept     windows.HWND
+	hDirEdit    windows.HWND
+	hProgress   windows.HWND
+	hStatus     windows.HWND
+	hLog        windows.HWND
+	hLaunch     windows.HWND
+	hAutostart  windows.HWND
+	hComponents []windows.HWND
+
+	w       *wizard
+	install installFunc
+
+	mu       sync.Mutex
+	logLines []string // 待显示的日志（由安装 goroutine 写入）
+	percent  int
+	status   string
+	exePath  string
+	err      error
+
+	choice        int            // 选择对话框结果，-1 表示尚未选择
+	pendingChoice *choiceRequest // 由其他线程经 SendMessage 转交的选择请求
+}
+
+// choiceRequest 通过 SendMessage 传给 UI 线程的选择请求
+type choiceRequest struct {
+	msg     string
+	choices []string
+	def     int
+}
+
+func newGUI() (installerUI, error) {
+	if err := procInitCommonControlsEx.Find(); err != nil {
+		return nil, err
+	}
+	icc := struct{ Size, ICC uint32 }{8, 0x4000 | 0x20} // ICC_STANDARD_CLASSES | ICC_PROGRESS_CLASS
+	procInitCommonControlsEx.Call(uintptr(unsafe.Pointer(&icc)))
+	_ = windows.CoInitializeEx(0, windows.COINIT_APARTMENTTHREADED)
+
+	g := &guiUI{choice: -1}
+	if err := windows.GetModuleHandleEx(0, nil, &g.instance); err != nil {
+		return nil, err
+	}
+	g.font = createFont(-12, 400)
+	g.titleFont = createFont(-16, 700)
+
+	cursor, _, _ := procLoadCursorW.Call(0, idcArrow)
+	for _, cls := range []struct {
+		name string
+		proc uintptr
+	}{
+		{wizardClass, syscall.NewCallback(wizardWndProc)},
+		{choiceClass, syscall.NewCallback(choiceWndProc)},
+	} {
+		wc := wndClassEx{
+			WndProc:    cls.proc,
+			Instance:   g.instance,
+			Cursor:     windows.Handle(cursor),
+			Background: windows.Handle(colorBtnFace + 1),
+			ClassName:  windows.StringToUTF16Ptr(cls.name),
+		}
+		wc.Size = uint32(unsafe.Sizeof(wc))
+		if r, _, err := procRegisterClassExW.Call(uintptr(unsafe.Pointer(&wc))); r == 0 {
+			return nil, fmt.Errorf("RegisterClassEx %s: %w", cls.name, err)
+		}
+	}
+
+	// 双击启动时系统为控制台程序单独创建的黑窗口没有意义，直接释放
+	var pids [2]uint32
+	if n, _, _ := procGetConsoleProcessList.Call(uintptr(unsafe.Pointer(&pids[0])), 2); n == 1 {
+		procFreeConsole.Call()
+	}
+	activeGUI = g
+	return g, nil
+}
+
+func createFont(height int32, weight int32) uintptr {
+	face := windows.StringToUTF16Ptr("Segoe UI")
+	f, _, _ := procCreateFontW.Call(uintptr(height), 0, 0, 0, uintptr(weight), 0, 0, 0,
+		1 /* DEFAULT_CHARSET */, 0, 0, 5 /* CLEARTYPE_QUALITY */, 0, uintptr(unsafe.Pointer(face)))
+	return f
+}
+
+// prepareConsole 控制台前端需要标准输入输出：GUI 子系统构建时附加到父进程控制台，
+// 没有父控制台且需要交互时新建一个。
+func prepareConsole() {
+	if h, err := windows.GetStdHandle(windows.STD_OUTPUT_HANDLE); err == nil && h != 0 && h != windows.InvalidHandle {
+		return
+	}
+	const attachParentProcess = ^uintptr(0)
+	if r, _, _ := procAttachConsole.Call(attachParentProcess); r == 0 {
+		if silentMode() {
+			return
+		}
+		procAllocConsole.Call()
+	}
+	if f, err := os.OpenFile("CONOUT$", os.O_WRONLY, 0); err == nil {
+		os.Stdout, os.Stderr = f, f
+	}
+	if f, err := os.OpenFile("CONIN$", os.O_RDONLY, 0); err == nil {
+		os.Stdin = f
+		stdin.Reset(f)
+	}
+}
+
+// ---------- installerUI ----------
+
+func (g *guiUI) Log(line string) {
+	fmt.Println(line) // 附加了控制台时同时输出
+	g.mu.Lock()
+	g.logLines = append(g.logLines, line)
+	hwnd := g.hwnd
+	g.mu.Unlock()
+	if hwnd != 0 {
+		procPostMessageW.Call(uintptr(hwnd), wmAppUpdate, 0, 0)
+	}
+}
+
+func (g *guiUI) Info(msg string) { g.messageBox(msg, mbOK|mbIconInfo) }
+
+func (g *guiUI) Error(msg string) { g.messageBox(msg, mbOK|mbIconError) }
+
+func (g *guiUI) messageBox(msg string, flags uint32) int32 {
+	title := "Setup"
+	if g.w != nil {
+		title = g.w.State.ProductName
+	}
+	r, _ := windows.MessageBox(g.hwnd, windows.StringToUTF16Ptr(msg), windows.StringToUTF16Ptr(title), flags)
+	return r
+}
+
+// Choose 可能在安装 goroutine 中调用：通过 SendMessage 交给 UI 线程弹出对话框并同步等待结果
+func (g *guiUI) Choose(msg string, choices []string, def int) int {
+	req := &choiceRequest{msg: msg, choices: choices, def: def}
+	if g.hwnd != 0 && windows.GetCurrentThreadId() != guiThreadID {
+		// SendMessage 同步等待 UI 线程处理完毕，期间 pendingChoice 不会被并发修改
+		g.pendingChoice = req
+		r, _, _ := procSendMessageW.Call(uintptr(g.hwnd), wmAppChoose, 0, 0)
+		return int(r)
+	}
+	return g.choiceDialog(req)
+}
+
+// guiThreadID 运行消息循环的线程
+var guiThreadID uint32
+
+func (g *guiUI) Run(w *wizard, install installFunc) {
+	g.w, g.install = w, install
+	guiThreadID = windows.GetCurrentThreadId()
+
+	sw, _, _ := procGetSystemMetrics.Call(smCxScreen)
+	sh, _, _ := procGetSystemMetrics.Call(smCyScreen)
+	title := T("gui.title", w.State.ProductName, w.State.Version)
+	hwnd, _, _ := procCreateWindowExW.Call(0,
+		uintptr(unsafe.Pointer(windows.StringToUTF16Ptr(wizardClass))),
+		uintptr(unsafe.Pointer(windows.StringToUTF16Ptr(title))),
+		wsCaption|wsSysMenu|wsMinimizeBox,
+		(sw-wndWidth)/2, (sh-wndHeight)/2, wndWidth, wndHeight,
+		0, 0, uintptr(g.instance), 0)
+	if hwnd == 0 {
+		// 无法创建窗口时退回控制台
+		prepareConsole()
+		ui = &consoleUI{}
+		ui.Run(w, install)
+		return
+	}
+	g.mu.Lock()
+	g.hwnd = windows.HWND(hwnd)
+	g.mu.Unlock()
+
+	g.hTitle = g.control("STATIC", "", 0, 20, 15, 490, 30, 0)
+	g.setFont(g.hTitle, g.titleFont)
+	g.hBack = g.control("BUTTON", T("gui.back"), bsPushButton|wsTabStop, 250, 350, 85, 28, idBack)
+	g.hNext = g.control("BUTTON", T("gui.next"), bsDefPushButton|wsTabStop, 340, 350, 85, 28, idNext)
+	g.hCancel = g.control("BUTTON", T("common.cancel"), bsPushButton|wsTabStop, 435, 350, 80, 28, idCancel)
+	g.showPage()
+
+	procShowWindow.Call(hwnd, swShow)
+	procUpdateWindow.Call(hwnd)
+	procSetForegroundWindow.Call(hwnd)
+
+	var m winMsg
+	for {
+		r, _, _ := procGetMessageW.Call(uintptr(unsafe.Pointer(&m)), 0, 0, 0)
+		if int32(r) <= 0 {
+			break
+		}
+		if d, _, _ := procIsDialogMessageW.Call(hwnd, uintptr(unsafe.Pointer(&m))); d != 0 {
+			continue
+		}
+		procTranslateMessage.Call(uintptr(unsafe.Pointer(&m)))
+		procDispatchMessageW.Call(uintptr(unsafe.Pointer(&m)))
+	}
+	g.mu.Lock()
+	g.hwnd = 0
+	g.mu.Unlock()
+}
+
+// ---------- 页面 ----------
+
+func (g *guiUI) control(class, text string, style uintptr, x, y, w, h int, id uintptr) windows.HWND {
+	return g.childOf(g.hwnd, class, text, style, 0, x, y, w, h, id)
+}
+
+func (g *guiUI) childOf(parent windows.HWND, class, text string, style, exStyle uintptr, x, y, w, h int, id uintptr) windows.HWND {
+	hwnd, _, _ := procCreateWindowExW.Call(exStyle,
+		uintptr(unsafe.Pointer(windows.StringToUTF16Ptr(class))),
+		uintptr(unsafe.Pointer(windows.StringToUTF16Ptr(text))),
+		wsChild|wsVisible|style,
+		uintptr(x), uintptr(y), uintptr(w), uintptr(h),
+		uintptr(parent), id, uintptr(g.instance), 0)
+	g.setFont(windows.HWND(hwnd), g.font)
+	return windows.HWND(hwnd)
+}
+
+// pageControl 创建属于当前页面的控件，切换页面时统一销毁
+func (g *guiUI) pageControl(class, text string, style, exStyle uintptr, x, y, w, h int, id uintptr) windows.HWND {
+	hwnd := g.childOf(g.hwnd, class, text, style, exStyle, x, y, w, h, id)
+	g.page = append(g.page, hwnd)
+	return hwnd
+}
+
+func (g *guiUI) setFont(hwnd windows.HWND, font uintptr) {
+	procSendMessageW.Call(uintptr(hwnd), wmSetFont, font, 1)
+}
+
+func (g *guiUI) showPage() {
+	for _, h := range g.page {
+		procDestroyWindow.Call(uintptr(h))
+	}
+	g.page = nil
+	g.hAccept, g.hDirEdit, g.hProgress, g.hStatus, g.hLog, g.hLaunch, g.hAutostart = 0, 0, 0, 0, 0, 0, 0
+	g.hComponents = nil
+
+	st := &g.w.State
+	next := T("gui.next")
+	switch g.w.Page {
+	case pageWelcome:
+		setText(g.hTitle, T("gui.welcome_title"))
+		text := T("gui.welcome_text", st.ProductName, st.Version)
+		if note := g.w.VersionNote(); note != "" {
+			text += "\n\n" + note
+		}
+		g.pageControl("STATIC", crlf(text), 0, 0, 20, 60, 490, 200, 0)
+	case pageLicense:
+		setText(g.hTitle, T("gui.license_title"))
+		g.pageControl("STATIC", T("gui.license_intro"), 0, 0, 20, 55, 490, 20, 0)
+		g.pageControl("EDIT", crlf(st.LicenseText), esMultiline|esReadOnly|esAutoVScroll|wsVScroll|wsTabStop,
+			wsExClientEdge, 20, 78, 490, 225, 0)
+		g.hAccept = g.pageControl("BUTTON", T("gui.license_accept"), bsAutoCheckBox|wsTabStop, 0, 20, 310, 490, 24, idAccept)
+		setCheck(g.hAccept, st.LicenseAccepted)
+	case pageDirectory:
+		setText(g.hTitle, T("gui.dir_title"))
+		g.pageControl("STATIC", crlf(T("gui.dir_text", st.ProductName)),
+			0, 0, 20, 60, 490, 40, 0)
+		g.hDirEdit = g.pageControl("EDIT", st.InstallDir, esAutoHScroll|wsTabStop, wsExClientEdge, 20, 110, 395, 24, 0)
+		g.pageControl("BUTTON", T("gui.browse"), bsPushButton|wsTabStop, 0, 425, 109, 85, 27, idBrowse)
+		if st.AutostartOffered {
+			g.hAutostart = g.pageControl("BUTTON", T("gui.autostart", st.ProductName), bsAutoCheckBox|wsTabStop, 0, 20, 150, 490, 24, idAutostart)
+			setCheck(g.hAutostart, st.Autostart)
+		}
+	case pageComponents:
+		setText(g.hTitle, T("gui.components_title"))
+		for i, c := range st.Components {
+			y := 55 + i*46
+			style := uintptr(bsAutoCheckBox | wsTabStop)
+			h := g.pageControl("BUTTON", fmt.Sprintf("%s (%s)", c.Name, formatSize(c.Size)), style, 0, 20, y, 490, 22, uintptr(idComponent+i))
+			setCheck(h, c.Selected || c.Required)
+			if c.Required {
+				procEnableWindow.Call(uintptr(h), 0)
+			}
+			g.hComponents = append(g.hComponents, h)
+			g.pageControl("STATIC", c.Description, 0, 0, 38, y+22, 472, 20, 0)
+		}
+	case pageProgress:
+		setText(g.hTitle, T("gui.progress_title"))
+		g.hStatus = g.pageControl("STATIC", st.Status, 0, 0, 20, 60, 490, 20, 0)
+		g.hProgress = g.pageControl("msctls_progress32", "", 0, 0, 20, 85, 490, 20, 0)
+		procSendMessageW.Call(uintptr(g.hProgress), pbmSetRange32, 0, 100)
+		g.hLog = g.pageControl("EDIT", "", esMultiline|esReadOnly|esAutoVScroll|wsVScroll, wsExClientEdge, 20, 115, 490, 220, 0)
+	case pageFinish:
+		setText(g.hTitle, T("gui.finish_title"))
+		text := crlf(T("gui.finish_text", st.ProductName, st.InstallDir))
+		if st.Err != nil {
+			setText(g.hTitle, T("gui.failed_title"))
+			text = crlf(T("gui.failed_text", st.Err))
+		}
+		g.pageControl("STATIC", text, 0, 0, 20, 60, 490, 120, 0)
+		if st.CanLaunch {
+			g.hLaunch = g.pageControl("BUTTON", T("gui.launch", st.ProductName), bsAutoCheckBox|wsTabStop, 0, 20, 190, 490, 24, idLaunch)
+			setCheck(g.hLaunch, st.LaunchApp)
+		}
+		next = T("gui.finish")
+	}
+	if g.w.Page != pageFinish && g.w.Page != pageProgress {
+		if i := g.w.index(); g.w.pages[i+1] == pageProgress {
+			next = T("gui.install")
+		}
+	}
+	setText(g.hNext, next)
+	enable(g.hBack, g.w.CanBack())
+	enable(g.hNext, g.w.Page != pageProgress)
+	enable(g.hCancel, g.w.CanCancel())
+}
+
+// collect 将当前页控件中的输入写回向导状态
+func (g *guiUI) collect() {
+	st := &g.w.State
+	switch g.w.Page {
+	case pageLicense:
+		st.LicenseAccepted = getCheck(g.hAccept)
+	case pageDirectory:
+		st.InstallDir = getText(g.hDirEdit)
+		if g.hAutostart != 0 {
+			st.Autostart = getCheck(g.hAutostart)
+		}
+	case pageComponents:
+		for i, h := range g.hComponents {
+			st.Components[i].Selected = getCheck(h) || st.Components[i].Required
+		}
+	case pageFinish:
+		st.LaunchApp = g.hLaunch != 0 && getCheck(g.hLaunch)
+	}
+}
+
+func (g *guiUI) onNext() {
+	g.collect()
+	act, err := g.w.Next()
+	if err != nil {
+		g.messageBox(wizardErrorText(err), mbOK|mbIconWarning)
+		return
+	}
+	switch act {
+	case actionFinish:
+		procDestroyWindow.Call(uintptr(g.hwnd))
+		return
+	case actionInstall:
+		g.showPage()
+		go g.runInstall()
+		return
+	}
+	g.showPage()
+}
+
+func (g *guiUI) runInstall() {
+	exe, err := g.install(func(percent int, status string) {
+		g.mu.Lock()
+		g.percent, g.status = percent, status
+		hwnd := g.hwnd
+		g.mu.Unlock()
+		procPostMessageW.Call(uintptr(hwnd), wmAppUpdate, 0, 0)
+	})
+	g.mu.Lock()
+	g.exePath, g.err = exe, err
+	hwnd := g.hwnd
+	g.mu.Unlock()
+	procPostMessageW.Call(uintptr(hwnd), wmAppUpdate, 0, 0)
+	procPostMessageW.Call(uintptr(hwnd), wmAppDone, 0, 0)
+}
+
+// onUpdate 在 UI 线程中刷新进度与日志
+func (g *guiUI) onUpdate() {
+	g.mu.Lock()
+	lines := g.logLines
+	g.logLines = nil
+	percent, status := g.percent, g.status
+	g.mu.Unlock()
+
+	if g.w.Page != pageProgress {
+		return
+	}
+	g.w.SetProgress(percent, status)
+	setText(g.hStatus, g.w.State.Status)
+	procSendMessageW.Call(uintptr(g.hProgress), pbmSetPos, uintptr(g.w.State.Progress), 0)
+	if len(lines) > 0 {
+		text := windows.StringToUTF16Ptr(strings.Join(lines, "\r\n") + "\r\n")
+		n, _, _ := procGetWindowTextLengthW.Call(uintptr(g.hLog))
+		procSendMessageW.Call(uintptr(g.hLog), emSetSel, n, n)
+		procSendMessageW.Call(uintptr(g.hLog), emReplaceSel, 0, uintptr(unsafe.Pointer(text)))
+	}
+}
+
+func (g *guiUI) onDone() {
+	g.mu.Lock()
+	exe, err := g.exePath, g.err
+	g.mu.Unlock()
+	g.w.InstallDone(err, exe != "")
+	g.showPage()
+}
+
+func (g *guiUI) onCancel() {
+	if g.w.Page == pageFinish {
+		g.collect()
+		procDestroyWindow.Call(uintptr(g.hwnd))
+		return
+	}
+	if !g.w.CanCancel() {
+		return
+	}
+	if g.messageBox(T("gui.confirm_exit"), mbYesNo|mbIconWarning) == idYes {
+		g.w.Cancel()
+		procDestroyWindow.Call(uintptr(g.hwnd))
+	}
+}
+
+// onBrowse 选择文件夹，并在末尾补上产品名
+func (g *guiUI) onBrowse() {
+	title := windows.StringToUTF16Ptr(T("gui.browse_title"))
+	display := make([]uint16, windows.MAX_PATH)
+	bi := browseInfo{
+		Owner:       g.hwnd,
+		DisplayName: &display[0],
+		Title:       title,
+		Flags:       0x1 | 0x40, // BIF_RETURNONLYFSDIRS | BIF_NEWDIALOGSTYLE
+	}
+	pidl, _, _ := procSHBrowseForFolderW.Call(uintptr(unsafe.Pointer(&bi)))
+	if pidl == 0 {
+		return
+	}
+	defer procCoTaskMemFree.Call(pidl)
+	buf := make([]uint16, windows.MAX_LONG_PATH)
+	if r, _, _ := procSHGetPathFromIDListW.Call(pidl, uintptr(unsafe.Pointer(&buf[0]))); r == 0 {
+		return
+	}
+	dir := windows.UTF16ToString(buf)
+	name := g.w.State.ProductName
+	if !strings.EqualFold(filepath.Base(dir), name) {
+		dir = filepath.Join(dir, name)
+	}
+	setText(g.hDirEdit, dir)
+}
+
+// onComponent 勾选变化后按依赖关系刷新全部组件复选框
+func (g *guiUI) onComponent(i int) {
+	g.w.SetComponent(i, getCheck(g.hComponents[i]))
+	for j, h := range g.hComponents {
+		setCheck(h, g.w.State.Components[j].Selected)
+	}
+}
+
+func wizardWndProc(hwnd windows.HWND, msg uint32, wparam, lparam uintptr) uintptr {
+	g := activeGUI
+	if g != nil && hwnd == g.hwnd {
+		switch msg {
+		case wmCommand:
+			switch wparam & 0xFFFF {
+			case idNext:
+				g.onNext()
+			case idBack:
+				g.collect()
+				g.w.Back()
+				g.showPage()
+			case idCancel:
+				g.onCancel()
+			case idBrowse:
+				g.onBrowse()
+			default:
+				if i := int(wparam&0xFFFF) - idComponent; i >= 0 && i < len(g.hComponents) {
+					g.onComponent(i)
+				}
+			}
+			return 0
+		case 0x0010: // WM_CLOSE
+			g.onCancel()
+			return 0
+		case wmAppUpdate:
+			g.onUpdate()
+			return 0
+		case wmAppDone:
+			g.onDone()
+			return 0
+		case wmAppChoose:
+			return uintptr(g.choiceDialog(g.pendingChoice))
+		case wmDestroy:
+			procPostQuitMessage.Call(0)
+			return 0
+		}
+	}
+	r, _, _ := procDefWindowProcW.Call(uintptr(hwnd), uintptr(msg), wparam, lparam)
+	return r
+}
+
+// ---------- 选择对话框 ----------
+
+// choiceDialog 模态对话框：一段说明文字与若干按钮，返回所选按钮序号；关闭窗口视为选择 def
+func (g *guiUI) choiceDialog(req *choiceRequest) int {
+	const w, h = 460, 220
+	sw, _, _ := procGetSystemMetrics.Call(smCxScreen)
+	sh, _, _ := procGetSystemMetrics.Call(smCyScreen)
+	title := "Setup"
+	if g.w != nil {
+		title = g.w.State.ProductName
+	}
+	dlg, _, _ := procCreateWindowExW.Call(wsExDlgModalFrame,
+		uintptr(unsafe.Pointer(windows.StringToUTF16Ptr(choiceClass))),
+		uintptr(unsafe.Pointer(windows.StringToUTF16Ptr(title))),
+		wsPopup|wsCaption|wsSysMenu,
+		(sw-w)/2, (sh-h)/2, w, h,
+		uintptr(g.hwnd), 0, uintptr(g.instance), 0)
+	if dlg == 0 {
+		return req.def
+	}
+	g.childOf(windows.HWND(dlg), "STATIC", crlf(req.msg), 0, 0, 15, 12, w-40, h-95, 0)
+	bw := 110
+	x := w - 25 - len(req.choices)*(bw+8)
+	for i, c := range req.choices {
+		style := uintptr(bsPushButton | wsTabStop)
+		if i == req.def {
+			style = bsDefPushButton | wsTabStop
+		}
+		g.childOf(windows.HWND(dlg), "BUTTON", c, style, 0, x+i*(bw+8), h-75, bw, 28, uintptr(idChoiceBase+i))
+	}
+
+	if g.hwnd != 0 {
+		procEnableWindow.Call(uintptr(g.hwnd), 0)
+	}
+	procShowWindow.Call(dlg, swShow)
+	procSetForegroundWindow.Call(dlg)
+
+	g.choice = -1
+	var m winMsg
+	for g.choice < 0 {
+		r, _, _ := procGetMessageW.Call(uintptr(unsafe.Pointer(&m)), 0, 0, 0)
+		if int32(r) <= 0 {
+			break
+		}
+		if d, _, _ := procIsDialogMessageW.Call(dlg, uintptr(unsafe.Pointer(&m))); d != 0 {
+			continue
+		}
+		procTranslateMessage.Call(uintptr(unsafe.Pointer(&m)))
+		procDispatchMessageW.Call(uintptr(unsafe.Pointer(&m)))
+	}
+	if g.hwnd != 0 {
+		procEnableWindow.Call(uintptr(g.hwnd), 1)
+		procSetForegroundWindow.Call(uintptr(g.hwnd))
+	}
+	procDestroyWindow.Call(dlg)
+
+	if g.choice < 0 || g.choice >= len(req.choices) {
+		return req.def
+	}
+	return g.choice
+}
+
+func choiceWndProc(hwnd windows.HWND, msg uint32, wparam, lparam uintptr) uintptr {
+	if g := activeGUI; g != nil {
+		switch msg {
+		case wmCommand:
+			if id := int(wparam & 0xFFFF); id >= idChoiceBase {
+				g.choice = id - idChoiceBase
+			}
+			return 0
+		case 0x0010: // WM_CLOSE：越界序号由 choiceDialog 换成默认值
+			g.choice = choiceClosed
+			return 0
+		}
+	}
+	r, _, _ := procDefWindowProcW.Call(uintptr(hwnd), uintptr(msg), wparam, lparam)
+	return r
+}
+
+// ---------- 小工具 ----------
+
+func setText(hwnd windows.HWND, s string) {
+	procSetWindowTextW.Call(uintptr(hwnd), uintptr(unsafe.Pointer(windows.StringToUTF16Ptr(s))))
+}
+
+func getText(hwnd windows.HWND) string {
+	n, _, _ := procGetWindowTextLengthW.Call(uintptr(hwnd))
+	buf := make([]uint16, n+1)
+	procGetWindowTextW.Call(uintptr(hwnd), uintptr(unsafe.Pointer(&buf[0])), n+1)
+	return windows.UTF16ToString(buf)
+}
+
+func setCheck(hwnd windows.HWND, on bool) {
+	v := uintptr(0)
+	if on {
+		v = bstChecked
+	}
+	procSendMessageW.Call(uintptr(hwnd), bmSetCheck, v, 0)
+}
+
+func getCheck(hwnd windows.HWND) bool {
+	r, _, _ := procSendMessageW.Call(uintptr(hwnd), bmGetCheck, 0, 0)
+	return r == bstChecked
+}
+
+func enable(hwnd windows.HWND, on bool) {
+	v := uintptr(0)
+	if on {
+		v = 1
+	}
+	procEnableWindow.Call(uintptr(hwnd), v)
+}
+
+// crlf Win32 编辑框需要 \r\n 换行
+func crlf(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\n", "\r\n")
+}
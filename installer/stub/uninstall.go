@@ -0,0 +1,308 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// uninstallerName 安装目录中卸载程序的文件名
+func uninstallerName() string {
+	if runtime.GOOS == "windows" {
+		return "uninstall.exe"
+	}
+	return "uninstall"
+}
+
+// isUninstallMode 判断是否以卸载模式运行：显式 /UNINSTALL 开关，
+// 或自身 trailer 为 uninstallMagic（由 createUninstaller 生成）。不再依赖文件名。
+func isUninstallMode() bool {
+	if hasSwitch("UNINSTALL") {
+		return true
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return false
+	}
+	f, err := os.Open(exe)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	t, err := readSelfTrailer(f)
+	return err == nil && t.Magic == uninstallMagic
+}
+
+// createUninstaller 将当前 stub 去掉内置归档后写为卸载程序，改为携带只含 meta.json 的归档，
+// 并追加 uninstallMagic trailer。升级时会覆盖旧的卸载程序，保证其与新版本一致。
+func createUninstaller(installDir string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	src, err := os.Open(exe)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	stubSize := info.Size()
+	if t, err := readSelfTrailer(src); err == nil {
+		stubSize = t.StubSize
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	dst := filepath.Join(installDir, uninstallerName())
+	if strings.EqualFold(dst, exe) {
+		return nil // 修改模式：正在运行的就是卸载程序
+	}
+	archive, err := metaArchive(meta)
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o755)
+	if err != nil {
+		return err
+	}
+	if _, err := io.CopyN(out, src, stubSize); err != nil {
+		out.Close()
+		return err
+	}
+	if _, err := out.Write(archive); err != nil {
+		out.Close()
+		return err
+	}
+	trailer := make([]byte, trailerSize)
+	binary.LittleEndian.PutUint64(trailer[:8], uint64(len(archive)))
+	copy(trailer[8:], uninstallMagic)
+	if _, err := out.Write(trailer); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// metaArchive 生成只含 meta.json 的 tar.gz，卸载时据此核对安装记录所属的产品
+func metaArchive(m InstallMeta) ([]byte, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: "meta.json", Mode: 0o644, Size: int64(len(data))}); err != nil {
+		return nil, err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// embeddedMeta 读取 setup 或卸载程序携带的 meta.json；旧版本生成的卸载程序不携带归档
+func embeddedMeta(exe string) (*InstallMeta, error) {
+	f, err := os.Open(exe)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	t, err := readSelfTrailer(f)
+	if err != nil {
+		return nil, err
+	}
+	if t.ArchiveLen == 0 {
+		return nil, errors.New("no embedded meta")
+	}
+	if _, err := f.Seek(t.StubSize, io.SeekStart); err != nil {
+		return nil, err
+	}
+	archive := make([]byte, t.ArchiveLen)
+	if _, err := io.ReadFull(f, archive); err != nil {
+		return nil, err
+	}
+	files, err := untarGzToMemory(archive)
+	if err != nil {
+		return nil, err
+	}
+	mf := findFile(files, "meta.json")
+	if mf == nil {
+		return nil, errors.New("no embedded meta")
+	}
+	var m InstallMeta
+	if err := json.Unmarshal(mf.Data, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// checkUninstallTarget 确认安装目录确实是自身所代表产品的安装目录。/UNINSTALL 可用于任意 setup，
+// 不能仅凭自身所在的目录就删除其中的内容（例如在“下载”目录中运行 setup /UNINSTALL）。
+func checkUninstallTarget(exe, installDir string, rec *installRecord) error {
+	m, err := embeddedMeta(exe)
+	switch {
+	case err == nil:
+		if m.ProductName != rec.ProductName {
+			return errors.New(T("uninstall.wrong_product", installDir, rec.ProductName, m.ProductName))
+		}
+	case strings.EqualFold(filepath.Clean(exe), filepath.Join(filepath.Clean(rec.InstallDir), uninstallerName())):
+		// 旧版本生成的卸载程序不携带 meta，位于记录中的安装目录即可
+	default:
+		return errors.New(T("uninstall.no_meta", err))
+	}
+	if !strings.EqualFold(filepath.Clean(rec.InstallDir), filepath.Clean(installDir)) {
+		return errors.New(T("uninstall.record_mismatch", installDir, rec.InstallDir))
+	}
+	meta.ProductName = rec.ProductName
+	_, err = planClean(installDir)
+	return err
+}
+
+// removeInstalledFiles 只删除安装记录中的文件与安装程序生成的文件，用户放入安装目录的其他内容保持不变；
+// 自身与安装目录交由 scheduleSelfDelete 处理
+func removeInstalledFiles(rec *installRecord, exe string) {
+	installDir := filepath.Clean(rec.InstallDir)
+	names := append([]string{recordFileName, payloadCacheName}, rec.Files...)
+	dirs := map[string]bool{}
+	for _, name := range names {
+		p, err := safeJoin(installDir, name)
+		if err != nil {
+			logAt(levelWarn, err.Error())
+			continue
+		}
+		if strings.EqualFold(p, exe) {
+			continue
+		}
+		for d := filepath.Dir(p); d != installDir && strings.HasPrefix(d, installDir); d = filepath.Dir(d) {
+			dirs[d] = true
+		}
+		err = os.Remove(p)
+		if os.IsNotExist(err) {
+			continue
+		}
+		logFileOp("remove", p, err)
+		if err != nil {
+			_ = deleteOnReboot(p)
+		}
+	}
+	logs := filepath.Join(installDir, "logs")
+	if err := os.RemoveAll(logs); err != nil {
+		logFileOp("remove", logs, err)
+	}
+	// 删除变空的目录，由深到浅；仍有其他内容的目录保留
+	var list []string
+	for d := range dirs {
+		list = append(list, d)
+	}
+	sort.Slice(list, func(i, j int) bool { return len(list[i]) > len(list[j]) })
+	for _, d := range list {
+		if os.Remove(d) == nil {
+			logFileOp("remove", d, nil)
+		}
+	}
+}
+
+// runUninstall 卸载流程：读取安装记录 -> 删除快捷方式与注册表 -> 删除文件 -> 计划删除自身与目录。
+func runUninstall() int {
+	logT("uninstall.start")
+	exe, err := os.Executable()
+	if err != nil {
+		reportError(T("uninstall.locate_failed", err))
+		return exitFatal
+	}
+	installDir := filepath.Dir(exe)
+
+	rec, err := loadInstallRecord(installDir)
+	if err != nil {
+		reportError(T("uninstall.no_record", installDir, err))
+		return exitFatal
+	}
+	if err := checkUninstallTarget(exe, installDir, rec); err != nil {
+		reportError(err.Error())
+		return exitFatal
+	}
+	// 默认沿用安装时的界面语言
+	loadTranslationDir(installDir)
+	selectLanguage(rec.Language)
+	_ = runLog.Open(rec.ProductName + "-uninstall")
+	logT("install.product", rec.ProductName, rec.Version)
+	release, code := lockInstance(rec.ProductName, "", 0)
+	defer release()
+	if code != exitSuccess {
+		runLog.Close(nil)
+		return code
+	}
+	if ui.Choose(T("uninstall.confirm", rec.ProductName), []string{T("uninstall.button"), T("common.cancel")}, 0) != 0 {
+		logT("uninstall.cancelled")
+		runLog.Close(nil)
+		return exitCancelled
+	}
+
+	hc := hookContext{InstallDir: installDir, ProductName: rec.ProductName, Version: rec.Version}
+	runLog.Step("pre-uninstall")
+	if err := runHooks(rec.Hooks, hookPreUninstall, hc); err != nil {
+		reportError(T("uninstall.aborted", err))
+		runLog.Close(err)
+		return exitCodeOf(err)
+	}
+	runLog.Step("remove-services")
+	removeServices(rec)
+	runLog.Step("close-apps")
+	if left := closeRunningInstances(installDir, 0); len(left) > 0 {
+		warnT("uninstall.still_running")
+	}
+
+	// post-uninstall 钩子在文件删除后执行，先将其引用的文件复制到临时目录
+	postDir, cleanupPost, err := stageHookFiles(rec.Hooks, hookPostUninstall, nil, installDir)
+	if err != nil {
+		warnT("uninstall.post_hooks_failed", err)
+	}
+	defer cleanupPost()
+
+	runLog.Step("remove-entries")
+	removePlatformEntries(rec)
+	revertEnvironment(rec)
+	removeAutostart(rec)
+	forgetInstallDir(rec.ProductName)
+
+	// 自身仍在运行，先删除其余内容，自身与目录交由 scheduleSelfDelete 处理
+	runLog.Step("remove-files")
+	removeInstalledFiles(rec, exe)
+	if postDir != "" {
+		runLog.Step("post-uninstall")
+		hc.BaseDir = postDir
+		if err := runHooks(rec.Hooks, hookPostUninstall, hc); err != nil {
+			logAt(levelWarn, err.Error())
+		}
+	}
+	runLog.Step("self-delete")
+	if err := scheduleSelfDelete(exe, installDir); err != nil {
+		warnT("uninstall.self_delete_failed", err)
+	} else {
+		logT("uninstall.self_delete_scheduled")
+	}
+	runLog.Close(nil)
+	ui.Info(T("uninstall.done", rec.ProductName))
+	return exitSuccess
+}
@@ -2,6 +2,5 @@
 
 package main
 
-func isUninstallMode() bool              { return false }
-func createUninstaller(dir string) error { _ = dir; return nil }
-func runUninstall()                      {}
+// 非 Windows 平台没有注册表与 .lnk 快捷方式
+func removePlatformEntries(rec *installRecord) { _ = rec }
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+
+	"exe_installer/installer/internal/version"
+)
+
+// 相同版本已安装时的处理，对应 meta 中的 sameVersion
+const (
+	sameVersionReinstall = "reinstall" // 默认：重新安装
+	sameVersionRepair    = "repair"    // 只校验并恢复缺失或损坏的文件
+	sameVersionAsk       = "ask"       // 让用户在修复、重新安装与取消之间选择；静默安装时重新安装
+	sameVersionBlock     = "block"     // 不安装
+)
+
+// versionPolicy 与 meta.json 中 versionPolicy 对应
+type versionPolicy struct {
+	AllowDowngrade           bool   `json:"allowDowngrade"`
+	SameVersion              string `json:"sameVersion"`
+	UninstallOlderMajorFirst bool   `json:"uninstallOlderMajorFirst"`
+}
+
+// sameVersionAction 相同版本已安装时的处理；用户取消时返回空
+func sameVersionAction(m InstallMeta) string {
+	switch m.VersionPolicy.SameVersion {
+	case sameVersionRepair, sameVersionBlock:
+		return m.VersionPolicy.SameVersion
+	case sameVersionAsk:
+		choices := []string{T("maintenance.repair"), T("version.reinstall"), T("common.cancel")}
+		switch ui.Choose(T("version.same_ask", m.ProductName, m.Version), choices, 1) {
+		case 0:
+			return sameVersionRepair
+		case 1:
+			return sameVersionReinstall
+		}
+		return ""
+	}
+	return sameVersionReinstall
+}
+
+// checkInstalledVersion 比较已安装版本（来自安装记录）与 setup 的版本：
+// 禁止的降级与需要先卸载的旧主版本返回错误，相同版本返回 true。
+// 任一版本无法解析时只记录警告，按不同版本处理。
+func checkInstalledVersion(m InstallMeta, rec *installRecord) (same bool, err error) {
+	if rec == nil || rec.ProductName != m.ProductName || rec.Version == "" {
+		return false, nil
+	}
+	cur, err1 := version.Parse(rec.Version)
+	next, err2 := version.Parse(m.Version)
+	if err1 != nil || err2 != nil {
+		warnT("version.unparsable", rec.Version, m.Version)
+		return rec.Version == m.Version, nil
+	}
+	c := next.Compare(cur)
+	switch {
+	case c < 0 && !m.VersionPolicy.AllowDowngrade:
+		return false, withExitCode(exitProductVersion, errors.New(T("version.downgrade", rec.Version, m.Version)))
+	case c > 0 && m.VersionPolicy.UninstallOlderMajorFirst && next.Major() > cur.Major():
+		return false, withExitCode(exitProductVersion, errors.New(T("version.uninstall_first", rec.Version, m.Version, rec.InstallDir)))
+	}
+	return c == 0, nil
+}
+
+// sameVersion 报告 a 与 b 是否为同一版本（"1.0" 与 "1.0.0" 相同）；无法解析时按字符串比较
+func sameVersion(a, b string) bool {
+	va, err1 := version.Parse(a)
+	vb, err2 := version.Parse(b)
+	if err1 != nil || err2 != nil {
+		return a == b
+	}
+	return va.Compare(vb) == 0
+}
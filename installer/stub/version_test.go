@@ -0,0 +1,99 @@
+package main
+
+import "testing"
+
+func TestCheckInstalledVersion(t *testing.T) {
+	setupTestInstall(t)
+	rec := func(v string) *installRecord {
+		return &installRecord{ProductName: "app", Version: v, InstallDir: "/opt/app"}
+	}
+	tests := []struct {
+		name      string
+		installed *installRecord
+		version   string
+		policy    versionPolicy
+		same      bool
+		blocked   bool
+	}{
+		{"not installed", nil, "1.0.0", versionPolicy{}, false, false},
+		{"other product", &installRecord{ProductName: "other", Version: "1.0.0"}, "1.0.0", versionPolicy{}, false, false},
+		{"upgrade", rec("1.0.0"), "1.1.0", versionPolicy{}, false, false},
+		{"same", rec("1.0.0"), "1.0.0", versionPolicy{}, true, false},
+		{"same with fewer parts", rec("1.0"), "1.0.0", versionPolicy{}, true, false},
+		{"same ignoring build metadata", rec("1.0.0+1"), "1.0.0+2", versionPolicy{}, true, false},
+		{"downgrade blocked", rec("2.0.0"), "1.9.9", versionPolicy{}, false, true},
+		{"downgrade allowed", rec("2.0.0"), "1.9.9", versionPolicy{AllowDowngrade: true}, false, false},
+		{"release to its prerelease", rec("1.0.0"), "1.0.0-rc.1", versionPolicy{}, false, true},
+		{"prerelease to release", rec("1.0.0-rc.1"), "1.0.0", versionPolicy{}, false, false},
+		{"prerelease ordering", rec("1.0.0-beta.11"), "1.0.0-beta.2", versionPolicy{}, false, true},
+		{"four-part upgrade", rec("1.2.3.4"), "1.2.3.10", versionPolicy{}, false, false},
+		{"four-part downgrade", rec("1.2.3.10"), "1.2.3.4", versionPolicy{}, false, true},
+		{"major upgrade without rule", rec("1.5.0"), "2.0.0", versionPolicy{}, false, false},
+		{"major upgrade needs uninstall", rec("1.5.0"), "2.0.0", versionPolicy{UninstallOlderMajorFirst: true}, false, true},
+		{"minor upgrade with major rule", rec("1.5.0"), "1.6.0", versionPolicy{UninstallOlderMajorFirst: true}, false, false},
+		{"major downgrade allowed with major rule", rec("2.0.0"), "1.0.0", versionPolicy{AllowDowngrade: true, UninstallOlderMajorFirst: true}, false, false},
+		{"unparsable different", rec("build-42"), "1.0.0", versionPolicy{}, false, false},
+		{"unparsable same", rec("nightly"), "nightly", versionPolicy{}, true, false},
+	}
+	for _, tt := range tests {
+		m := InstallMeta{ProductName: "app", Version: tt.version, VersionPolicy: tt.policy}
+		same, err := checkInstalledVersion(m, tt.installed)
+		if same != tt.same || (err != nil) != tt.blocked {
+			t.Errorf("%s: checkInstalledVersion = %v, %v; want same %v blocked %v", tt.name, same, err, tt.same, tt.blocked)
+			continue
+		}
+		if err != nil && exitCodeOf(err) != exitProductVersion {
+			t.Errorf("%s: exit code = %d, want %d", tt.name, exitCodeOf(err), exitProductVersion)
+		}
+	}
+}
+
+func TestSameVersionAction(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+		silent bool
+		input  string
+		want   string
+	}{
+		{"default", "", false, "", sameVersionReinstall},
+		{"reinstall", sameVersionReinstall, false, "", sameVersionReinstall},
+		{"repair", sameVersionRepair, false, "", sameVersionRepair},
+		{"block", sameVersionBlock, false, "", sameVersionBlock},
+		{"ask repair", sameVersionAsk, false, "1\n", sameVersionRepair},
+		{"ask reinstall", sameVersionAsk, false, "2\n", sameVersionReinstall},
+		{"ask default", sameVersionAsk, false, "\n", sameVersionReinstall},
+		{"ask cancel", sameVersionAsk, false, "3\n", ""},
+		{"ask silent", sameVersionAsk, true, "", sameVersionReinstall},
+	}
+	oldUI := ui
+	t.Cleanup(func() { ui = oldUI })
+	for _, tt := range tests {
+		ui = &consoleUI{silent: tt.silent}
+		setTestStdin(t, tt.input)
+		m := InstallMeta{ProductName: "app", Version: "1.0.0", VersionPolicy: versionPolicy{SameVersion: tt.policy}}
+		if got := sameVersionAction(m); got != tt.want {
+			t.Errorf("%s: sameVersionAction = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestVersionNote(t *testing.T) {
+	tests := []struct {
+		installed, version string
+		want               string
+	}{
+		{"", "1.0.0", ""},
+		{"1.0.0", "1.0.0", T("wizard.same_version", "1.0.0")},
+		{"1.0", "1.0.0", T("wizard.same_version", "1.0.0")},
+		{"1.0.0", "1.1.0", T("wizard.version_change", "1.0.0", "1.1.0")},
+		{"1.0.0-rc.1", "1.0.0", T("wizard.version_change", "1.0.0-rc.1", "1.0.0")},
+	}
+	for _, tt := range tests {
+		w := &wizard{}
+		w.State.InstalledVersion, w.State.Version = tt.installed, tt.version
+		if got := w.VersionNote(); got != tt.want {
+			t.Errorf("VersionNote(%q, %q) = %q, want %q", tt.installed, tt.version, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+)
+
+// wizardPage 安装向导页面
+type wizardPage int
+
+const (
+	pageWelcome wizardPage = iota
+	pageLicense
+	pageDirectory
+	pageComponents
+	pageProgress
+	pageFinish
+)
+
+func (p wizardPage) String() string {
+	switch p {
+	case pageWelcome:
+		return "welcome"
+	case pageLicense:
+		return "license"
+	case pageDirectory:
+		return "directory"
+	case pageComponents:
+		return "components"
+	case pageProgress:
+		return "progress"
+	case pageFinish:
+		return "finish"
+	}
+	return "unknown"
+}
+
+// wizardAction Next 之后前端需要执行的动作
+type wizardAction int
+
+const (
+	actionNone    wizardAction = iota // 仅切换页面
+	actionInstall                     // 进入进度页，开始安装
+	actionFinish                      // 向导结束
+)
+
+var (
+	errLicenseNotAccepted  = errors.New("license not accepted")
+	errInstallDirInvalid   = errors.New("install directory must be an absolute path")
+	errInstallDirProtected = errors.New("install directory is a system or user folder")
+	errWizardBusy          = errors.New("installation in progress")
+)
+
+// wizardComponent 组件页中的一项
+type wizardComponent struct {
+	Name        string
+	Description string
+	Size        int64
+	Depends     []string
+	Selected    bool
+	Required    bool
+}
+
+// wizardState 向导收集与展示的数据，前端直接读写其中的字段
+type wizardState struct {
+	ProductName      string
+	Version          string
+	LicenseText      string
+	LicenseAccepted  bool
+	InstallDir       string
+	Components       []wizardComponent
+	Progress         int // 0-100
+	Status           string
+	CanLaunch        bool   // 安装成功且找到主程序
+	LaunchApp        bool   // 完成页“运行程序”复选框
+	LaunchOffered    bool   // 是否提供“运行程序”选项
+	LaunchChecked    bool   // “运行程序”的默认值
+	AutostartOffered bool   // 目录页是否显示“自动启动”复选框
+	Autostart        bool   // 是否创建自动启动项
+	Maintenance      bool   // 修改已安装的组件：只显示组件页
+	InstalledVersion string // 安装记录中的版本，未安装时为空
+	Err              error
+}
+
+// wizard 与界面无关的安装向导状态机：决定显示哪些页面、页面间如何流转以及每页的校验，
+// 图形界面与控制台前端都只负责展示 State 并调用 Next/Back/Cancel。
+type wizard struct {
+	State     wizardState
+	Page      wizardPage
+	pages     []wizardPage
+	cancelled bool
+}
+
+func newWizard(st wizardState) *wizard {
+	w := &wizard{State: st}
+	if !st.Maintenance {
+		w.pages = append(w.pages, pageWelcome)
+		if strings.TrimSpace(st.LicenseText) != "" {
+			w.pages = append(w.pages, pageLicense)
+		}
+		w.pages = append(w.pages, pageDirectory)
+	}
+	if len(st.Components) > 0 {
+		w.pages = append(w.pages, pageComponents)
+	}
+	w.pages = append(w.pages, pageProgress, pageFinish)
+	w.Page = w.pages[0]
+	return w
+}
+
+// Pages 返回本次向导启用的页面
+func (w *wizard) Pages() []wizardPage { return w.pages }
+
+func (w *wizard) index() int {
+	for i, p := range w.pages {
+		if p == w.Page {
+			return i
+		}
+	}
+	return 0
+}
+
+// validate 校验当前页输入
+func (w *wizard) validate() error {
+	switch w.Page {
+	case pageLicense:
+		if !w.State.LicenseAccepted {
+			return errLicenseNotAccepted
+		}
+	case pageDirectory:
+		dir := strings.TrimSpace(w.State.InstallDir)
+		if dir == "" || !filepath.IsAbs(dir) {
+			return errInstallDirInvalid
+		}
+		if protectedDir(dir) {
+			return errInstallDirProtected
+		}
+		w.State.InstallDir = filepath.Clean(dir)
+	case pageComponents:
+		resolveDepends(w.State.Components)
+	}
+	return nil
+}
+
+// Next 校验当前页并前进；进入进度页时返回 actionInstall，完成页返回 actionFinish
+func (w *wizard) Next() (wizardAction, error) {
+	switch w.Page {
+	case pageProgress:
+		return actionNone, errWizardBusy
+	case pageFinish:
+		return actionFinish, nil
+	}
+	if err := w.validate(); err != nil {
+		return actionNone, err
+	}
+	w.Page = w.pages[w.index()+1]
+	if w.Page == pageProgress {
+		w.State.Progress = 0
+		return actionInstall, nil
+	}
+	return actionNone, nil
+}
+
+// CanBack 安装开始后不能返回
+func (w *wizard) CanBack() bool {
+	return w.index() > 0 && w.Page != pageProgress && w.Page != pageFinish
+}
+
+func (w *wizard) Back() {
+	if w.CanBack() {
+		w.Page = w.pages[w.index()-1]
+	}
+}
+
+// CanCancel 安装进行中与完成后不能取消
+func (w *wizard) CanCancel() bool { return w.Page != pageProgress && w.Page != pageFinish }
+
+func (w *wizard) Cancel() {
+	if w.CanCancel() {
+		w.cancelled = true
+	}
+}
+
+func (w *wizard) Cancelled() bool { return w.cancelled }
+
+// SetProgress 由安装过程回调
+func (w *wizard) SetProgress(percent int, status string) {
+	if percent < 0 {
+		percent = 0
+	}
+	if percent > 100 {
+		percent = 100
+	}
+	w.State.Progress = percent
+	if status != "" {
+		w.State.Status = status
+	}
+}
+
+// VersionNote 欢迎页中已安装版本与新版本的说明，未安装时为空
+func (w *wizard) VersionNote() string {
+	switch {
+	case w.State.InstalledVersion == "":
+		return ""
+	case sameVersion(w.State.InstalledVersion, w.State.Version):
+		return T("wizard.same_version", w.State.Version)
+	}
+	return T("wizard.version_change", w.State.InstalledVersion, w.State.Version)
+}
+
+// InstallDone 安装结束后进入完成页
+func (w *wizard) InstallDone(err error, canLaunch bool) {
+	w.State.Err = err
+	w.State.CanLaunch = err == nil && canLaunch && w.State.LaunchOffered
+	w.State.LaunchApp = w.State.CanLaunch && w.State.LaunchChecked
+	if err == nil {
+		w.State.Progress = 100
+	}
+	w.Page = pageFinish
+}
+
+// SetComponent 切换组件选择：选中时一并选中其依赖，取消时一并取消依赖它的组件；必选组件不能取消
+func (w *wizard) SetComponent(i int, selected bool) {
+	comps := w.State.Components
+	if i < 0 || i >= len(comps) || comps[i].Required && !selected || comps[i].Selected == selected {
+		return
+	}
+	comps[i].Selected = selected
+	if selected {
+		resolveDepends(comps)
+		return
+	}
+	for j, c := range comps {
+		for _, d := range c.Depends {
+			if d == comps[i].Name && c.Selected {
+				w.SetComponent(j, false)
+			}
+		}
+	}
+}
+
+// SelectedComponents 返回已选择的组件名
+func (w *wizard) SelectedComponents() []string {
+	return selectedComponentNames(w.State.Components)
+}
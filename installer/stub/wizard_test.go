@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWizardPages(t *testing.T) {
+	comps := []wizardComponent{{Name: "core", Required: true}}
+	tests := []struct {
+		name  string
+		state wizardState
+		want  []wizardPage
+	}{
+		{"minimal", wizardState{}, []wizardPage{pageWelcome, pageDirectory, pageProgress, pageFinish}},
+		{"license", wizardState{LicenseText: "EULA"}, []wizardPage{pageWelcome, pageLicense, pageDirectory, pageProgress, pageFinish}},
+		{"blank license", wizardState{LicenseText: " \n"}, []wizardPage{pageWelcome, pageDirectory, pageProgress, pageFinish}},
+		{"components", wizardState{LicenseText: "EULA", Components: comps},
+			[]wizardPage{pageWelcome, pageLicense, pageDirectory, pageComponents, pageProgress, pageFinish}},
+		{"maintenance", wizardState{Maintenance: true, LicenseText: "EULA", Components: comps},
+			[]wizardPage{pageComponents, pageProgress, pageFinish}},
+	}
+	for _, tt := range tests {
+		w := newWizard(tt.state)
+		if got := w.Pages(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: pages = %v, want %v", tt.name, got, tt.want)
+		}
+		if w.Page != tt.want[0] {
+			t.Errorf("%s: first page = %v, want %v", tt.name, w.Page, tt.want[0])
+		}
+	}
+}
+
+func TestWizardValidation(t *testing.T) {
+	home := filepath.Join(t.TempDir(), "home")
+	setFakeKnownFolders(t, fakeKnownFolders{folderUserHome: home})
+	tests := []struct {
+		name     string
+		accepted bool
+		dir      string
+		wantPage wizardPage
+		wantErr  error
+	}{
+		{"license not accepted", false, "/opt/app", pageLicense, errLicenseNotAccepted},
+		{"relative dir", true, "app", pageDirectory, errInstallDirInvalid},
+		{"empty dir", true, "  ", pageDirectory, errInstallDirInvalid},
+		{"root dir", true, string(filepath.Separator), pageDirectory, errInstallDirProtected},
+		{"user home", true, home, pageDirectory, errInstallDirProtected},
+		{"parent of user home", true, filepath.Dir(home), pageDirectory, errInstallDirProtected},
+		{"valid", true, filepath.Join(home, "apps", "app") + string(filepath.Separator), pageProgress, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newWizard(wizardState{LicenseText: "EULA", InstallDir: tt.dir})
+			w.State.LicenseAccepted = tt.accepted
+			var err error
+			for err == nil && w.Page != pageProgress {
+				_, err = w.Next()
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if w.Page != tt.wantPage {
+				t.Errorf("stopped at %v, want %v", w.Page, tt.wantPage)
+			}
+			if tt.wantErr == nil && w.State.InstallDir != filepath.Join(home, "apps", "app") {
+				t.Errorf("install dir = %q, want it cleaned", w.State.InstallDir)
+			}
+		})
+	}
+}
+
+func TestWizardNavigation(t *testing.T) {
+	w := newWizard(wizardState{LicenseText: "EULA", LicenseAccepted: true, InstallDir: "/opt/app"})
+	steps := []struct {
+		do       string
+		wantPage wizardPage
+		wantAct  wizardAction
+	}{
+		{"back", pageWelcome, actionNone}, // 第一页不能返回
+		{"next", pageLicense, actionNone},
+		{"next", pageDirectory, actionNone},
+		{"back", pageLicense, actionNone},
+		{"next", pageDirectory, actionNone},
+		{"next", pageProgress, actionInstall},
+		{"back", pageProgress, actionNone}, // 安装开始后不能返回
+		{"next", pageProgress, actionNone},
+		{"done", pageFinish, actionNone},
+		{"back", pageFinish, actionNone},
+		{"next", pageFinish, actionFinish},
+	}
+	for i, s := range steps {
+		var act wizardAction
+		switch s.do {
+		case "next":
+			act, _ = w.Next()
+		case "back":
+			w.Back()
+		case "done":
+			w.InstallDone(nil, true)
+		}
+		if w.Page != s.wantPage || act != s.wantAct {
+			t.Fatalf("step %d (%s): page %v action %v, want %v %v", i, s.do, w.Page, act, s.wantPage, s.wantAct)
+		}
+	}
+}
+
+func TestWizardCancel(t *testing.T) {
+	tests := []struct {
+		page wizardPage
+		want bool
+	}{
+		{pageWelcome, true},
+		{pageLicense, true},
+		{pageDirectory, true},
+		{pageComponents, true},
+		{pageProgress, false},
+		{pageFinish, false},
+	}
+	for _, tt := range tests {
+		w := newWizard(wizardState{LicenseText: "EULA", Components: []wizardComponent{{Name: "a"}}})
+		w.Page = tt.page
+		w.Cancel()
+		if w.Cancelled() != tt.want {
+			t.Errorf("cancel on %v: cancelled = %v, want %v", tt.page, w.Cancelled(), tt.want)
+		}
+	}
+	w := newWizard(wizardState{InstallDir: "/opt/app"})
+	w.Next()
+	w.Next()
+	if _, err := w.Next(); !errors.Is(err, errWizardBusy) {
+		t.Errorf("Next during install: %v, want errWizardBusy", err)
+	}
+}
+
+// componentOp 一次 SetComponent 调用
+type componentOp struct {
+	i   int
+	sel bool
+}
+
+func TestWizardSetComponent(t *testing.T) {
+	// core 必选；plugins 依赖 sdk；extras 依赖 plugins
+	newComps := func() []wizardComponent {
+		return []wizardComponent{
+			{Name: "core", Required: true, Selected: true},
+			{Name: "sdk"},
+			{Name: "plugins", Depends: []string{"sdk"}},
+			{Name: "extras", Depends: []string{"plugins"}},
+			{Name: "docs", Selected: true},
+		}
+	}
+	tests := []struct {
+		name string
+		ops  []componentOp
+		want []string
+	}{
+		{"select pulls dependencies", []componentOp{{3, true}}, []string{"core", "sdk", "plugins", "extras", "docs"}},
+		{"deselect drops dependents", []componentOp{{3, true}, {1, false}}, []string{"core", "docs"}},
+		{"deselect middle keeps its dependencies", []componentOp{{3, true}, {2, false}}, []string{"core", "sdk", "docs"}},
+		{"required cannot be deselected", []componentOp{{0, false}}, []string{"core", "docs"}},
+		{"out of range ignored", []componentOp{{-1, true}, {9, true}}, []string{"core", "docs"}},
+	}
+	for _, tt := range tests {
+		w := newWizard(wizardState{Components: newComps()})
+		for _, op := range tt.ops {
+			w.SetComponent(op.i, op.sel)
+		}
+		if got := w.SelectedComponents(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: selected = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
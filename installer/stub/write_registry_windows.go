@@ -3,10 +3,14 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"golang.org/x/sys/windows/registry"
 )
@@ -24,7 +28,7 @@ func writeRegistry(meta InstallMeta, installDir, exePath string) error {
 		return fmt.Errorf("empty paths")
 	}
 
-	basePath := `Software\\` + meta.ProductName
+	basePath := `Software\` + meta.ProductName
 	if err := setValues(registry.CURRENT_USER, basePath, map[string]any{
 		"InstallDir": installDir,
 		"ExePath":    exePath,
@@ -39,31 +43,85 @@ func writeRegistry(meta InstallMeta, installDir, exePath string) error {
 		return fmt.Errorf("write base key: %w", err)
 	}
 
-	uninstallPath := `Software\\Microsoft\\Windows\\CurrentVersion\\Uninstall\\` + meta.ProductName
+	uninstallPath := `Software\Microsoft\Windows\CurrentVersion\Uninstall\` + meta.ProductName
 	uninstallExe := filepath.Join(installDir, "uninstall.exe")
 	if _, err := os.Stat(uninstallExe); err != nil {
 		// 如果尚未创建，尝试复制自身
 		_ = createUninstaller(installDir)
 	}
 	uninstallString := fmt.Sprintf("\"%s\"", uninstallExe)
-	if err := setValues(registry.CURRENT_USER, uninstallPath, map[string]any{
+	values := map[string]any{
 		"DisplayName":          meta.ProductName,
 		"DisplayVersion":       meta.Version,
 		"InstallLocation":      installDir,
-		"Publisher":            "",
 		"UninstallString":      uninstallString,
 		"QuietUninstallString": uninstallString + " /S",
-		"DisplayIcon":          exePath + ",0",
-		"NoModify":             uint32(1),
-		"NoRepair":             uint32(1),
-		"InstallSource":        filepath.Dir(exePath),
-	}); err != nil {
+		// “修改”运行维护程序，其中可选择修改组件或修复；非 MSI 安装没有单独的“修复”入口
+		"ModifyPath":    uninstallString + " /MODIFY",
+		"DisplayIcon":   displayIconValue(meta, installDir, exePath),
+		"NoModify":      uint32(0),
+		"NoRepair":      uint32(1),
+		"InstallDate":   time.Now().Format("20060102"),
+		"VersionMajor":  meta.VersionMajor,
+		"VersionMinor":  meta.VersionMinor,
+		"EstimatedSize": estimatedSizeKB(meta, installDir),
+		"Publisher":     meta.Publisher,
+		"URLInfoAbout":  meta.URLInfoAbout,
+		"HelpLink":      meta.HelpLink,
+		"URLUpdateInfo": meta.URLUpdateInfo,
+		"Contact":       meta.Contact,
+		"Comments":      meta.Comments,
+	}
+	if meta.Language != 0 {
+		values["Language"] = meta.Language
+	} else {
+		values["Language"] = nil // 删除旧版本写入的值
+	}
+	if self, err := os.Executable(); err == nil {
+		values["InstallSource"] = filepath.Dir(self)
+	}
+	if err := setValues(registry.CURRENT_USER, uninstallPath, values); err != nil {
 		return fmt.Errorf("write uninstall key: %w", err)
 	}
 
 	return nil
 }
 
+// displayIconValue 生成 DisplayIcon："<绝对路径>,<索引>"。
+// meta.DisplayIcon 为相对安装目录的路径，可带 ",索引" 后缀；为空时使用主程序图标。
+func displayIconValue(meta InstallMeta, installDir, exePath string) string {
+	icon, index := meta.DisplayIcon, "0"
+	if icon == "" {
+		return exePath + ",0"
+	}
+	if i := strings.LastIndex(icon, ","); i > 0 {
+		if _, err := strconv.Atoi(icon[i+1:]); err == nil {
+			icon, index = icon[:i], icon[i+1:]
+		}
+	}
+	if !filepath.IsAbs(icon) {
+		icon = filepath.Join(installDir, icon)
+	}
+	return icon + "," + index
+}
+
+// estimatedSizeKB 返回 EstimatedSize（单位 KB）。优先使用打包时记录的大小，缺失时统计安装目录。
+func estimatedSizeKB(meta InstallMeta, installDir string) uint32 {
+	size := meta.InstallSize
+	if size <= 0 {
+		_ = filepath.WalkDir(installDir, func(_ string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+			return nil
+		})
+	}
+	return uint32((size + 1023) / 1024)
+}
+
 func setValues(root registry.Key, path string, kv map[string]any) error {
 	k, _, err := registry.CreateKey(root, path, registry.SET_VALUE)
 	if err != nil {
@@ -73,6 +131,14 @@ func setValues(root registry.Key, path string, kv map[string]any) error {
 	for name, v := range kv {
 		switch val := v.(type) {
 		case string:
+			if val == "" {
+				// 可选信息为空时删除该值：既避免“应用和功能”显示空白字段，
+				// 也不让旧版本写入的 Publisher、HelpLink 等在升级后残留
+				if err := deleteValue(k, name); err != nil {
+					return err
+				}
+				continue
+			}
 			if err := k.SetStringValue(name, val); err != nil {
 				return err
 			}
@@ -80,6 +146,10 @@ func setValues(root registry.Key, path string, kv map[string]any) error {
 			if err := k.SetDWordValue(name, val); err != nil {
 				return err
 			}
+		case nil:
+			if err := deleteValue(k, name); err != nil {
+				return err
+			}
 		default:
 			// ignore unsupported types
 		}
@@ -87,6 +157,14 @@ func setValues(root registry.Key, path string, kv map[string]any) error {
 	return nil
 }
 
+// deleteValue 删除值，值不存在时不算错误
+func deleteValue(k registry.Key, name string) error {
+	if err := k.DeleteValue(name); err != nil && !errors.Is(err, registry.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
 // createUninstallScript 生成简单卸载脚本：删除注册表、快捷方式和安装目录。
 // 以下函数仅保留 sanitizePath 以防后续使用
 func sanitizePath(p string) string { return strings.Trim(p, "\"") }
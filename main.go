@@ -1,12 +1,28 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
 	"log"
+	"os"
 
 	"exe_installer/installer"
 )
 
+const usage = `用法:
+  exe_installer                                     按 main.go 中的配置打包
+  exe_installer inspect [-json] <setup>             显示容器头、meta.json 与文件列表
+  exe_installer extract [-o 目录] <setup> [文件...]  解出文件（支持通配符与 "hooks/" 目录前缀）
+  exe_installer verify <setup>                      校验完整性，失败时退出码为 1
+`
+
 func main() {
+	if len(os.Args) > 1 {
+		runCommand(os.Args[1], os.Args[2:])
+		return
+	}
 	err := installer.CreateInstaller(
 		"./stub.exe",
 		"./myproject.exe",
@@ -23,4 +39,96 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
-}
\ No newline at end of file
+}
+
+// runCommand 检查已生成的 setup：只读取文件内容，不会执行它
+func runCommand(name string, args []string) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	asJSON := fs.Bool("json", false, "以 JSON 输出（inspect）")
+	outDir := fs.String("o", ".", "解出目录（extract）")
+	switch name {
+	case "inspect", "extract", "verify":
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	_ = fs.Parse(args)
+	if fs.NArg() < 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	s, err := installer.OpenSetup(fs.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	switch name {
+	case "inspect":
+		if *asJSON {
+			printSetupJSON(s)
+		} else {
+			printSetup(s)
+		}
+	case "extract":
+		n, err := s.Extract(*outDir, fs.Args()[1:]...)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("已解出 %d 个文件到 %s\n", n, *outDir)
+	case "verify":
+		if err := s.Verify(); err != nil {
+			fmt.Fprintf(os.Stderr, "校验失败: %s\n%v\n", s.Path, err)
+			os.Exit(1)
+		}
+		fmt.Printf("校验通过: %s（%d 个文件）\n", s.Path, len(s.Files))
+	}
+}
+
+func printSetup(s *installer.Setup) {
+	fmt.Printf("文件:       %s (%d bytes)\n", s.Path, s.Size)
+	fmt.Printf("标记:       %s\n", s.Magic)
+	fmt.Printf("stub:       %d bytes, arch=%s\n", s.StubSize, orDash(s.StubArch))
+	fmt.Printf("归档:       %d bytes (tar.gz), 偏移 %d\n", s.ArchiveLen, s.StubSize)
+	if s.MetaJSON != nil {
+		var buf bytes.Buffer
+		if json.Indent(&buf, s.MetaJSON, "", "  ") == nil {
+			fmt.Printf("\nmeta.json:\n%s\n", buf.String())
+		} else {
+			fmt.Printf("\nmeta.json（无法解析）:\n%s\n", s.MetaJSON)
+		}
+	}
+	if len(s.Files) > 0 {
+		fmt.Printf("\n%-10s %-6s %-64s %s\n", "大小", "权限", "SHA-256", "路径")
+		for _, f := range s.Files {
+			fmt.Printf("%-10d %04o   %s %s\n", f.Size, f.Mode, f.SHA256, f.Name)
+		}
+	}
+}
+
+func printSetupJSON(s *installer.Setup) {
+	out := map[string]any{
+		"path":       s.Path,
+		"size":       s.Size,
+		"magic":      s.Magic,
+		"stubSize":   s.StubSize,
+		"stubArch":   s.StubArch,
+		"archiveLen": s.ArchiveLen,
+		"files":      s.Files,
+	}
+	if m, err := s.Meta(); err == nil {
+		out["meta"] = m
+	}
+	data, _ := json.MarshalIndent(out, "", "  ")
+	fmt.Println(string(data))
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}